@@ -0,0 +1,24 @@
+// Command alpacamock serves a local Alpaca-style brokerage API for forward tests.
+// Point broker.alpaca.base_url at it and move prices with POST /mock/price.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"massive-orb/internal/broker/alpacamock"
+)
+
+func main() {
+	var (
+		addr = flag.String("addr", "127.0.0.1:8099", "Listen address")
+		cash = flag.Float64("cash", 100000, "Starting cash")
+	)
+	flag.Parse()
+
+	log.Printf("Mock broker listening on http://%s (any API key works)", *addr)
+	if err := http.ListenAndServe(*addr, alpacamock.New(*cash)); err != nil {
+		log.Fatal(err)
+	}
+}
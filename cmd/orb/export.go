@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"massive-orb/internal/engine"
+	"massive-orb/internal/export"
+	"massive-orb/internal/store"
+)
+
+// runExport replays one session (or a from → to range) and writes the report tables to dir.
+// CSV writes one file per table; JSON writes a single document.
+func runExport(ctx context.Context, eng *engine.Engine, st *store.Store, loc *time.Location, fromISO, toISO, format, dir string) error {
+	if format != export.FormatCSV && format != export.FormatJSON {
+		return fmt.Errorf("invalid export format %q (csv|json)", format)
+	}
+	from, err := time.ParseInLocation("2006-01-02", fromISO, loc)
+	if err != nil {
+		return fmt.Errorf("invalid export date %q (want YYYY-MM-DD)", fromISO)
+	}
+
+	if toISO == "" {
+		err = eng.RunHistoricForDate(ctx, from)
+	} else {
+		to, perr := time.ParseInLocation("2006-01-02", toISO, loc)
+		if perr != nil {
+			return fmt.Errorf("invalid export end date %q (want YYYY-MM-DD)", toISO)
+		}
+		err = eng.RunHistoricRange(ctx, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	rep, rng := st.HistoricReports()
+	if toISO == "" {
+		rng = nil
+	}
+	if rep == nil && rng == nil {
+		return errors.New("replay produced no report")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	prefix := export.Prefix(rep, rng)
+	if format == export.FormatJSON {
+		return writeExportFile(filepath.Join(dir, prefix+".json"), func(f *os.File) error {
+			return export.WriteJSON(f, export.NewDocument(rep, rng))
+		})
+	}
+	for _, t := range export.Tables(rep, rng) {
+		t := t
+		if err := writeExportFile(filepath.Join(dir, prefix+"_"+t.Name+".csv"), func(f *os.File) error {
+			return export.WriteCSV(f, t.Rows)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExportFile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("exported %s", path)
+	return nil
+}
@@ -12,9 +12,14 @@ import (
 
 	"github.com/joho/godotenv"
 
+	"massive-orb/internal/broker"
 	"massive-orb/internal/config"
 	"massive-orb/internal/engine"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/massive"
+	"massive-orb/internal/mdcache"
 	"massive-orb/internal/openai"
+	"massive-orb/internal/reportdb"
 	"massive-orb/internal/server"
 	"massive-orb/internal/store"
 	"massive-orb/internal/watchlist"
@@ -25,6 +30,15 @@ func main() {
 		configPath    = flag.String("config", "config.yaml", "Path to config.yaml")
 		watchlistPath = flag.String("watchlist", "watchlist.yaml", "Path to watchlist.yaml")
 		historic      = flag.Bool("historic", false, "Run today's session in historic mode (REST replay, no audio)")
+		sweepPath     = flag.String("sweep", "", "Run a parameter sweep from this spec (see sweep.yaml.example), print the ranking and exit")
+		sweepOut      = flag.String("sweep-out", "", "Also write the full sweep report as JSON to this path")
+		wfPath        = flag.String("walkforward", "", "Run a walk-forward optimization from this spec (see walkforward.yaml.example), print the report and exit")
+		wfOut         = flag.String("walkforward-out", "", "Also write the full walk-forward report as JSON to this path")
+		exportDate    = flag.String("export-date", "", "Replay this session (YYYY-MM-DD), export the report tables and exit")
+		exportTo      = flag.String("export-to", "", "With -export-date: replay the range export-date → export-to instead of one session")
+		exportFormat  = flag.String("export-format", "csv", "Export format: csv (one file per table) or json")
+		exportDir     = flag.String("export-dir", ".", "Directory for exported files")
+		buildUniverse = flag.Bool("universe", false, "Rebuild the watchlist from the provider's ticker universe (config universe section), write it and exit")
 	)
 	flag.Parse()
 
@@ -35,14 +49,6 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	wl, err := watchlist.Load(*watchlistPath)
-	if err != nil {
-		log.Fatalf("failed to load watchlist: %v", err)
-	}
-	if len(wl) == 0 {
-		log.Fatalf("watchlist is empty")
-	}
-
 	openaiKey := os.Getenv("OPENAI_API_KEY")
 	massiveKey := os.Getenv("MASSIVE_API_KEY")
 	if massiveKey == "" {
@@ -52,8 +58,35 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	provider := massive.NewProvider(massiveKey, cfg.Massive.Feed, cfg.Massive.WSBatchSize)
+
+	// Offline modes replay REST data only: no audio, no server.
+	offline := *sweepPath != "" || *wfPath != "" || *exportDate != ""
+
+	// (NEW) generated watchlist: refreshed at live / historic startup when it is older than the last
+	// session (offline runs keep the file they were given)
+	if *buildUniverse {
+		if err := refreshUniverse(ctx, cfg, provider, *watchlistPath, true); err != nil {
+			log.Fatalf("universe build failed: %v", err)
+		}
+		return
+	}
+	if cfg.Universe.Enabled && !offline {
+		if err := refreshUniverse(ctx, cfg, provider, *watchlistPath, false); err != nil {
+			log.Printf("WARN: universe refresh failed, keeping %s: %v", *watchlistPath, err)
+		}
+	}
+
+	wl, err := watchlist.Load(*watchlistPath)
+	if err != nil {
+		log.Fatalf("failed to load watchlist: %v", err)
+	}
+	if len(wl) == 0 {
+		log.Fatalf("watchlist is empty")
+	}
+
 	st := store.New(cfg, wl)
-	if *historic {
+	if *historic || offline {
 		st.SetMode(store.ModeHistoric)
 	} else {
 		st.SetMode(store.ModeRealtime)
@@ -62,7 +95,9 @@ func main() {
 	// IMPORTANT:
 	// - In historic mode, never generate audio, even if OPENAI_API_KEY is set.
 	var tts *openai.TTSClient
-	if *historic {
+	if offline {
+		tts = nil
+	} else if *historic {
 		tts = nil
 		log.Printf("Historic mode enabled: audio disabled; replaying today's session via REST.")
 	} else {
@@ -72,8 +107,73 @@ func main() {
 		tts = openai.NewTTSClient(openaiKey, cfg.OpenAI.TTSModel, cfg.OpenAI.Voice, cfg.OpenAI.ResponseFormat)
 	}
 
-	eng := engine.New(cfg, st, massiveKey, tts)
-	srv := server.New(cfg, st, eng)
+	var md marketdata.MarketData = provider
+	if cfg.Cache.Enabled {
+		loc, _ := time.LoadLocation(cfg.Market.Timezone)
+		c, err := mdcache.New(md, cfg.Cache.Dir, cfg.Cache.MaxSizeMB*1024*1024, loc)
+		if err != nil {
+			log.Fatalf("failed to open market data cache: %v", err)
+		}
+		cs := c.Stats()
+		log.Printf("Market data cache: %s (%d files, %.1f MB)", cs.Dir, cs.Files, float64(cs.Bytes)/(1024*1024))
+		md = c
+	}
+
+	// Saved historic reports (not needed for offline sweeps, which also keep the DB lock free)
+	var reports *reportdb.DB
+	if cfg.Reports.Enabled && !offline {
+		reports, err = reportdb.Open(cfg.Reports.DBPath)
+		if err != nil {
+			log.Fatalf("failed to open report db: %v", err)
+		}
+		defer reports.Close()
+	}
+
+	eng := engine.New(cfg, st, md, tts, reports)
+
+	// Live order routing (never for historic replays)
+	if !*historic && !offline {
+		switch cfg.Broker.Provider {
+		case "paper":
+			eng.SetBroker(broker.NewPaper(cfg.Broker.Paper.StartingCash, cfg.Broker.Paper.BuyingPowerMultiplier))
+			log.Printf("Broker: paper (starting cash %.2f)", cfg.Broker.Paper.StartingCash)
+		case "alpaca":
+			keyID, secret := os.Getenv("ALPACA_API_KEY_ID"), os.Getenv("ALPACA_API_SECRET_KEY")
+			if keyID == "" || secret == "" {
+				log.Fatalf("broker.provider is alpaca but ALPACA_API_KEY_ID / ALPACA_API_SECRET_KEY are missing")
+			}
+			a := broker.NewAlpaca(cfg.Broker.Alpaca.BaseURL, keyID, secret, time.Duration(cfg.Broker.Alpaca.PollIntervalMS)*time.Millisecond)
+			if err := a.Sync(ctx); err != nil {
+				log.Fatalf("broker: cannot reach %s: %v", cfg.Broker.Alpaca.BaseURL, err)
+			}
+			eng.SetBroker(a)
+			go a.Run(ctx)
+			log.Printf("Broker: alpaca (%s)", cfg.Broker.Alpaca.BaseURL)
+		}
+	}
+
+	if *sweepPath != "" {
+		if err := runSweep(ctx, eng, *sweepPath, *sweepOut); err != nil {
+			log.Fatalf("sweep failed: %v", err)
+		}
+		return
+	}
+	if *wfPath != "" {
+		if err := runWalkForward(ctx, eng, *wfPath, *wfOut); err != nil {
+			log.Fatalf("walk-forward failed: %v", err)
+		}
+		return
+	}
+
+	if *exportDate != "" {
+		loc, _ := time.LoadLocation(cfg.Market.Timezone)
+		if err := runExport(ctx, eng, st, loc, *exportDate, *exportTo, *exportFormat, *exportDir); err != nil {
+			log.Fatalf("export failed: %v", err)
+		}
+		return
+	}
+
+	srv := server.New(cfg, st, eng, md, reports)
 
 	go func() {
 		var runErr error
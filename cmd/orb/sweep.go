@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"massive-orb/internal/engine"
+	"massive-orb/internal/store"
+)
+
+func runSweep(ctx context.Context, eng *engine.Engine, specPath, outPath string) error {
+	spec, err := engine.LoadSweepSpec(specPath)
+	if err != nil {
+		return err
+	}
+	rep, err := eng.RunSweep(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	printSweepReport(os.Stdout, rep)
+	return writeReportJSON(outPath, rep)
+}
+
+func runWalkForward(ctx context.Context, eng *engine.Engine, specPath, outPath string) error {
+	spec, err := engine.LoadWalkForwardSpec(specPath)
+	if err != nil {
+		return err
+	}
+	rep, err := eng.RunWalkForward(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	printWalkForwardReport(os.Stdout, rep)
+	return writeReportJSON(outPath, rep)
+}
+
+func writeReportJSON(path string, v any) error {
+	if path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return err
+	}
+	log.Printf("report written to %s", path)
+	return nil
+}
+
+func printSweepReport(w io.Writer, rep *engine.SweepReport) {
+	fmt.Fprintf(w, "Sweep: %d combinations over %d sessions (%s → %s), ranked by %s\n",
+		rep.Combos, len(rep.Sessions), first(rep.Sessions), last(rep.Sessions), rep.RankBy)
+	if len(rep.Skipped) > 0 {
+		fmt.Fprintf(w, "Skipped (no data / incomplete): %v\n", rep.Skipped)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\ttrades\twin%\tnet P/L\tgross P/L\tPF\tmaxDD\tside\tentry\trng%\tvol\ttoday%\tentry min\tprice\tTP%\tSL%\t")
+	for _, r := range rep.Results {
+		f := r.Params.Filters
+		fmt.Fprintf(tw, "%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%s\t%s\t%.2f–%.2f\t%.0f–%.0f\t%.0f–%.0f\t%d–%d\t%.2f–%.2f\t%.2f\t%.2f\t\n",
+			r.Rank,
+			r.Summary.TradesTaken,
+			r.Summary.WinRate*100,
+			r.Summary.NetPnL,
+			r.Summary.GrossPnL,
+			r.Summary.ProfitFactor,
+			r.MaxDrawdown,
+			f.Side,
+			f.EntryMode,
+			f.Open5mRangePctMin*100, f.Open5mRangePctMax*100,
+			f.Open5mVolMin, f.Open5mVolMax,
+			f.Open5mTodayPctMin, f.Open5mTodayPctMax,
+			f.EntryMinAfterOpen, f.EntryMaxAfterOpen,
+			f.EntryPriceMin, f.EntryPriceMax,
+			r.Params.TakeProfitPct*100,
+			r.Params.StopLossPct*100,
+		)
+	}
+	_ = tw.Flush()
+}
+
+func printWalkForwardReport(w io.Writer, rep *engine.WalkForwardReport) {
+	fmt.Fprintf(w, "Walk-forward: %d combinations, IS=%d / OOS=%d / step=%d sessions over %d sessions (%s → %s), ranked by %s\n",
+		rep.Combos, rep.InSampleSessions, rep.OutOfSampleSessions, rep.StepSessions,
+		len(rep.Sessions), first(rep.Sessions), last(rep.Sessions), rep.RankBy)
+	if len(rep.Skipped) > 0 {
+		fmt.Fprintf(w, "Skipped (no data / incomplete): %v\n", rep.Skipped)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\tin-sample\tIS trades\tIS net\tout-of-sample\tOOS trades\tOOS net\trng%\tvol\ttoday%\tTP%\tSL%\t")
+	for _, win := range rep.Windows {
+		f := win.Params.Filters
+		fmt.Fprintf(tw, "%d\t%s→%s\t%d\t%.2f\t%s→%s\t%d\t%.2f\t%.2f–%.2f\t%.0f–%.0f\t%.0f–%.0f\t%.2f\t%.2f\t\n",
+			win.Index,
+			win.InSampleFrom, win.InSampleTo,
+			win.InSample.Summary.TradesTaken,
+			win.InSample.Summary.NetPnL,
+			win.OutOfSampleFrom, win.OutOfSampleTo,
+			win.OutOfSample.Summary.TradesTaken,
+			win.OutOfSample.Summary.NetPnL,
+			f.Open5mRangePctMin*100, f.Open5mRangePctMax*100,
+			f.Open5mVolMin, f.Open5mVolMax,
+			f.Open5mTodayPctMin, f.Open5mTodayPctMax,
+			win.Params.TakeProfitPct*100,
+			win.Params.StopLossPct*100,
+		)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tsessions\ttrades\twin%\tnet P/L\tPF\tavg day\tmaxDD\t")
+	for _, row := range []struct {
+		name string
+		r    *store.HistoricRangeReport
+	}{{"in-sample", rep.InSample}, {"out-of-sample", rep.OutOfSample}} {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			row.name,
+			row.r.Sessions,
+			row.r.Summary.TradesTaken,
+			row.r.Summary.WinRate*100,
+			row.r.Summary.NetPnL,
+			row.r.Summary.ProfitFactor,
+			row.r.AvgDayPnL,
+			row.r.MaxDrawdown,
+		)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\nWalk-forward efficiency (OOS avg day / IS avg day): %.2f\n", rep.Efficiency)
+}
+
+func first(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+func last(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1]
+}
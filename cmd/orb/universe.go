@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/config"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/watchlist"
+)
+
+// groupedDailyReadyHMS: a session's grouped daily bars are complete after this (NY).
+const groupedDailyReadyHMS = "17:00:00"
+
+// universeAsOf is the latest session with complete daily bars: yesterday's session until today's
+// are in, so a refresh before the open filters on the previous close.
+func universeAsOf(nowNY time.Time) time.Time {
+	var hh, mm, ss int
+	_, _ = fmt.Sscanf(groupedDailyReadyHMS, "%d:%d:%d", &hh, &mm, &ss)
+	ready := time.Date(nowNY.Year(), nowNY.Month(), nowNY.Day(), hh, mm, ss, 0, nowNY.Location())
+	if calendar.IsTradingDay(nowNY) && nowNY.Before(ready) {
+		return calendar.PrevTradingDay(nowNY)
+	}
+	return calendar.LastTradingDay(nowNY)
+}
+
+// refreshUniverse rebuilds the watchlist file at path from the provider's ticker universe when it
+// was built from an older session than universeAsOf (always with force). A file without a universe
+// block is hand-written: the automatic refresh leaves it alone, force replaces it and keeps a .bak.
+func refreshUniverse(ctx context.Context, cfg config.Config, ref marketdata.Reference, path string, force bool) error {
+	loc, err := time.LoadLocation(cfg.Market.Timezone)
+	if err != nil {
+		return err
+	}
+	asOf := universeAsOf(time.Now().In(loc))
+	have := watchlist.UniverseAsOf(path)
+	if !force && have == asOf.Format("2006-01-02") {
+		log.Printf("Universe: %s is current (as of %s)", path, have)
+		return nil
+	}
+	var handWritten []byte
+	if have == "" {
+		if b, err := os.ReadFile(path); err == nil {
+			if !force {
+				return fmt.Errorf("%s has no universe block (hand-written?); run with -universe to replace it", path)
+			}
+			handWritten = b
+		}
+	}
+
+	u := cfg.Universe
+	log.Printf("Universe: building %s from %v tickers on %v, %d session(s) ending %s...",
+		path, u.Types, u.Exchanges, u.LookbackSessions, asOf.Format("2006-01-02"))
+	syms, info, err := watchlist.BuildUniverse(ctx, ref, watchlist.UniverseFilter{
+		Types:            u.Types,
+		Exchanges:        u.Exchanges,
+		PriceMin:         u.PriceMin,
+		PriceMax:         u.PriceMax,
+		AvgVolumeMin:     u.AvgVolumeMin,
+		LookbackSessions: u.LookbackSessions,
+	}, asOf)
+	if err != nil {
+		return err
+	}
+	if len(syms) == 0 {
+		return fmt.Errorf("no tickers passed the universe filters (%d listed)", info.Listed)
+	}
+	if handWritten != nil {
+		if err := os.WriteFile(path+".bak", handWritten, 0o644); err != nil {
+			return fmt.Errorf("back up %s: %w", path, err)
+		}
+		log.Printf("Universe: kept the previous %s as %s.bak", path, path)
+	}
+	if err := watchlist.Save(path, syms, info); err != nil {
+		return err
+	}
+	log.Printf("Universe: wrote %d/%d tickers to %s", info.Kept, info.Listed, path)
+	return nil
+}
@@ -0,0 +1,460 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---- Alpaca-style REST wire format (v2 orders / positions / account) ----
+
+type AlpacaLeg struct {
+	LimitPrice string `json:"limit_price,omitempty"`
+	StopPrice  string `json:"stop_price,omitempty"`
+}
+
+type AlpacaOrderRequest struct {
+	Symbol        string     `json:"symbol"`
+	Qty           string     `json:"qty"`
+	Side          string     `json:"side"`
+	Type          string     `json:"type"`
+	TimeInForce   string     `json:"time_in_force"`
+	LimitPrice    string     `json:"limit_price,omitempty"`
+	StopPrice     string     `json:"stop_price,omitempty"`
+	ClientOrderID string     `json:"client_order_id,omitempty"`
+	OrderClass    string     `json:"order_class,omitempty"` // "" | bracket
+	TakeProfit    *AlpacaLeg `json:"take_profit,omitempty"`
+	StopLoss      *AlpacaLeg `json:"stop_loss,omitempty"`
+}
+
+type AlpacaOrder struct {
+	ID             string        `json:"id"`
+	ClientOrderID  string        `json:"client_order_id"`
+	Symbol         string        `json:"symbol"`
+	Qty            string        `json:"qty"`
+	FilledQty      string        `json:"filled_qty"`
+	FilledAvgPrice *string       `json:"filled_avg_price"`
+	Side           string        `json:"side"`
+	Type           string        `json:"type"`
+	LimitPrice     *string       `json:"limit_price"`
+	StopPrice      *string       `json:"stop_price"`
+	Status         string        `json:"status"`
+	OrderClass     string        `json:"order_class"`
+	SubmittedAt    time.Time     `json:"submitted_at"`
+	FilledAt       *time.Time    `json:"filled_at"`
+	Legs           []AlpacaOrder `json:"legs,omitempty"`
+}
+
+type AlpacaPosition struct {
+	Symbol        string `json:"symbol"`
+	Qty           string `json:"qty"`  // negative for shorts
+	Side          string `json:"side"` // long | short
+	AvgEntryPrice string `json:"avg_entry_price"`
+	CurrentPrice  string `json:"current_price"`
+	MarketValue   string `json:"market_value"`
+	UnrealizedPL  string `json:"unrealized_pl"`
+}
+
+type AlpacaAccount struct {
+	Cash        string `json:"cash"`
+	BuyingPower string `json:"buying_power"`
+	Equity      string `json:"equity"`
+	LastEquity  string `json:"last_equity"`
+}
+
+type alpacaError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ToAlpacaRequest converts an OrderRequest (brackets included) to the wire format.
+func ToAlpacaRequest(req OrderRequest) AlpacaOrderRequest {
+	out := AlpacaOrderRequest{
+		Symbol:        req.Symbol,
+		Qty:           strconv.Itoa(req.Qty),
+		Side:          string(req.Side),
+		Type:          string(req.Type),
+		TimeInForce:   "day",
+		LimitPrice:    priceStr(req.LimitPrice),
+		StopPrice:     priceStr(req.StopPrice),
+		ClientOrderID: req.ClientID,
+	}
+	if req.Bracket() {
+		out.OrderClass = "bracket"
+		out.TakeProfit = &AlpacaLeg{LimitPrice: priceStr(req.TakeProfit)}
+		out.StopLoss = &AlpacaLeg{StopPrice: priceStr(req.StopLoss)}
+	}
+	return out
+}
+
+// FromAlpacaRequest is the inverse of ToAlpacaRequest (used by the mock server).
+func FromAlpacaRequest(a AlpacaOrderRequest) OrderRequest {
+	req := OrderRequest{
+		Symbol:     strings.ToUpper(a.Symbol),
+		Side:       Side(a.Side),
+		Type:       OrderType(a.Type),
+		Qty:        int(parseNum(a.Qty)),
+		LimitPrice: parseNum(a.LimitPrice),
+		StopPrice:  parseNum(a.StopPrice),
+		ClientID:   a.ClientOrderID,
+	}
+	if a.OrderClass == "bracket" && a.TakeProfit != nil && a.StopLoss != nil {
+		req.TakeProfit = parseNum(a.TakeProfit.LimitPrice)
+		req.StopLoss = parseNum(a.StopLoss.StopPrice)
+	}
+	return req
+}
+
+// ToAlpacaOrder converts an order (legs attached by the caller) to the wire format.
+func ToAlpacaOrder(o Order, legs []Order) AlpacaOrder {
+	a := AlpacaOrder{
+		ID:            o.ID,
+		ClientOrderID: o.ClientID,
+		Symbol:        o.Symbol,
+		Qty:           strconv.Itoa(o.Qty),
+		FilledQty:     strconv.Itoa(o.FilledQty),
+		Side:          string(o.Side),
+		Type:          string(o.Type),
+		Status:        string(o.Status),
+		SubmittedAt:   o.SubmittedAt,
+	}
+	if o.Bracket() {
+		a.OrderClass = "bracket"
+	}
+	if o.LimitPrice > 0 {
+		s := priceStr(o.LimitPrice)
+		a.LimitPrice = &s
+	}
+	if o.StopPrice > 0 {
+		s := priceStr(o.StopPrice)
+		a.StopPrice = &s
+	}
+	if o.FilledQty > 0 {
+		s := priceStr(o.FilledAvgPrice)
+		a.FilledAvgPrice = &s
+		t := o.FilledAt
+		a.FilledAt = &t
+	}
+	for _, l := range legs {
+		a.Legs = append(a.Legs, ToAlpacaOrder(l, nil))
+	}
+	return a
+}
+
+// orders flattens a (nested) wire order into the entry plus its legs.
+func (a AlpacaOrder) orders() []Order {
+	o := Order{
+		ID: a.ID,
+		OrderRequest: OrderRequest{
+			Symbol:   a.Symbol,
+			Side:     Side(a.Side),
+			Type:     OrderType(a.Type),
+			Qty:      int(parseNum(a.Qty)),
+			ClientID: a.ClientOrderID,
+		},
+		Status:      alpacaStatus(a.Status),
+		SubmittedAt: a.SubmittedAt,
+		FilledQty:   int(parseNum(a.FilledQty)),
+	}
+	if a.LimitPrice != nil {
+		o.LimitPrice = parseNum(*a.LimitPrice)
+	}
+	if a.StopPrice != nil {
+		o.StopPrice = parseNum(*a.StopPrice)
+	}
+	if a.FilledAvgPrice != nil {
+		o.FilledAvgPrice = parseNum(*a.FilledAvgPrice)
+	}
+	if a.FilledAt != nil {
+		o.FilledAt = *a.FilledAt
+	}
+	if o.Status == StatusRejected || o.Status == StatusCanceled {
+		o.Reason = a.Status
+	}
+
+	out := []Order{o}
+	for _, l := range a.Legs {
+		for _, lo := range l.orders() {
+			lo.ParentID = a.ID
+			switch lo.Type {
+			case Limit:
+				lo.Leg = LegTakeProfit
+				out[0].TakeProfit = lo.LimitPrice
+			case Stop:
+				lo.Leg = LegStopLoss
+				out[0].StopLoss = lo.StopPrice
+			}
+			out = append(out, lo)
+		}
+	}
+	return out
+}
+
+// alpacaStatus folds the brokerage's many states into ours. "held" legs (waiting for the
+// entry to fill) and the pending/accepted states all count as open.
+func alpacaStatus(s string) OrderStatus {
+	switch s {
+	case "filled":
+		return StatusFilled
+	case "partially_filled":
+		return StatusPartial
+	case "canceled", "expired", "done_for_day", "replaced", "pending_cancel":
+		return StatusCanceled
+	case "rejected", "suspended", "stopped":
+		return StatusRejected
+	default:
+		return StatusNew
+	}
+}
+
+func priceStr(v float64) string {
+	if v <= 0 {
+		return ""
+	}
+	// brokerages reject sub-penny prices above $1
+	if v >= 1 {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+func parseNum(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v
+}
+
+// ---- Adapter ----
+
+// Alpaca talks to an Alpaca-style brokerage REST API. Orders, positions and the account are
+// polled (Run) and served from that cache; status changes are pushed to the OnUpdate callback.
+type Alpaca struct {
+	baseURL string
+	keyID   string
+	secret  string
+	poll    time.Duration
+	hc      *http.Client
+	since   time.Time
+
+	mu        sync.Mutex
+	orders    map[string]Order
+	positions []Position
+	account   Account
+	onUpdate  func(Order)
+}
+
+func NewAlpaca(baseURL, keyID, secret string, poll time.Duration) *Alpaca {
+	if poll <= 0 {
+		poll = time.Second
+	}
+	return &Alpaca{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		keyID:   keyID,
+		secret:  secret,
+		poll:    poll,
+		hc:      &http.Client{Timeout: 10 * time.Second},
+		since:   time.Now().Add(-12 * time.Hour),
+		orders:  make(map[string]Order, 64),
+	}
+}
+
+func (a *Alpaca) Name() string { return "alpaca" }
+
+func (a *Alpaca) OnUpdate(fn func(Order)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onUpdate = fn
+}
+
+// Run polls until ctx is done. Call it in its own goroutine.
+func (a *Alpaca) Run(ctx context.Context) {
+	t := time.NewTicker(a.poll)
+	defer t.Stop()
+	for {
+		if err := a.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("alpaca sync failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Sync refreshes orders, positions and account once.
+func (a *Alpaca) Sync(ctx context.Context) error {
+	var wire []AlpacaOrder
+	q := url.Values{}
+	q.Set("status", "all")
+	q.Set("nested", "true")
+	q.Set("limit", "500")
+	q.Set("after", a.since.UTC().Format(time.RFC3339))
+	if err := a.do(ctx, http.MethodGet, "/v2/orders?"+q.Encode(), nil, &wire); err != nil {
+		return err
+	}
+	var pos []AlpacaPosition
+	if err := a.do(ctx, http.MethodGet, "/v2/positions", nil, &pos); err != nil {
+		return err
+	}
+	var acct AlpacaAccount
+	if err := a.do(ctx, http.MethodGet, "/v2/account", nil, &acct); err != nil {
+		return err
+	}
+
+	positions := make([]Position, 0, len(pos))
+	for _, p := range pos {
+		qty := int(parseNum(p.Qty))
+		if p.Side == "short" && qty > 0 {
+			qty = -qty
+		}
+		positions = append(positions, Position{
+			Symbol:        p.Symbol,
+			Qty:           qty,
+			AvgPrice:      parseNum(p.AvgEntryPrice),
+			LastPrice:     parseNum(p.CurrentPrice),
+			MarketValue:   parseNum(p.MarketValue),
+			UnrealizedPnL: parseNum(p.UnrealizedPL),
+		})
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+
+	a.mu.Lock()
+	var changed []Order
+	for _, w := range wire {
+		for _, o := range w.orders() {
+			prev, seen := a.orders[o.ID]
+			a.orders[o.ID] = o
+			if !seen || prev.Status != o.Status || prev.FilledQty != o.FilledQty {
+				changed = append(changed, o)
+			}
+		}
+	}
+	a.positions = positions
+	a.account = Account{
+		Cash:        parseNum(acct.Cash),
+		BuyingPower: parseNum(acct.BuyingPower),
+		Equity:      parseNum(acct.Equity),
+		RealizedPnL: parseNum(acct.Equity) - parseNum(acct.LastEquity) - unrealized(positions),
+	}
+	notify := a.onUpdate
+	a.mu.Unlock()
+
+	if notify != nil {
+		for _, o := range changed {
+			notify(o)
+		}
+	}
+	return nil
+}
+
+func unrealized(ps []Position) float64 {
+	v := 0.0
+	for _, p := range ps {
+		v += p.UnrealizedPnL
+	}
+	return v
+}
+
+func (a *Alpaca) Submit(ctx context.Context, req OrderRequest) (Order, error) {
+	var w AlpacaOrder
+	if err := a.do(ctx, http.MethodPost, "/v2/orders", ToAlpacaRequest(req), &w); err != nil {
+		return Order{}, err
+	}
+	// not cached here: the next Sync reports it (and any immediate fill) through OnUpdate
+	return w.orders()[0], nil
+}
+
+func (a *Alpaca) Cancel(ctx context.Context, id string) error {
+	return a.do(ctx, http.MethodDelete, "/v2/orders/"+url.PathEscape(id), nil, nil)
+}
+
+func (a *Alpaca) CloseAll(ctx context.Context) error {
+	return a.do(ctx, http.MethodDelete, "/v2/positions?cancel_orders=true", nil, nil)
+}
+
+func (a *Alpaca) Orders() []Order {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]Order, 0, len(a.orders))
+	for _, o := range a.orders {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].SubmittedAt.Equal(out[j].SubmittedAt) {
+			return out[i].SubmittedAt.After(out[j].SubmittedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	return out
+}
+
+func (a *Alpaca) Positions() []Position {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Position(nil), a.positions...)
+}
+
+func (a *Alpaca) Account() Account {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.account
+}
+
+func (a *Alpaca) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("APCA-API-KEY-ID", a.keyID)
+	req.Header.Set("APCA-API-SECRET-KEY", a.secret)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := a.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(res.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(b))
+		var ae alpacaError
+		if json.Unmarshal(b, &ae) == nil && ae.Message != "" {
+			msg = ae.Message
+		}
+		switch {
+		case res.StatusCode == http.StatusNotFound && method == http.MethodDelete:
+			return ErrOrderNotFound
+		case res.StatusCode == http.StatusUnprocessableEntity && method == http.MethodDelete:
+			return ErrNotOpen
+		}
+		return fmt.Errorf("%s %s: %d %s", method, path, res.StatusCode, msg)
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,249 @@
+// Package alpacamock is a local stand-in for an Alpaca-style brokerage REST API.
+// Orders are held by a paper broker; prices move only when a print is posted to /mock/price.
+//
+//	POST   /v2/orders            submit (bracket supported)
+//	GET    /v2/orders            list (?nested=true groups legs under their entry)
+//	GET    /v2/orders/{id}
+//	DELETE /v2/orders/{id}       cancel
+//	GET    /v2/positions
+//	DELETE /v2/positions         cancel everything and flatten
+//	GET    /v2/account
+//	POST   /mock/price           {"symbol":"ABCD","price":12.34} → fills whatever it reaches
+package alpacamock
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"massive-orb/internal/broker"
+)
+
+type Server struct {
+	paper *broker.Paper
+	mux   *http.ServeMux
+
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+func New(startingCash float64) *Server {
+	s := &Server{
+		paper: broker.NewPaper(startingCash, 1),
+		mux:   http.NewServeMux(),
+		last:  make(map[string]float64, 16),
+	}
+	s.mux.HandleFunc("/v2/orders", s.handleOrders)
+	s.mux.HandleFunc("/v2/orders/", s.handleOrder)
+	s.mux.HandleFunc("/v2/positions", s.handlePositions)
+	s.mux.HandleFunc("/v2/account", s.handleAccount)
+	s.mux.HandleFunc("/mock/price", s.handlePrice)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/mock/") {
+		if r.Header.Get("APCA-API-KEY-ID") == "" || r.Header.Get("APCA-API-SECRET-KEY") == "" {
+			writeErr(w, http.StatusUnauthorized, "missing API key headers")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Print feeds one trade print to the book (same as POST /mock/price).
+func (s *Server) Print(sym string, price float64) {
+	sym = strings.ToUpper(sym)
+	s.mu.Lock()
+	s.last[sym] = price
+	s.mu.Unlock()
+	s.paper.OnTrade(sym, time.Now(), price, 0)
+}
+
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var in broker.AlpacaOrderRequest
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeErr(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		o, err := s.paper.Submit(r.Context(), broker.FromAlpacaRequest(in))
+		if err != nil {
+			writeErr(w, http.StatusForbidden, err.Error())
+			return
+		}
+		// market orders fill right away when the mock already has a price for the symbol
+		s.mu.Lock()
+		px := s.last[o.Symbol]
+		s.mu.Unlock()
+		if o.Type == broker.Market && px > 0 {
+			s.paper.OnTrade(o.Symbol, time.Now(), px, 0)
+		}
+		o, _ = s.find(o.ID)
+		writeJSON(w, http.StatusOK, s.wire(o))
+
+	case http.MethodGet:
+		all := s.paper.Orders()
+		nested := r.URL.Query().Get("nested") == "true"
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		out := make([]broker.AlpacaOrder, 0, len(all))
+		for _, o := range all {
+			if nested && o.ParentID != "" {
+				continue
+			}
+			if nested {
+				out = append(out, s.wire(o))
+			} else {
+				out = append(out, broker.ToAlpacaOrder(o, nil))
+			}
+			if limit > 0 && len(out) >= limit {
+				break
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v2/orders/"), "/")
+	switch r.Method {
+	case http.MethodGet:
+		o, ok := s.find(id)
+		if !ok {
+			writeErr(w, http.StatusNotFound, "order not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, s.wire(o))
+
+	case http.MethodDelete:
+		err := s.paper.Cancel(r.Context(), id)
+		switch {
+		case errors.Is(err, broker.ErrOrderNotFound):
+			writeErr(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, broker.ErrNotOpen):
+			writeErr(w, http.StatusUnprocessableEntity, err.Error())
+		case err != nil:
+			writeErr(w, http.StatusInternalServerError, err.Error())
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handlePositions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		out := make([]broker.AlpacaPosition, 0, 8)
+		for _, p := range s.paper.Positions() {
+			if p.Qty == 0 {
+				continue
+			}
+			side := "long"
+			if p.Qty < 0 {
+				side = "short"
+			}
+			out = append(out, broker.AlpacaPosition{
+				Symbol:        p.Symbol,
+				Qty:           strconv.Itoa(p.Qty),
+				Side:          side,
+				AvgEntryPrice: num(p.AvgPrice),
+				CurrentPrice:  num(p.LastPrice),
+				MarketValue:   num(p.MarketValue),
+				UnrealizedPL:  num(p.UnrealizedPnL),
+			})
+		}
+		writeJSON(w, http.StatusOK, out)
+
+	case http.MethodDelete:
+		if err := s.paper.CloseAll(r.Context()); err != nil {
+			writeErr(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusMultiStatus, []any{})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a := s.paper.Account()
+	writeJSON(w, http.StatusOK, broker.AlpacaAccount{
+		Cash:        num(a.Cash),
+		BuyingPower: num(a.BuyingPower),
+		Equity:      num(a.Equity),
+		LastEquity:  num(a.Equity - a.RealizedPnL - unrealized(s.paper.Positions())),
+	})
+}
+
+func (s *Server) handlePrice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var in struct {
+		Symbol string  `json:"symbol"`
+		Price  float64 `json:"price"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Symbol == "" || in.Price <= 0 {
+		writeErr(w, http.StatusBadRequest, "expected {symbol, price>0}")
+		return
+	}
+	s.Print(in.Symbol, in.Price)
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func (s *Server) find(id string) (broker.Order, bool) {
+	for _, o := range s.paper.Orders() {
+		if o.ID == id {
+			return o, true
+		}
+	}
+	return broker.Order{}, false
+}
+
+// wire renders o with its bracket legs nested, like the real API.
+func (s *Server) wire(o broker.Order) broker.AlpacaOrder {
+	var legs []broker.Order
+	for _, l := range s.paper.Orders() {
+		if l.ParentID == o.ID {
+			legs = append(legs, l)
+		}
+	}
+	return broker.ToAlpacaOrder(o, legs)
+}
+
+func unrealized(ps []broker.Position) float64 {
+	v := 0.0
+	for _, p := range ps {
+		v += p.UnrealizedPnL
+	}
+	return v
+}
+
+func num(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeErr(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]any{"code": status, "message": msg})
+}
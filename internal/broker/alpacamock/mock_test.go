@@ -0,0 +1,170 @@
+package alpacamock
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"massive-orb/internal/broker"
+)
+
+// newClient starts the mock and an Alpaca client pointed at it; updates collects OnUpdate.
+func newClient(t *testing.T) (*Server, *broker.Alpaca, *updates) {
+	t.Helper()
+	mock := New(100000)
+	ts := httptest.NewServer(mock)
+	t.Cleanup(ts.Close)
+
+	a := broker.NewAlpaca(ts.URL, "key", "secret", time.Second)
+	u := &updates{}
+	a.OnUpdate(u.add)
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatalf("initial sync: %v", err)
+	}
+	return mock, a, u
+}
+
+type updates struct {
+	mu  sync.Mutex
+	got []broker.Order
+}
+
+func (u *updates) add(o broker.Order) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.got = append(u.got, o)
+}
+
+// last is the newest update for the entry (leg "") or one of its legs.
+func (u *updates) last(leg string) (broker.Order, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i := len(u.got) - 1; i >= 0; i-- {
+		if u.got[i].Leg == leg {
+			return u.got[i], true
+		}
+	}
+	return broker.Order{}, false
+}
+
+func mustSync(t *testing.T, a *broker.Alpaca) {
+	t.Helper()
+	if err := a.Sync(context.Background()); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+}
+
+func position(a *broker.Alpaca, sym string) int {
+	for _, p := range a.Positions() {
+		if p.Symbol == sym {
+			return p.Qty
+		}
+	}
+	return 0
+}
+
+func submitBracket(t *testing.T, mock *Server, a *broker.Alpaca) broker.Order {
+	t.Helper()
+	mock.Print("ABCD", 10)
+	o, err := a.Submit(context.Background(), broker.OrderRequest{
+		Symbol:     "ABCD",
+		Side:       broker.Buy,
+		Type:       broker.Market,
+		Qty:        100,
+		ClientID:   "ORB-BUY-ABCD-1",
+		TakeProfit: 11,
+		StopLoss:   9.5,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if !o.Bracket() {
+		t.Fatalf("submitted order lost its bracket: %+v", o)
+	}
+	mustSync(t, a)
+	return o
+}
+
+func TestBracketSubmitAndFillSync(t *testing.T) {
+	mock, a, u := newClient(t)
+	o := submitBracket(t, mock, a)
+
+	entry, ok := u.last("")
+	if !ok || entry.ID != o.ID {
+		t.Fatalf("no update for the entry order %s", o.ID)
+	}
+	if entry.Status != broker.StatusFilled || entry.FilledQty != 100 || entry.FilledAvgPrice != 10 {
+		t.Fatalf("entry = %s %d @ %.4f, want filled 100 @ 10", entry.Status, entry.FilledQty, entry.FilledAvgPrice)
+	}
+	for _, leg := range []string{broker.LegTakeProfit, broker.LegStopLoss} {
+		l, ok := u.last(leg)
+		if !ok {
+			t.Fatalf("no %s leg reported", leg)
+		}
+		if l.ParentID != o.ID || !l.Open() {
+			t.Fatalf("%s leg = %+v, want open child of %s", leg, l, o.ID)
+		}
+	}
+	if q := position(a, "ABCD"); q != 100 {
+		t.Fatalf("position = %d, want 100", q)
+	}
+}
+
+func TestTakeProfitLegFill(t *testing.T) {
+	mock, a, u := newClient(t)
+	submitBracket(t, mock, a)
+
+	mock.Print("ABCD", 11.2)
+	mustSync(t, a)
+
+	tp, _ := u.last(broker.LegTakeProfit)
+	if tp.Status != broker.StatusFilled || tp.FilledAvgPrice < 11 {
+		t.Fatalf("take-profit leg = %s @ %.4f, want filled at >= 11", tp.Status, tp.FilledAvgPrice)
+	}
+	if sl, _ := u.last(broker.LegStopLoss); sl.Status != broker.StatusCanceled {
+		t.Fatalf("stop leg = %s, want canceled (OCO)", sl.Status)
+	}
+	if q := position(a, "ABCD"); q != 0 {
+		t.Fatalf("position = %d, want flat", q)
+	}
+}
+
+func TestStopLossLegFill(t *testing.T) {
+	mock, a, u := newClient(t)
+	submitBracket(t, mock, a)
+
+	mock.Print("ABCD", 9.4)
+	mustSync(t, a)
+
+	sl, _ := u.last(broker.LegStopLoss)
+	if sl.Status != broker.StatusFilled || sl.FilledAvgPrice > 9.5 {
+		t.Fatalf("stop leg = %s @ %.4f, want filled at <= 9.5", sl.Status, sl.FilledAvgPrice)
+	}
+	if tp, _ := u.last(broker.LegTakeProfit); tp.Status != broker.StatusCanceled {
+		t.Fatalf("take-profit leg = %s, want canceled (OCO)", tp.Status)
+	}
+	if q := position(a, "ABCD"); q != 0 {
+		t.Fatalf("position = %d, want flat", q)
+	}
+}
+
+func TestCloseAllFlattens(t *testing.T) {
+	mock, a, u := newClient(t)
+	submitBracket(t, mock, a)
+
+	if err := a.CloseAll(context.Background()); err != nil {
+		t.Fatalf("close all: %v", err)
+	}
+	mustSync(t, a)
+
+	for _, leg := range []string{broker.LegTakeProfit, broker.LegStopLoss} {
+		if l, _ := u.last(leg); l.Open() {
+			t.Fatalf("%s leg still open after CloseAll", leg)
+		}
+	}
+	if q := position(a, "ABCD"); q != 0 {
+		t.Fatalf("position = %d, want flat", q)
+	}
+}
@@ -0,0 +1,122 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+type OrderType string
+
+const (
+	Market OrderType = "market"
+	Limit  OrderType = "limit"
+	Stop   OrderType = "stop"
+)
+
+type OrderStatus string
+
+const (
+	StatusNew      OrderStatus = "new"
+	StatusPartial  OrderStatus = "partially_filled"
+	StatusFilled   OrderStatus = "filled"
+	StatusCanceled OrderStatus = "canceled"
+	StatusRejected OrderStatus = "rejected"
+)
+
+// Bracket legs (Order.Leg)
+const (
+	LegTakeProfit = "take_profit"
+	LegStopLoss   = "stop_loss"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrNotOpen       = errors.New("order is not open")
+)
+
+// OrderRequest is what the engine asks for. LimitPrice / StopPrice only apply to those order types.
+type OrderRequest struct {
+	Symbol     string    `json:"symbol"`
+	Side       Side      `json:"side"`
+	Type       OrderType `json:"type"`
+	Qty        int       `json:"qty"`
+	LimitPrice float64   `json:"limit_price,omitempty"`
+	StopPrice  float64   `json:"stop_price,omitempty"`
+	ClientID   string    `json:"client_id,omitempty"` // caller's tag (e.g. "ORB-BUY-ABCD")
+
+	// Bracket: when both are set, a filled buy arms a take-profit limit sell and a stop-loss
+	// stop sell for the same qty; whichever fills first cancels the other (OCO).
+	TakeProfit float64 `json:"take_profit,omitempty"`
+	StopLoss   float64 `json:"stop_loss,omitempty"`
+}
+
+func (r OrderRequest) Bracket() bool { return r.TakeProfit > 0 && r.StopLoss > 0 }
+
+type Order struct {
+	ID string `json:"id"`
+	OrderRequest
+
+	ParentID string `json:"parent_id,omitempty"` // bracket legs point at their entry order
+	Leg      string `json:"leg,omitempty"`       // take_profit | stop_loss
+
+	Status         OrderStatus `json:"status"`
+	Reason         string      `json:"reason,omitempty"` // rejection / cancel reason
+	SubmittedAt    time.Time   `json:"submitted_at"`
+	FilledAt       time.Time   `json:"filled_at,omitempty"`
+	FilledQty      int         `json:"filled_qty"`
+	FilledAvgPrice float64     `json:"filled_avg_price"`
+}
+
+func (o Order) Open() bool { return o.Status == StatusNew || o.Status == StatusPartial }
+
+type Position struct {
+	Symbol        string  `json:"symbol"`
+	Qty           int     `json:"qty"`
+	AvgPrice      float64 `json:"avg_price"`
+	LastPrice     float64 `json:"last_price"`
+	MarketValue   float64 `json:"market_value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	RealizedPnL   float64 `json:"realized_pnl"` // closed portions of this symbol today
+}
+
+type Account struct {
+	Cash        float64 `json:"cash"`
+	BuyingPower float64 `json:"buying_power"`
+	Equity      float64 `json:"equity"` // cash + market value of positions
+	RealizedPnL float64 `json:"realized_pnl"`
+}
+
+// Broker places orders and reports their state. Implementations must be safe for concurrent use.
+type Broker interface {
+	Name() string
+	Submit(ctx context.Context, req OrderRequest) (Order, error)
+	Cancel(ctx context.Context, id string) error
+	// CloseAll cancels every open order and flattens every position (end-of-window exit).
+	CloseAll(ctx context.Context) error
+	Orders() []Order
+	Positions() []Position
+	Account() Account
+}
+
+// PriceFeed is implemented by brokers that fill against the engine's trade stream (paper).
+type PriceFeed interface {
+	OnTrade(sym string, at time.Time, price, size float64)
+}
+
+// QuoteFeed is implemented by brokers that fill marketable orders against the live NBBO (paper).
+type QuoteFeed interface {
+	OnQuote(sym string, at time.Time, bid, ask float64)
+}
+
+// Notifier is implemented by brokers that can push order updates (fills, cancels, rejections).
+type Notifier interface {
+	OnUpdate(fn func(Order))
+}
@@ -0,0 +1,509 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paper is a simulated broker. Orders fill against the trade prints fed through OnTrade:
+// market orders on the next print, limit/stop orders on the first print that reaches them.
+// Fills are all-or-nothing at the print price (print size is ignored); with quotes fed through
+// OnQuote, market and triggered stop orders fill at the ask (buys) / bid (sells) instead. Selling more than is held
+// opens a short (negative Position.Qty). Bracket entries arm their take-profit / stop-loss legs
+// (on the opposite side) when the entry fills.
+type Paper struct {
+	mu sync.Mutex
+
+	cash     float64
+	bpMult   float64
+	realized float64
+
+	seq       int64
+	orders    []*Order // submission order
+	byID      map[string]*Order
+	positions map[string]*Position
+	last      map[string]float64
+	quotes    map[string][2]float64 // bid, ask
+
+	onUpdate func(Order)
+}
+
+// NewPaper starts with startingCash. buyingPowerMult > 1 allows margin (e.g. 4 for intraday).
+func NewPaper(startingCash, buyingPowerMult float64) *Paper {
+	if buyingPowerMult <= 0 {
+		buyingPowerMult = 1
+	}
+	return &Paper{
+		cash:      startingCash,
+		bpMult:    buyingPowerMult,
+		byID:      make(map[string]*Order, 64),
+		positions: make(map[string]*Position, 16),
+		last:      make(map[string]float64, 64),
+		quotes:    make(map[string][2]float64, 64),
+	}
+}
+
+func (p *Paper) Name() string { return "paper" }
+
+// OnUpdate registers a callback for fills, cancels and rejections (called without the lock held).
+func (p *Paper) OnUpdate(fn func(Order)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onUpdate = fn
+}
+
+func (p *Paper) Submit(_ context.Context, req OrderRequest) (Order, error) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+
+	p.mu.Lock()
+	p.seq++
+	o := &Order{
+		ID:           fmt.Sprintf("paper-%d", p.seq),
+		OrderRequest: req,
+		Status:       StatusNew,
+		SubmittedAt:  time.Now(),
+	}
+	if reason := p.checkLocked(req); reason != "" {
+		o.Status = StatusRejected
+		o.Reason = reason
+	}
+	p.orders = append(p.orders, o)
+	p.byID[o.ID] = o
+	out := *o
+	notify := p.onUpdate
+	p.mu.Unlock()
+
+	if out.Status == StatusRejected {
+		if notify != nil {
+			notify(out)
+		}
+		return out, fmt.Errorf("order rejected: %s", out.Reason)
+	}
+	return out, nil
+}
+
+// checkLocked returns a rejection reason, or "" if the order is acceptable.
+func (p *Paper) checkLocked(req OrderRequest) string {
+	if req.Symbol == "" {
+		return "missing symbol"
+	}
+	if req.Qty <= 0 {
+		return "qty must be > 0"
+	}
+	switch req.Type {
+	case Market:
+	case Limit:
+		if req.LimitPrice <= 0 {
+			return "limit_price must be > 0"
+		}
+	case Stop:
+		if req.StopPrice <= 0 {
+			return "stop_price must be > 0"
+		}
+	default:
+		return fmt.Sprintf("unsupported order type %q", req.Type)
+	}
+
+	if req.Bracket() {
+		if req.Side == Buy && req.TakeProfit <= req.StopLoss {
+			return "take_profit must be above stop_loss"
+		}
+		if req.Side == Sell && req.TakeProfit >= req.StopLoss {
+			return "take_profit must be below stop_loss for a short"
+		}
+	}
+	if req.Side != Buy && req.Side != Sell {
+		return fmt.Sprintf("unsupported side %q", req.Side)
+	}
+
+	// only the part that opens or adds to a position needs buying power;
+	// exits already working against the position don't count as closable
+	held := p.heldLocked(req.Symbol)
+	switch {
+	case req.Side == Sell && held > 0:
+		held = max(held-p.pendingExitQtyLocked(req.Symbol, Sell), 0)
+	case req.Side == Buy && held < 0:
+		held = min(held+p.pendingExitQtyLocked(req.Symbol, Buy), 0)
+	}
+	ref := p.refPriceLocked(req)
+	if need := float64(opensQty(held, req.Side, req.Qty)) * ref; ref > 0 && need > p.buyingPowerLocked() {
+		return fmt.Sprintf("insufficient buying power (need %.2f, have %.2f)", need, p.buyingPowerLocked())
+	}
+	return ""
+}
+
+func (p *Paper) heldLocked(sym string) int {
+	if pos := p.positions[sym]; pos != nil {
+		return pos.Qty
+	}
+	return 0
+}
+
+// opensQty is how much of a qty order on side opens or adds to exposure when held is the
+// signed position (the rest closes it).
+func opensQty(held int, side Side, qty int) int {
+	closable := 0
+	switch {
+	case side == Sell && held > 0:
+		closable = held
+	case side == Buy && held < 0:
+		closable = -held
+	}
+	return max(qty-closable, 0)
+}
+
+func (p *Paper) refPriceLocked(req OrderRequest) float64 {
+	switch req.Type {
+	case Limit:
+		return req.LimitPrice
+	case Stop:
+		return req.StopPrice
+	}
+	return p.last[req.Symbol]
+}
+
+// pendingExitQtyLocked is the qty already working on side against the position (non-bracket orders).
+func (p *Paper) pendingExitQtyLocked(sym string, side Side) int {
+	n := 0
+	for _, o := range p.orders {
+		// an OCO pair only ever fills once: count the take-profit leg, skip its stop sibling
+		if o.Open() && o.Side == side && o.Symbol == sym && !o.Bracket() && o.Leg != LegStopLoss {
+			n += o.Qty
+		}
+	}
+	return n
+}
+
+// buyingPowerLocked is equity × multiplier minus gross position value and what open entries
+// (buys and bracket short sells) would take. With multiplier 1 and no shorts this is cash.
+func (p *Paper) buyingPowerLocked() float64 {
+	equity := p.cash
+	gross := 0.0
+	for _, pos := range p.positions {
+		v := float64(pos.Qty) * pos.LastPrice
+		equity += v
+		if v < 0 {
+			v = -v
+		}
+		gross += v
+	}
+	reserved := 0.0
+	for _, o := range p.orders {
+		if o.Open() && o.Leg == "" && (o.Side == Buy || o.Bracket()) {
+			reserved += float64(o.Qty) * p.refPriceLocked(o.OrderRequest)
+		}
+	}
+	return equity*p.bpMult - gross - reserved
+}
+
+func (p *Paper) Cancel(_ context.Context, id string) error {
+	p.mu.Lock()
+	o := p.byID[id]
+	if o == nil {
+		p.mu.Unlock()
+		return ErrOrderNotFound
+	}
+	if !o.Open() {
+		p.mu.Unlock()
+		return ErrNotOpen
+	}
+	o.Status = StatusCanceled
+	updates := append([]Order{*o}, p.cancelLegsLocked(o.ID, "parent canceled")...)
+	notify := p.onUpdate
+	p.mu.Unlock()
+
+	if notify != nil {
+		for _, u := range updates {
+			notify(u)
+		}
+	}
+	return nil
+}
+
+// cancelLegsLocked cancels the open legs of parentID (or, for a leg, its OCO sibling).
+func (p *Paper) cancelLegsLocked(parentID, reason string) []Order {
+	var out []Order
+	for _, o := range p.orders {
+		if o.ParentID == parentID && o.Open() {
+			o.Status = StatusCanceled
+			o.Reason = reason
+			out = append(out, *o)
+		}
+	}
+	return out
+}
+
+// CloseAll cancels open orders and sells every position at its last print right away
+// (the trade stream may already be closed at the exit time, so there is no next print to wait for).
+func (p *Paper) CloseAll(_ context.Context) error {
+	p.mu.Lock()
+	var updates []Order
+	for _, o := range p.orders {
+		if o.Open() {
+			o.Status = StatusCanceled
+			o.Reason = "close all"
+			updates = append(updates, *o)
+		}
+	}
+	syms := make([]string, 0, len(p.positions))
+	for sym, pos := range p.positions {
+		if pos.Qty != 0 {
+			syms = append(syms, sym)
+		}
+	}
+	sort.Strings(syms)
+	for _, sym := range syms {
+		pos := p.positions[sym]
+		px := p.last[sym]
+		if px <= 0 {
+			px = pos.AvgPrice
+		}
+		side, qty := Sell, pos.Qty
+		if qty < 0 {
+			side, qty = Buy, -qty
+		}
+		p.seq++
+		o := &Order{
+			ID:           fmt.Sprintf("paper-%d", p.seq),
+			OrderRequest: OrderRequest{Symbol: sym, Side: side, Type: Market, Qty: qty, ClientID: "CLOSE-ALL"},
+			Status:       StatusNew,
+			SubmittedAt:  time.Now(),
+		}
+		p.orders = append(p.orders, o)
+		p.byID[o.ID] = o
+		p.fillLocked(o, o.SubmittedAt, p.fillPriceLocked(o.OrderRequest, px))
+		updates = append(updates, *o)
+	}
+	notify := p.onUpdate
+	p.mu.Unlock()
+
+	if notify != nil {
+		for _, u := range updates {
+			notify(u)
+		}
+	}
+	return nil
+}
+
+// OnTrade marks positions to the print and fills any open orders it reaches.
+func (p *Paper) OnTrade(sym string, at time.Time, price, size float64) {
+	if price <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	p.last[sym] = price
+	if pos := p.positions[sym]; pos != nil {
+		pos.LastPrice = price
+	}
+
+	var filled []Order
+	// index loop: filling a bracket entry appends its legs, which can't fill on the same print
+	for i, n := 0, len(p.orders); i < n; i++ {
+		o := p.orders[i]
+		if !o.Open() || o.Symbol != sym || !reaches(o.OrderRequest, price) {
+			continue
+		}
+		if o.ParentID == "" && float64(opensQty(p.heldLocked(sym), o.Side, o.Qty))*price > p.fillRoomLocked(o) {
+			o.Status = StatusRejected
+			o.Reason = "insufficient buying power at fill"
+			filled = append(filled, *o)
+			continue
+		}
+		p.fillLocked(o, at, p.fillPriceLocked(o.OrderRequest, price))
+		filled = append(filled, *o)
+
+		switch {
+		case o.Bracket():
+			filled = append(filled, p.armLegsLocked(o)...)
+		case o.ParentID != "":
+			filled = append(filled, p.cancelLegsLocked(o.ParentID, "oco")...)
+		}
+	}
+	notify := p.onUpdate
+	p.mu.Unlock()
+
+	if notify != nil {
+		for _, o := range filled {
+			notify(o)
+		}
+	}
+}
+
+// OnQuote records the NBBO that marketable orders fill against.
+func (p *Paper) OnQuote(sym string, _ time.Time, bid, ask float64) {
+	if bid <= 0 || ask <= 0 || ask < bid {
+		return
+	}
+	p.mu.Lock()
+	p.quotes[sym] = [2]float64{bid, ask}
+	p.mu.Unlock()
+}
+
+// fillPriceLocked: limit orders fill at the print; market and stop (market once triggered)
+// orders take the quoted side when a quote is known.
+func (p *Paper) fillPriceLocked(req OrderRequest, price float64) float64 {
+	q, ok := p.quotes[req.Symbol]
+	if !ok || req.Type == Limit {
+		return price
+	}
+	if req.Side == Buy {
+		return q[1]
+	}
+	return q[0]
+}
+
+// fillRoomLocked is the buying power available to o at fill time (its own reservation added back).
+func (p *Paper) fillRoomLocked(o *Order) float64 {
+	room := p.buyingPowerLocked()
+	if o.Side == Buy || o.Bracket() {
+		room += float64(o.Qty) * p.refPriceLocked(o.OrderRequest)
+	}
+	return room
+}
+
+// armLegsLocked creates the take-profit and stop-loss exits for a filled bracket entry
+// (sells for a long entry, buys for a short one).
+func (p *Paper) armLegsLocked(parent *Order) []Order {
+	exit := Sell
+	if parent.Side == Sell {
+		exit = Buy
+	}
+	legs := []OrderRequest{
+		{Symbol: parent.Symbol, Side: exit, Type: Limit, Qty: parent.Qty, LimitPrice: parent.TakeProfit, ClientID: parent.ClientID + "-TP"},
+		{Symbol: parent.Symbol, Side: exit, Type: Stop, Qty: parent.Qty, StopPrice: parent.StopLoss, ClientID: parent.ClientID + "-SL"},
+	}
+	names := []string{LegTakeProfit, LegStopLoss}
+
+	out := make([]Order, 0, 2)
+	for i, req := range legs {
+		p.seq++
+		o := &Order{
+			ID:           fmt.Sprintf("paper-%d", p.seq),
+			OrderRequest: req,
+			ParentID:     parent.ID,
+			Leg:          names[i],
+			Status:       StatusNew,
+			SubmittedAt:  time.Now(),
+		}
+		p.orders = append(p.orders, o)
+		p.byID[o.ID] = o
+		out = append(out, *o)
+	}
+	return out
+}
+
+func reaches(req OrderRequest, price float64) bool {
+	switch req.Type {
+	case Market:
+		return true
+	case Limit:
+		if req.Side == Buy {
+			return price <= req.LimitPrice
+		}
+		return price >= req.LimitPrice
+	case Stop:
+		if req.Side == Buy {
+			return price >= req.StopPrice
+		}
+		return price <= req.StopPrice
+	}
+	return false
+}
+
+func (p *Paper) fillLocked(o *Order, at time.Time, price float64) {
+	o.Status = StatusFilled
+	o.FilledAt = at
+	o.FilledQty = o.Qty
+	o.FilledAvgPrice = price
+
+	pos := p.positions[o.Symbol]
+	if pos == nil {
+		pos = &Position{Symbol: o.Symbol}
+		p.positions[o.Symbol] = pos
+	}
+	pos.LastPrice = price
+
+	delta := o.Qty
+	if o.Side == Sell {
+		delta = -delta
+	}
+	p.cash -= price * float64(delta)
+
+	old := pos.Qty
+	pos.Qty += delta
+	switch {
+	case old == 0 || (old > 0) == (delta > 0):
+		// opening / adding: average in
+		pos.AvgPrice = (pos.AvgPrice*float64(abs(old)) + price*float64(abs(delta))) / float64(abs(pos.Qty))
+	default:
+		// reducing (or flipping through zero): realize the closed part
+		closed := min(abs(old), abs(delta))
+		pnl := (price - pos.AvgPrice) * float64(closed)
+		if old < 0 {
+			pnl = -pnl
+		}
+		pos.RealizedPnL += pnl
+		p.realized += pnl
+		switch {
+		case pos.Qty == 0:
+			pos.AvgPrice = 0
+		case (pos.Qty > 0) != (old > 0):
+			pos.AvgPrice = price
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Orders returns every order, newest first.
+func (p *Paper) Orders() []Order {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Order, 0, len(p.orders))
+	for i := len(p.orders) - 1; i >= 0; i-- {
+		out = append(out, *p.orders[i])
+	}
+	return out
+}
+
+// Positions returns every symbol traded this run (flat ones keep their realized P&L), sorted.
+func (p *Paper) Positions() []Position {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Position, 0, len(p.positions))
+	for _, pos := range p.positions {
+		cp := *pos
+		cp.MarketValue = float64(cp.Qty) * cp.LastPrice
+		if cp.Qty != 0 {
+			cp.UnrealizedPnL = (cp.LastPrice - cp.AvgPrice) * float64(cp.Qty)
+		}
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out
+}
+
+func (p *Paper) Account() Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	mv := 0.0
+	for _, pos := range p.positions {
+		mv += float64(pos.Qty) * pos.LastPrice
+	}
+	return Account{
+		Cash:        p.cash,
+		BuyingPower: p.buyingPowerLocked(),
+		Equity:      p.cash + mv,
+		RealizedPnL: p.realized,
+	}
+}
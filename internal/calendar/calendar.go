@@ -0,0 +1,172 @@
+// Package calendar is the NYSE / Nasdaq equities calendar: full-day holidays and 13:00 early
+// closes, computed from the exchange rules (plus the one-off closures listed below), so the
+// engine knows whether a day trades without probing the data provider.
+//
+// Days are taken as calendar dates: only Year/Month/Day of the given time are used, so pass
+// dates in the exchange time zone (America/New_York).
+package calendar
+
+import "time"
+
+const (
+	RegularCloseHMS = "16:00:00"
+	EarlyCloseHMS   = "13:00:00"
+)
+
+// special lists unscheduled full-day closures (national days of mourning, weather, 9/11).
+var special = map[string]string{
+	"2001-09-11": "September 11",
+	"2001-09-12": "September 11",
+	"2001-09-13": "September 11",
+	"2001-09-14": "September 11",
+	"2004-06-11": "Reagan day of mourning",
+	"2007-01-02": "Ford day of mourning",
+	"2012-10-29": "Hurricane Sandy",
+	"2012-10-30": "Hurricane Sandy",
+	"2018-12-05": "Bush day of mourning",
+	"2025-01-09": "Carter day of mourning",
+}
+
+// Holiday returns the reason the exchanges are closed on a weekday, or "" when it trades.
+// Weekends are not holidays (see IsTradingDay).
+func Holiday(day time.Time) string {
+	y, m, d := day.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if name, ok := special[date.Format("2006-01-02")]; ok {
+		return name
+	}
+
+	switch {
+	case m == time.January && date.Equal(observed(y, m, 1)):
+		// (Jan 1 on a Saturday is not made up on Dec 31)
+		return "New Year's Day"
+	case m == time.January && y >= 1998 && date.Equal(nthWeekday(y, m, time.Monday, 3)):
+		return "Martin Luther King Jr. Day"
+	case m == time.February && date.Equal(nthWeekday(y, m, time.Monday, 3)):
+		return "Washington's Birthday"
+	case date.Equal(easter(y).AddDate(0, 0, -2)):
+		return "Good Friday"
+	case m == time.May && date.Equal(lastWeekday(y, m, time.Monday)):
+		return "Memorial Day"
+	case y >= 2022 && date.Equal(observed(y, time.June, 19)):
+		return "Juneteenth"
+	case date.Equal(observed(y, time.July, 4)):
+		return "Independence Day"
+	case m == time.September && date.Equal(nthWeekday(y, m, time.Monday, 1)):
+		return "Labor Day"
+	case m == time.November && date.Equal(nthWeekday(y, m, time.Thursday, 4)):
+		return "Thanksgiving Day"
+	case date.Equal(observed(y, time.December, 25)):
+		return "Christmas Day"
+	}
+	return ""
+}
+
+// IsTradingDay reports whether the exchanges hold a session on day.
+func IsTradingDay(day time.Time) bool {
+	wd := day.Weekday()
+	return wd != time.Saturday && wd != time.Sunday && Holiday(day) == ""
+}
+
+// EarlyClose reports whether day is a trading day that closes at 13:00: July 3 (Mon–Thu),
+// the day after Thanksgiving and Christmas Eve (Mon–Thu).
+func EarlyClose(day time.Time) bool {
+	if !IsTradingDay(day) {
+		return false
+	}
+	y, m, d := day.Date()
+	wd := day.Weekday()
+	switch {
+	case m == time.July && d == 3 && wd >= time.Monday && wd <= time.Thursday:
+		return true
+	case m == time.November && sameDate(day, nthWeekday(y, m, time.Thursday, 4).AddDate(0, 0, 1)):
+		return true
+	case m == time.December && d == 24 && wd >= time.Monday && wd <= time.Thursday:
+		return true
+	}
+	return false
+}
+
+// CloseHMS is the regular-session close on a trading day ("HH:MM:SS").
+func CloseHMS(day time.Time) string {
+	if EarlyClose(day) {
+		return EarlyCloseHMS
+	}
+	return RegularCloseHMS
+}
+
+// PrevTradingDay returns the last trading day strictly before day (same location, midnight).
+func PrevTradingDay(day time.Time) time.Time {
+	d := midnight(day).AddDate(0, 0, -1)
+	for !IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+// LastTradingDay returns day itself when it trades, else the trading day before it.
+func LastTradingDay(day time.Time) time.Time {
+	if IsTradingDay(day) {
+		return midnight(day)
+	}
+	return PrevTradingDay(day)
+}
+
+// Describe names why day does not trade ("weekend", a holiday) or "" for a trading day.
+func Describe(day time.Time) string {
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return "weekend"
+	}
+	return Holiday(day)
+}
+
+func midnight(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, day.Location())
+}
+
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// observed moves a fixed-date holiday off the weekend: Saturday → Friday, Sunday → Monday.
+func observed(y int, m time.Month, d int) time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// nthWeekday is the n-th (1-based) wd of the month.
+func nthWeekday(y int, m time.Month, wd time.Weekday, n int) time.Time {
+	t := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	t = t.AddDate(0, 0, (int(wd)-int(t.Weekday())+7)%7)
+	return t.AddDate(0, 0, 7*(n-1))
+}
+
+func lastWeekday(y int, m time.Month, wd time.Weekday) time.Time {
+	t := time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	return t.AddDate(0, 0, -((int(t.Weekday()) - int(wd) + 7) % 7))
+}
+
+// easter is Western Easter Sunday (anonymous Gregorian algorithm).
+func easter(y int) time.Time {
+	a := y % 19
+	b, c := y/100, y%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(y, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"errors"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,6 +24,9 @@ type Config struct {
 		ForceExitTime   string `yaml:"force_exit_time"`
 	} `yaml:"market"`
 
+	// NEW: entry/exit rules by name (engine strategy registry)
+	Strategy string `yaml:"strategy"` // orb (default)
+
 	Filters struct {
 		Open5mRangePctMin float64 `yaml:"open_5m_range_pct_min"`
 		Open5mRangePctMax float64 `yaml:"open_5m_range_pct_max"`
@@ -35,7 +41,20 @@ type Config struct {
 		EntryPriceMin     float64 `yaml:"entry_price_min"`
 		EntryPriceMax     float64 `yaml:"entry_price_max"`
 
-		// Historic “sold off” scan (by 10:30)
+		Side string `yaml:"side"` // long (default) | short | both
+
+		EntryMode       string  `yaml:"entry_mode"`        // vwap_cross (default) | or_breakout
+		BreakoutVolMult float64 `yaml:"breakout_vol_mult"` // or_breakout volume confirmation (0 = off)
+
+		MaxSpreadPct float64 `yaml:"max_spread_pct"` // max quoted spread / mid at entry (0 = off)
+
+		// NEW: premarket gap vs previous close, and 04:00 → open volume (each 0 = off)
+		GapPctMin       float64 `yaml:"gap_pct_min"`
+		GapPctMax       float64 `yaml:"gap_pct_max"`
+		PremarketVolMin float64 `yaml:"premarket_vol_min"`
+
+		// Historic “sold off” scan (by sold_off_scan_time)
+		SoldOffScanTime          string  `yaml:"sold_off_scan_time"` // "HH:MM:SS" NY (default 10:30:00)
 		SoldOffFromOpenPctMin    float64 `yaml:"sold_off_from_open_pct_min"`
 		SoldOffOpen5mRangePctMin float64 `yaml:"sold_off_open5m_range_pct_min"`
 		SoldOffOpen5mTodayPctMin float64 `yaml:"sold_off_open5m_today_pct_min"`
@@ -46,18 +65,121 @@ type Config struct {
 		StopLossPct   float64 `yaml:"stop_loss_pct"`
 	} `yaml:"risk"`
 
+	// Stop management on top of the fixed take-profit / stop (all off by default).
+	Exits struct {
+		TrailMode    string  `yaml:"trail_mode"` // "" (off) | percent | atr
+		TrailPct     float64 `yaml:"trail_pct"`
+		TrailATRMult float64 `yaml:"trail_atr_mult"`
+		ATRPeriod    int     `yaml:"atr_period"` // 1-minute bars (default 14)
+
+		BreakEvenTriggerPct float64 `yaml:"break_even_trigger_pct"` // 0 = off
+		BreakEvenOffsetPct  float64 `yaml:"break_even_offset_pct"`
+
+		TimeStopMinutes float64 `yaml:"time_stop_minutes"` // 0 = off
+	} `yaml:"exits"`
+
+	// NEW: rebound (mean-reversion) backtest on the sold-off scan's names: one long per name
+	// after the scan time, target / stop, flat at the close.
+	Rebound struct {
+		Entry           string  `yaml:"entry"`             // vwap_reclaim (default) | bounce
+		BouncePct       float64 `yaml:"bounce_pct"`        // bounce: close >= low so far * (1 + bounce_pct)
+		TakeProfitPct   float64 `yaml:"take_profit_pct"`   // from the entry
+		StopLossPct     float64 `yaml:"stop_loss_pct"`     // from the entry
+		EntryCutoffTime string  `yaml:"entry_cutoff_time"` // no new entries from this bar on (HH:MM:SS NY)
+	} `yaml:"rebound"`
+
+	// NEW: realtime alerts beyond the ORB signals.
+	Alerts struct {
+		SoldOff    bool `yaml:"sold_off"`     // SOLD_OFF event when a name breaches sold_off_from_open_pct_min before the scan time
+		SoldOffTTS bool `yaml:"sold_off_tts"` // also speak it (needs openai tts)
+	} `yaml:"alerts"`
+
+	// Position size per entry (live orders, historic replays and sweeps).
+	Sizing struct {
+		Mode          string  `yaml:"mode"` // shares | notional | risk | equity_pct
+		Shares        int     `yaml:"shares"`
+		Notional      float64 `yaml:"notional"`
+		RiskDollars   float64 `yaml:"risk_dollars"`
+		EquityPct     float64 `yaml:"equity_pct"`     // 0.10 = 10%
+		AccountEquity float64 `yaml:"account_equity"` // equity_pct base when no broker account is available
+		MaxNotional   float64 `yaml:"max_notional"`   // 0 = no cap
+	} `yaml:"sizing"`
+
+	// Trading costs netted out of historic P&L (reports show gross and net side by side).
+	Costs struct {
+		CommissionPerShare float64 `yaml:"commission_per_share"`
+		CommissionPerOrder float64 `yaml:"commission_per_order"`
+
+		SlippageMode   string  `yaml:"slippage_mode"` // "" (none) | bps | spread | quote
+		SlippageBps    float64 `yaml:"slippage_bps"`
+		SpreadBps      float64 `yaml:"spread_bps"`      // assumed quoted spread
+		SpreadMin      float64 `yaml:"spread_min"`      // spread floor in dollars (default 0.01)
+		SpreadFraction float64 `yaml:"spread_fraction"` // share of the spread paid per fill (default 0.5)
+
+		SECFeeRate       float64 `yaml:"sec_fee_rate"`        // per dollar sold
+		FINRATAFPerShare float64 `yaml:"finra_taf_per_share"` // per share sold
+		FINRATAFMax      float64 `yaml:"finra_taf_max"`       // per sell, 0 = no cap
+	} `yaml:"costs"`
+
+	// NEW: prints kept out of VWAP, cross detection and stop/target triggers.
+	Trades struct {
+		ExcludeConditions []int `yaml:"exclude_conditions"` // SIP condition codes; omitted = DefaultExcludeConditions, [] = keep all
+	} `yaml:"trades"`
+
+	// NEW: realtime A/B — extra parameter sets evaluated silently on the live tape.
+	ShadowProfiles []ShadowProfile `yaml:"shadow_profiles"`
+
 	History struct {
 		Open5mLookbackSessions int `yaml:"open5m_lookback_sessions"`
 		MaxCalendarLookback    int `yaml:"max_calendar_lookback_days"`
 		MaxWorkers             int `yaml:"max_workers"`
 	} `yaml:"history"`
 
+	// NEW: build the watchlist from the provider's ticker universe (reference tickers + grouped
+	// daily bars) instead of maintaining watchlist.yaml by hand. Refreshed at startup.
+	Universe struct {
+		Enabled          bool     `yaml:"enabled"`
+		Types            []string `yaml:"types"`             // reference ticker types; omitted = [CS] (common stock)
+		Exchanges        []string `yaml:"exchanges"`         // primary exchange MICs; omitted = DefaultUniverseExchanges, [] = any
+		PriceMin         float64  `yaml:"price_min"`         // previous close; 0 = off
+		PriceMax         float64  `yaml:"price_max"`         // previous close; 0 = off
+		AvgVolumeMin     float64  `yaml:"avg_volume_min"`    // average daily volume over lookback_sessions; 0 = off
+		LookbackSessions int      `yaml:"lookback_sessions"` // grouped daily sessions averaged (default 5)
+	} `yaml:"universe"`
+
 	Massive struct {
 		Feed        string `yaml:"feed"` // realtime | delayed
 		Market      string `yaml:"market"`
 		WSBatchSize int    `yaml:"ws_batch_size"`
 	} `yaml:"massive"`
 
+	Cache struct {
+		Enabled   bool   `yaml:"enabled"`
+		Dir       string `yaml:"dir"`
+		MaxSizeMB int64  `yaml:"max_size_mb"` // 0 = unlimited
+	} `yaml:"cache"`
+
+	Reports struct {
+		Enabled bool   `yaml:"enabled"`
+		DBPath  string `yaml:"db_path"`
+	} `yaml:"reports"`
+
+	// Order routing for live signals. Provider "" disables it (alerts only).
+	Broker struct {
+		Provider string `yaml:"provider"` // "" | paper | alpaca
+
+		Paper struct {
+			StartingCash          float64 `yaml:"starting_cash"`
+			BuyingPowerMultiplier float64 `yaml:"buying_power_multiplier"` // 1 = cash account
+		} `yaml:"paper"`
+
+		// Alpaca-style REST API; keys come from ALPACA_API_KEY_ID / ALPACA_API_SECRET_KEY.
+		Alpaca struct {
+			BaseURL        string `yaml:"base_url"`
+			PollIntervalMS int    `yaml:"poll_interval_ms"`
+		} `yaml:"alpaca"`
+	} `yaml:"broker"`
+
 	OpenAI struct {
 		TTSModel       string `yaml:"tts_model"`
 		Voice          string `yaml:"voice"`
@@ -69,6 +191,20 @@ type Config struct {
 	} `yaml:"ui"`
 }
 
+// ShadowProfile overrides the live filters/risk for one shadow run.
+type ShadowProfile struct {
+	Name string `yaml:"name"`
+
+	// Any /api/filters key (open_5m_range_pct_min, side, entry_mode, ...).
+	// Keys left out follow the live filters as they are at 09:35.
+	Filters map[string]any `yaml:"filters"`
+
+	Risk struct {
+		TakeProfitPct float64 `yaml:"take_profit_pct"` // 0 = live value
+		StopLossPct   float64 `yaml:"stop_loss_pct"`   // 0 = live value
+	} `yaml:"risk"`
+}
+
 func Load(path string) (Config, error) {
 	var cfg Config
 
@@ -87,6 +223,24 @@ func Load(path string) (Config, error) {
 	return cfg, nil
 }
 
+// strategyNames is the engine's strategy registry (RegisterStrategy); config cannot import the
+// engine, so the engine hands its names over at init.
+var strategyNames = map[string]bool{}
+
+// RegisterStrategy makes name a valid strategy value.
+func RegisterStrategy(name string) { strategyNames[name] = true }
+
+// DefaultUniverseExchanges are the primary listings kept by the universe builder: NYSE, Nasdaq,
+// NYSE American and NYSE Arca.
+var DefaultUniverseExchanges = []string{"XNYS", "XNAS", "XASE", "ARCX"}
+
+// DefaultExcludeConditions are the SIP trade conditions (Massive numbering) that are not regular
+// last-sale prints: average price (2), cash sale (7), derivatively priced (10), Form T (12),
+// extended hours out of sequence (13), official open/close (15, 16), next day (20), price
+// variation (21), prior reference price (22), seller (29), sold out of sequence (32, 33),
+// odd lot (37), corrected close (38) and contingent / qualified contingent (52, 53).
+var DefaultExcludeConditions = []int{2, 7, 10, 12, 13, 15, 16, 20, 21, 22, 29, 32, 33, 37, 38, 52, 53}
+
 func applyDefaults(cfg *Config) {
 	if cfg.Server.Host == "" {
 		cfg.Server.Host = "0.0.0.0"
@@ -137,6 +291,58 @@ func applyDefaults(cfg *Config) {
 		cfg.Risk.StopLossPct = 0.02
 	}
 
+	if cfg.Strategy == "" {
+		cfg.Strategy = "orb"
+	}
+
+	if cfg.Filters.Side == "" {
+		cfg.Filters.Side = "long"
+	}
+	if cfg.Filters.EntryMode == "" {
+		cfg.Filters.EntryMode = "vwap_cross"
+	}
+
+	if cfg.Exits.ATRPeriod <= 0 {
+		cfg.Exits.ATRPeriod = 14
+	}
+
+	if cfg.Rebound.Entry == "" {
+		cfg.Rebound.Entry = "vwap_reclaim"
+	}
+	if cfg.Rebound.BouncePct <= 0 {
+		cfg.Rebound.BouncePct = 0.03
+	}
+	if cfg.Rebound.TakeProfitPct <= 0 {
+		cfg.Rebound.TakeProfitPct = 0.05
+	}
+	if cfg.Rebound.StopLossPct <= 0 {
+		cfg.Rebound.StopLossPct = 0.03
+	}
+	if cfg.Rebound.EntryCutoffTime == "" {
+		cfg.Rebound.EntryCutoffTime = "15:00:00"
+	}
+
+	if cfg.Sizing.Mode == "" {
+		cfg.Sizing.Mode = "shares"
+	}
+	if cfg.Sizing.Shares <= 0 {
+		cfg.Sizing.Shares = 1000
+	}
+	if cfg.Sizing.AccountEquity <= 0 {
+		cfg.Sizing.AccountEquity = 100000
+	}
+
+	if cfg.Costs.SpreadMin <= 0 {
+		cfg.Costs.SpreadMin = 0.01
+	}
+	if cfg.Costs.SpreadFraction <= 0 {
+		cfg.Costs.SpreadFraction = 0.5
+	}
+
+	if cfg.Cache.Dir == "" {
+		cfg.Cache.Dir = ".cache/marketdata"
+	}
+
 	if cfg.OpenAI.TTSModel == "" {
 		cfg.OpenAI.TTSModel = "tts-1"
 	}
@@ -148,6 +354,9 @@ func applyDefaults(cfg *Config) {
 	}
 
 	// Historic sold-off scan defaults
+	if cfg.Filters.SoldOffScanTime == "" {
+		cfg.Filters.SoldOffScanTime = "10:30:00"
+	}
 	if cfg.Filters.SoldOffFromOpenPctMin <= 0 {
 		cfg.Filters.SoldOffFromOpenPctMin = 0.20
 	}
@@ -161,6 +370,36 @@ func applyDefaults(cfg *Config) {
 	if cfg.UI.MaxEvents <= 0 {
 		cfg.UI.MaxEvents = 250
 	}
+
+	if cfg.Reports.DBPath == "" {
+		cfg.Reports.DBPath = "data/reports.db"
+	}
+
+	if cfg.Broker.Paper.StartingCash <= 0 {
+		cfg.Broker.Paper.StartingCash = 100000
+	}
+	if cfg.Broker.Paper.BuyingPowerMultiplier <= 0 {
+		cfg.Broker.Paper.BuyingPowerMultiplier = 1
+	}
+	if cfg.Broker.Alpaca.BaseURL == "" {
+		cfg.Broker.Alpaca.BaseURL = "https://paper-api.alpaca.markets"
+	}
+	if cfg.Broker.Alpaca.PollIntervalMS <= 0 {
+		cfg.Broker.Alpaca.PollIntervalMS = 1000
+	}
+	if cfg.Trades.ExcludeConditions == nil {
+		cfg.Trades.ExcludeConditions = append([]int(nil), DefaultExcludeConditions...)
+	}
+
+	if len(cfg.Universe.Types) == 0 {
+		cfg.Universe.Types = []string{"CS"}
+	}
+	if cfg.Universe.Exchanges == nil {
+		cfg.Universe.Exchanges = append([]string(nil), DefaultUniverseExchanges...)
+	}
+	if cfg.Universe.LookbackSessions <= 0 {
+		cfg.Universe.LookbackSessions = 5
+	}
 }
 
 func validate(cfg *Config) error {
@@ -181,6 +420,9 @@ func validate(cfg *Config) error {
 	}
 
 	// Sold-off scan validation
+	if _, err := time.Parse("15:04:05", cfg.Filters.SoldOffScanTime); err != nil {
+		return errors.New("filters.sold_off_scan_time invalid (HH:MM:SS)")
+	}
 	if cfg.Filters.SoldOffFromOpenPctMin <= 0 || cfg.Filters.SoldOffFromOpenPctMin >= 1 {
 		return errors.New("filters.sold_off_from_open_pct_min invalid (expected 0..1)")
 	}
@@ -190,5 +432,147 @@ func validate(cfg *Config) error {
 	if cfg.Filters.SoldOffOpen5mTodayPctMin <= 0 {
 		return errors.New("filters.sold_off_open5m_today_pct_min invalid (>0)")
 	}
+	if cfg.Cache.MaxSizeMB < 0 {
+		return errors.New("cache.max_size_mb invalid (>=0)")
+	}
+	for _, c := range cfg.Trades.ExcludeConditions {
+		if c < 0 {
+			return errors.New("trades.exclude_conditions invalid (condition codes are >= 0)")
+		}
+	}
+	if cfg.Universe.PriceMin < 0 || cfg.Universe.PriceMax < 0 || cfg.Universe.AvgVolumeMin < 0 {
+		return errors.New("universe.price_min / price_max / avg_volume_min invalid (>=0)")
+	}
+	if cfg.Universe.PriceMax > 0 && cfg.Universe.PriceMin > cfg.Universe.PriceMax {
+		return errors.New("universe.price_min must not exceed price_max")
+	}
+	if cfg.Universe.LookbackSessions > 30 {
+		return errors.New("universe.lookback_sessions invalid (1..30)")
+	}
+
+	seen := make(map[string]bool, len(cfg.ShadowProfiles))
+	for _, sp := range cfg.ShadowProfiles {
+		if sp.Name == "" || sp.Name == "live" || seen[sp.Name] {
+			return errors.New("shadow_profiles.name invalid (non-empty, unique, not \"live\")")
+		}
+		seen[sp.Name] = true
+		if sp.Risk.TakeProfitPct < 0 || sp.Risk.StopLossPct < 0 {
+			return errors.New("shadow_profiles.risk invalid (>=0)")
+		}
+	}
+	if len(strategyNames) > 0 && !strategyNames[cfg.Strategy] {
+		names := make([]string, 0, len(strategyNames))
+		for n := range strategyNames {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return errors.New("strategy invalid (" + strings.Join(names, "|") + ")")
+	}
+	switch cfg.Filters.Side {
+	case "long", "short", "both":
+	default:
+		return errors.New("filters.side invalid (long|short|both)")
+	}
+	switch cfg.Filters.EntryMode {
+	case "vwap_cross", "or_breakout":
+	default:
+		return errors.New("filters.entry_mode invalid (vwap_cross|or_breakout)")
+	}
+	if cfg.Filters.BreakoutVolMult < 0 {
+		return errors.New("filters.breakout_vol_mult invalid (>=0)")
+	}
+	if cfg.Filters.MaxSpreadPct < 0 || cfg.Filters.MaxSpreadPct >= 1 {
+		return errors.New("filters.max_spread_pct invalid (expected 0..1, 0 = off)")
+	}
+	gMin, gMax := cfg.Filters.GapPctMin, cfg.Filters.GapPctMax
+	if gMin <= -1 || gMax <= -1 || (gMin != 0 && gMax != 0 && gMax < gMin) {
+		return errors.New("filters.gap_pct_min/max invalid (> -1, max >= min when both set, 0 = off)")
+	}
+	if cfg.Filters.PremarketVolMin < 0 {
+		return errors.New("filters.premarket_vol_min invalid (>=0)")
+	}
+	switch cfg.Exits.TrailMode {
+	case "":
+	case "percent":
+		if cfg.Exits.TrailPct <= 0 || cfg.Exits.TrailPct >= 1 {
+			return errors.New("exits.trail_pct invalid (expected 0..1)")
+		}
+	case "atr":
+		if cfg.Exits.TrailATRMult <= 0 {
+			return errors.New("exits.trail_atr_mult invalid (>0)")
+		}
+	default:
+		return errors.New("exits.trail_mode invalid (\"\", percent or atr)")
+	}
+	if cfg.Exits.BreakEvenTriggerPct < 0 || cfg.Exits.BreakEvenOffsetPct < 0 {
+		return errors.New("exits.break_even_* invalid (>=0)")
+	}
+	if cfg.Exits.BreakEvenTriggerPct > 0 && cfg.Exits.BreakEvenOffsetPct >= cfg.Exits.BreakEvenTriggerPct {
+		return errors.New("exits.break_even_offset_pct must be below break_even_trigger_pct")
+	}
+	if cfg.Exits.TimeStopMinutes < 0 {
+		return errors.New("exits.time_stop_minutes invalid (>=0)")
+	}
+	switch cfg.Rebound.Entry {
+	case "vwap_reclaim", "bounce":
+	default:
+		return errors.New("rebound.entry invalid (vwap_reclaim or bounce)")
+	}
+	if cfg.Rebound.BouncePct >= 1 {
+		return errors.New("rebound.bounce_pct invalid (expected 0..1)")
+	}
+	if cfg.Rebound.StopLossPct >= 1 {
+		return errors.New("rebound.stop_loss_pct invalid (expected 0..1)")
+	}
+	if _, err := time.Parse("15:04:05", cfg.Rebound.EntryCutoffTime); err != nil {
+		return errors.New("rebound.entry_cutoff_time invalid (HH:MM:SS)")
+	}
+	switch cfg.Sizing.Mode {
+	case "shares":
+	case "notional":
+		if cfg.Sizing.Notional <= 0 {
+			return errors.New("sizing.notional invalid (>0)")
+		}
+	case "risk":
+		if cfg.Sizing.RiskDollars <= 0 {
+			return errors.New("sizing.risk_dollars invalid (>0)")
+		}
+	case "equity_pct":
+		if cfg.Sizing.EquityPct <= 0 || cfg.Sizing.EquityPct > 4 {
+			return errors.New("sizing.equity_pct invalid (expected 0..4)")
+		}
+	default:
+		return errors.New("sizing.mode invalid (shares|notional|risk|equity_pct)")
+	}
+	if cfg.Sizing.MaxNotional < 0 {
+		return errors.New("sizing.max_notional invalid (>=0)")
+	}
+	if cfg.Costs.CommissionPerShare < 0 || cfg.Costs.CommissionPerOrder < 0 {
+		return errors.New("costs.commission_* invalid (>=0)")
+	}
+	switch cfg.Costs.SlippageMode {
+	case "":
+	case "bps":
+		if cfg.Costs.SlippageBps < 0 {
+			return errors.New("costs.slippage_bps invalid (>=0)")
+		}
+	case "spread", "quote":
+		if cfg.Costs.SpreadBps < 0 {
+			return errors.New("costs.spread_bps invalid (>=0)")
+		}
+		if cfg.Costs.SpreadFraction > 1 {
+			return errors.New("costs.spread_fraction invalid (expected 0..1)")
+		}
+	default:
+		return errors.New("costs.slippage_mode invalid (\"\", bps, spread or quote)")
+	}
+	if cfg.Costs.SECFeeRate < 0 || cfg.Costs.FINRATAFPerShare < 0 || cfg.Costs.FINRATAFMax < 0 {
+		return errors.New("costs.sec_fee_rate / finra_taf_* invalid (>=0)")
+	}
+	switch cfg.Broker.Provider {
+	case "", "paper", "alpaca":
+	default:
+		return errors.New("broker.provider invalid (expected \"\", paper or alpaca)")
+	}
 	return nil
 }
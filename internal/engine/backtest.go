@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/reportdb"
+	"massive-orb/internal/store"
+)
+
+// RunHistoricRange replays every trading session in [fromNY, toNY] (NY dates) in order and
+// publishes an aggregate report: per-day summaries, an equity curve and max drawdown.
+// Weekends and exchange holidays are not sessions; trading days without open-5m data are skipped,
+// and so is today until its force-exit time has passed.
+func (e *Engine) RunHistoricRange(ctx context.Context, fromNY, toNY time.Time) error {
+	asOfNY := time.Now().In(e.loc)
+	fromDay := dateOnlyInLoc(fromNY, e.loc)
+	toDay := dateOnlyInLoc(toNY, e.loc)
+	todayNY := dateOnlyInLoc(asOfNY, e.loc)
+
+	if toDay.After(todayNY) {
+		toDay = todayNY
+	}
+	if fromDay.After(toDay) {
+		return fmt.Errorf("from %s is after to %s", fromDay.Format("2006-01-02"), toDay.Format("2006-01-02"))
+	}
+	if err := replayQuotesErr(e.simParams()); err != nil {
+		return err
+	}
+
+	fromISO := fromDay.Format("2006-01-02")
+	toISO := toDay.Format("2006-01-02")
+
+	days := make([]store.HistoricSummary, 0, 64)
+	trades := make([]store.HistoricTrade, 0, 256)
+	skipped := make([]string, 0, 8)
+	rebounds := make([]*store.HistoricRebound, 0, 64)
+
+	build := func(running bool) *store.HistoricRangeReport {
+		rng := buildHistoricRangeReport(fromISO, toISO, days, trades, skipped, running)
+		rng.Rebound = buildReboundRange(fromISO, toISO, rebounds)
+		return rng
+	}
+	publish := func(running bool) {
+		e.st.SetHistoricRangeReport(build(running))
+	}
+	publish(true)
+
+	for d := fromDay; !d.After(toDay); d = d.AddDate(0, 0, 1) {
+		select {
+		case <-ctx.Done():
+			publish(false)
+			return ctx.Err()
+		default:
+		}
+
+		// (NEW) weekends and exchange holidays are not sessions
+		if !calendar.IsTradingDay(d) {
+			continue
+		}
+
+		sess := e.SessionFor(d)
+		openNY, selNY, cutoffNY, exitNY := sess.OpenNY, sess.SelNY, sess.CutoffNY, sess.ExitNY
+
+		// never mix a partial session into the aggregate
+		if sameDayInLoc(d, asOfNY, e.loc) && asOfNY.Before(exitNY) {
+			skipped = append(skipped, d.Format("2006-01-02"))
+			continue
+		}
+
+		ok, err := e.hasOpen5mData(ctx, openNY, selNY)
+		if err != nil {
+			publish(false)
+			return fmt.Errorf("%s: %w", d.Format("2006-01-02"), err)
+		}
+		if !ok {
+			skipped = append(skipped, d.Format("2006-01-02"))
+			continue
+		}
+
+		note := fmt.Sprintf("Range backtest %s → %s: session %d.", fromISO, toISO, len(days)+1)
+		e.st.ResetForHistoricRun(d, d, note)
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("RANGE backtest: replaying %s (%s → %s).", d.Format("2006-01-02"), fromISO, toISO), "", "info")
+
+		rep, err := e.replaySession(ctx, d, openNY, selNY, cutoffNY, exitNY, exitNY)
+		if err != nil {
+			publish(false)
+			return fmt.Errorf("%s: %w", d.Format("2006-01-02"), err)
+		}
+
+		days = append(days, rep.Summary)
+		trades = append(trades, rep.Trades...)
+		if rep.Rebound != nil {
+			rebounds = append(rebounds, rep.Rebound)
+		}
+		publish(true)
+	}
+
+	rng := build(false)
+	e.st.SetHistoricRangeReport(rng)
+	e.saveReport(&reportdb.Record{
+		Meta: reportdb.Meta{
+			Kind:    reportdb.KindRange,
+			DateNY:  rng.Summary.DateNY,
+			Summary: rng.Summary,
+		},
+		Range: rng,
+	})
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Range backtest done: %d sessions, %d trades, net P&L %.2f (gross %.2f, costs %.2f), max drawdown %.2f.",
+		rng.Sessions,
+		rng.Summary.TradesTaken,
+		rng.Summary.NetPnL,
+		rng.Summary.GrossPnL,
+		rng.Summary.TotalCosts,
+		rng.MaxDrawdown,
+	), "", "info")
+	return nil
+}
+
+// buildHistoricRangeReport aggregates per-session summaries and trades into a range report.
+// Equity is cumulative net P&L (starting at 0); drawdown is measured from the running peak.
+func buildHistoricRangeReport(fromISO, toISO string, days []store.HistoricSummary, trades []store.HistoricTrade, skipped []string, running bool) *store.HistoricRangeReport {
+	sum := summarizeTrades(trades)
+	sum.DateNY = fromISO + " → " + toISO
+	for i, d := range days {
+		if i == 0 {
+			sum.Sizing = d.Sizing
+			sum.CostModel = d.CostModel
+			sum.WindowStartNY = d.WindowStartNY
+			sum.WindowEndNY = d.WindowEndNY
+		}
+		sum.Candidates += d.Candidates
+		sum.NoEntry += d.NoEntry
+		sum.ExcludedTrades += d.ExcludedTrades
+	}
+
+	rng := &store.HistoricRangeReport{
+		FromNY:   fromISO,
+		ToNY:     toISO,
+		Running:  running,
+		Summary:  sum,
+		Sessions: len(days),
+		Skipped:  append([]string(nil), skipped...),
+		Days:     append([]store.HistoricSummary(nil), days...),
+		Equity:   make([]store.HistoricEquityPoint, 0, len(days)),
+		Trades:   append([]store.HistoricTrade(nil), trades...),
+	}
+
+	equity := 0.0
+	peak := 0.0
+	for i, d := range days {
+		switch {
+		case d.NetPnL > 0:
+			rng.GreenDays++
+		case d.NetPnL < 0:
+			rng.RedDays++
+		default:
+			rng.FlatDays++
+		}
+		if i == 0 || d.NetPnL > rng.BestDayPnL {
+			rng.BestDayPnL = d.NetPnL
+		}
+		if i == 0 || d.NetPnL < rng.WorstDayPnL {
+			rng.WorstDayPnL = d.NetPnL
+		}
+
+		equity += d.NetPnL
+		if equity > peak {
+			peak = equity
+		}
+		dd := equity - peak
+		if dd < rng.MaxDrawdown {
+			rng.MaxDrawdown = dd
+			rng.MaxDrawdownDateNY = d.DateNY
+		}
+
+		rng.Equity = append(rng.Equity, store.HistoricEquityPoint{
+			DateNY:   d.DateNY,
+			PnL:      d.NetPnL,
+			Equity:   equity,
+			Drawdown: dd,
+		})
+	}
+	if len(days) > 0 {
+		rng.AvgDayPnL = equity / float64(len(days))
+	}
+
+	return rng
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"massive-orb/internal/marketdata"
+)
+
+// open5mVolume sums 1-minute aggregate volumes in [start,end) (expected 09:30-09:35).
+func (e *Engine) open5mVolume(ctx context.Context, ticker string, startNY, endNY time.Time) (vol float64, ok bool, err error) {
+	bars, err := e.md.MinuteBars(ctx, ticker, startNY, endNY)
+	if err != nil {
+		return 0, false, err
+	}
+	sum := 0.0
+	n := 0
+	for _, a := range bars {
+		if a.Volume > 0 {
+			sum += a.Volume
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false, nil
+	}
+	return sum, true, nil
+}
+
+// open5mMetrics returns (open0930, orHigh, orLow, vol) for 1-minute bars in [start,end).
+func (e *Engine) open5mMetrics(ctx context.Context, ticker string, startNY, endNY time.Time) (open0930, orHigh, orLow, vol float64, ok bool, err error) {
+	bars, err := e.md.MinuteBars(ctx, ticker, startNY, endNY)
+	if err != nil {
+		return 0, 0, 0, 0, false, err
+	}
+
+	n := 0
+	for _, a := range bars {
+		if a.Open <= 0 {
+			continue
+		}
+		if n == 0 {
+			open0930 = a.Open
+			orHigh = a.High
+			orLow = a.Low
+		} else {
+			if a.High > orHigh {
+				orHigh = a.High
+			}
+			if orLow == 0 || a.Low < orLow {
+				orLow = a.Low
+			}
+		}
+		vol += a.Volume
+		n++
+	}
+	if n == 0 {
+		return 0, 0, 0, 0, false, nil
+	}
+	return open0930, orHigh, orLow, vol, true, nil
+}
+
+// minLowAndLastClose scans the 1-minute bars starting before endNY (oldest first) for:
+// - min low price
+// - time (NY) of that min low (bar start)
+// - last close seen (approx px at endNY)
+func minLowAndLastClose(bars []marketdata.Bar, endNY time.Time) (minLow float64, minLowTime time.Time, lastClose float64, ok bool) {
+	for _, a := range bars {
+		if !a.Start.Before(endNY) {
+			break
+		}
+		if a.Low > 0 {
+			if minLow == 0 || a.Low < minLow {
+				minLow = a.Low
+				minLowTime = a.Start
+			}
+		}
+		if a.Close > 0 {
+			lastClose = a.Close
+		}
+	}
+	if minLow <= 0 || lastClose <= 0 {
+		return 0, time.Time{}, 0, false
+	}
+	return minLow, minLowTime, lastClose, true
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"time"
+
+	"massive-orb/internal/store"
+)
+
+// Entry modes (RuntimeFilters.EntryMode).
+const (
+	EntryVWAPCross  = "vwap_cross"  // VWAP cross since 09:31, still on the right side of VWAP after 09:35
+	EntryORBreakout = "or_breakout" // print through the opening-range high (under the low for shorts) after 09:35
+)
+
+// trackBreakout folds one print into the 1-minute volume counter and, while armed (flat,
+// between selection and cutoff), records the first print through each side of the opening range.
+func trackBreakout(t *store.TickerState, trNY time.Time, price, size float64, armed bool) {
+	minute := trNY.Truncate(time.Minute)
+	if !minute.Equal(t.VolMinute) {
+		t.VolMinute = minute
+		t.MinuteVol = 0
+	}
+	t.MinuteVol += size
+
+	if !armed {
+		return
+	}
+	if t.ORHigh > 0 && price > t.ORHigh && t.BreakoutUpTime.IsZero() {
+		t.BreakoutUpTime = trNY
+		t.BreakoutUpPrice = price
+	}
+	if t.ORLow > 0 && price < t.ORLow && t.BreakoutDownTime.IsZero() {
+		t.BreakoutDownTime = trNY
+		t.BreakoutDownPrice = price
+	}
+}
+
+// breakoutVolOK is the optional volume confirmation: the current minute has traded at least
+// mult × the average opening-range minute (Open5mVol / 5). mult <= 0 turns it off.
+func breakoutVolOK(t *store.TickerState, mult float64) bool {
+	if mult <= 0 {
+		return true
+	}
+	return t.Open5mVol > 0 && t.MinuteVol >= mult*t.Open5mVol/5.0
+}
+
+// breakoutIntent is the or_breakout entry for the print at price (filters already checked),
+// with the stop at the other side of the opening range.
+func breakoutIntent(t *store.TickerState, f store.RuntimeFilters, price float64) Intent {
+	if !breakoutVolOK(t, f.BreakoutVolMult) {
+		return Intent{}
+	}
+	side := ""
+	switch {
+	case f.Side != SideShort && t.ORHigh > 0 && price > t.ORHigh:
+		side = SideLong
+	case (f.Side == SideShort || f.Side == SideBoth) && t.ORLow > 0 && price < t.ORLow:
+		side = SideShort
+	default:
+		return Intent{}
+	}
+	in := enterIntent(side)
+	in.Stop = breakoutStop(t, side)
+	return in
+}
+
+// breakoutStop is the opposite side of the opening range (0 when the range is unknown).
+func breakoutStop(t *store.TickerState, side string) float64 {
+	if side == SideShort {
+		return t.ORHigh
+	}
+	return t.ORLow
+}
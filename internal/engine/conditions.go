@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/store"
+)
+
+// tradeFilter is the set of trade condition codes whose prints stay out of VWAP, cross
+// detection and stop/target triggers (trades.exclude_conditions). They are only counted
+// (TickerState.ExcludedTrades), so a bad print can no longer fire a fake STOP.
+type tradeFilter map[int32]struct{}
+
+func newTradeFilter(codes []int) tradeFilter {
+	f := make(tradeFilter, len(codes))
+	for _, c := range codes {
+		f[int32(c)] = struct{}{}
+	}
+	return f
+}
+
+// excluded reports whether any of the print's conditions is filtered.
+func (f tradeFilter) excluded(conds []int32) bool {
+	if len(f) == 0 {
+		return false
+	}
+	for _, c := range conds {
+		if _, ok := f[c]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eligible is the per-print gate shared by live, seed and replay paths: a positive price and
+// size and no excluded condition. Excluded prints are counted on t (when given).
+func (f tradeFilter) eligible(t *store.TickerState, tr marketdata.Trade) bool {
+	if tr.Price <= 0 || tr.Size <= 0 {
+		return false
+	}
+	if f.excluded(tr.Conditions) {
+		if t != nil {
+			t.ExcludedTrades++
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"massive-orb/internal/config"
+)
+
+const (
+	SlippageNone   = ""       // fill at the print that triggered the entry/exit
+	SlippageBps    = "bps"    // fixed basis points against us on every fill
+	SlippageSpread = "spread" // pay a fraction of an assumed bid/ask spread on every fill
+	SlippageQuote  = "quote"  // fill at the live quote (ask for buys, bid for sells); spread assumption without one
+)
+
+// Costs turn a perfect-fill trade into what the account would actually see: commissions,
+// slippage on entry and exit, and the regulatory fees charged on sells. Part of SimParams
+// so historic reports and sweeps net them out the same way.
+type Costs struct {
+	CommissionPerShare float64 `json:"commission_per_share,omitempty"`
+	CommissionPerOrder float64 `json:"commission_per_order,omitempty"`
+
+	SlippageMode   string  `json:"slippage_mode,omitempty"`
+	SlippageBps    float64 `json:"slippage_bps,omitempty"`
+	SpreadBps      float64 `json:"spread_bps,omitempty"`      // assumed quoted spread (spread mode)
+	SpreadMin      float64 `json:"spread_min,omitempty"`      // spread floor in dollars (spread mode)
+	SpreadFraction float64 `json:"spread_fraction,omitempty"` // share of the spread paid per fill (0.5 = half)
+
+	SECFeeRate       float64 `json:"sec_fee_rate,omitempty"`        // per dollar of sell proceeds
+	FINRATAFPerShare float64 `json:"finra_taf_per_share,omitempty"` // per share sold
+	FINRATAFMax      float64 `json:"finra_taf_max,omitempty"`       // cap per sell (0 = none)
+}
+
+func costsFromConfig(cfg config.Config) Costs {
+	c := cfg.Costs
+	return Costs{
+		CommissionPerShare: c.CommissionPerShare,
+		CommissionPerOrder: c.CommissionPerOrder,
+		SlippageMode:       c.SlippageMode,
+		SlippageBps:        c.SlippageBps,
+		SpreadBps:          c.SpreadBps,
+		SpreadMin:          c.SpreadMin,
+		SpreadFraction:     c.SpreadFraction,
+		SECFeeRate:         c.SECFeeRate,
+		FINRATAFPerShare:   c.FINRATAFPerShare,
+		FINRATAFMax:        c.FINRATAFMax,
+	}
+}
+
+// tradeCost is what one round trip paid on top of its gross P&L (all amounts >= 0).
+type tradeCost struct {
+	Slippage   float64
+	Commission float64
+	Fees       float64
+}
+
+func (tc tradeCost) total() float64 { return tc.Slippage + tc.Commission + tc.Fees }
+
+// slippage per share for a fill at price. quotePx is the quoted side the fill would take
+// (ask for a buy, bid for a sell; 0 = no quote), used by quote mode only.
+func (c Costs) slippage(price, quotePx float64, buy bool) float64 {
+	switch c.SlippageMode {
+	case SlippageBps:
+		return price * c.SlippageBps / 10000.0
+	case SlippageQuote:
+		if quotePx > 0 {
+			// never better than the print: a stale quote inside the print is no price improvement
+			if buy {
+				return math.Max(quotePx-price, 0)
+			}
+			return math.Max(price-quotePx, 0)
+		}
+		fallthrough
+	case SlippageSpread:
+		spread := math.Max(price*c.SpreadBps/10000.0, c.SpreadMin)
+		return spread * c.SpreadFraction
+	}
+	return 0
+}
+
+func (c Costs) commission(shares int) float64 {
+	if shares <= 0 {
+		return 0
+	}
+	return c.CommissionPerShare*float64(shares) + c.CommissionPerOrder
+}
+
+// sellFees are the SEC fee on proceeds plus the FINRA trading activity fee.
+func (c Costs) sellFees(price float64, shares int) float64 {
+	sec := c.SECFeeRate * price * float64(shares)
+	taf := c.FINRATAFPerShare * float64(shares)
+	if c.FINRATAFMax > 0 && taf > c.FINRATAFMax {
+		taf = c.FINRATAFMax
+	}
+	return sec + taf
+}
+
+// roundTrip prices a trade opened at entry and closed at exit (both trigger prints), with the
+// quotes seen at each (0 = none, see TickerState.EntryQuotePrice).
+// Sell-side fees land on the exit for longs and on the entry for shorts.
+func (c Costs) roundTrip(side string, entry, exit float64, shares int, entryQuote, exitQuote float64) tradeCost {
+	n := float64(shares)
+	long := side != SideShort
+	entrySlip := c.slippage(entry, entryQuote, long)
+	exitSlip := c.slippage(exit, exitQuote, !long)
+	sellPx := exit - exitSlip
+	if !long {
+		sellPx = entry - entrySlip
+	}
+	return tradeCost{
+		Slippage:   (entrySlip + exitSlip) * n,
+		Commission: c.commission(shares) * 2,
+		Fees:       c.sellFees(sellPx, shares),
+	}
+}
+
+// String is the short label shown next to reports ("none", "$0.005/sh · 5 bps slip · SEC/FINRA").
+func (c Costs) String() string {
+	parts := make([]string, 0, 4)
+	if c.CommissionPerShare > 0 {
+		parts = append(parts, fmt.Sprintf("$%g/sh", c.CommissionPerShare))
+	}
+	if c.CommissionPerOrder > 0 {
+		parts = append(parts, fmt.Sprintf("$%g/order", c.CommissionPerOrder))
+	}
+	switch c.SlippageMode {
+	case SlippageBps:
+		if c.SlippageBps > 0 {
+			parts = append(parts, fmt.Sprintf("%g bps slip", c.SlippageBps))
+		}
+	case SlippageSpread:
+		parts = append(parts, fmt.Sprintf("%g%% of %g bps spread", c.SpreadFraction*100, c.SpreadBps))
+	case SlippageQuote:
+		parts = append(parts, fmt.Sprintf("quoted bid/ask (else %g%% of %g bps spread)", c.SpreadFraction*100, c.SpreadBps))
+	}
+	if c.SECFeeRate > 0 || c.FINRATAFPerShare > 0 {
+		parts = append(parts, "SEC/FINRA")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " · ")
+}
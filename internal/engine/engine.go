@@ -8,47 +8,89 @@ import (
 	"sync"
 	"time"
 
+	"massive-orb/internal/broker"
+	"massive-orb/internal/calendar"
 	"massive-orb/internal/config"
-	"massive-orb/internal/massive"
+	"massive-orb/internal/marketdata"
 	"massive-orb/internal/nato"
 	"massive-orb/internal/openai"
+	"massive-orb/internal/reportdb"
 	"massive-orb/internal/store"
-
-	massivews "github.com/massive-com/client-go/v2/websocket"
 )
 
 type Engine struct {
-	cfg        config.Config
-	st         *store.Store
-	massiveKey string
-	tts        *openai.TTSClient
+	cfg config.Config
+	st  *store.Store
+	md  marketdata.MarketData
+	tts *openai.TTSClient
+
+	// NEW: optional; finished historic reports are saved here
+	reports *reportdb.DB
+
+	// NEW: optional; live signals become orders (see SetBroker)
+	broker broker.Broker
+
+	// NEW: trade conditions kept out of VWAP/signals (see conditions.go)
+	condFilter tradeFilter
+
+	// NEW: realtime shadow profiles (see shadow.go)
+	shadowMu      sync.Mutex
+	shadows       []*shadowProfile
+	liveFinal     *store.HistoricReport
+	shadowSeeding bool // profiles still seeding; their prints wait in shadowBuf
+	shadowBuf     []shadowPrint
 
 	loc *time.Location
 }
 
-func New(cfg config.Config, st *store.Store, massiveKey string, tts *openai.TTSClient) *Engine {
+func New(cfg config.Config, st *store.Store, md marketdata.MarketData, tts *openai.TTSClient, reports *reportdb.DB) *Engine {
 	loc, _ := time.LoadLocation(cfg.Market.Timezone)
 	return &Engine{
-		cfg:        cfg,
-		st:         st,
-		massiveKey: massiveKey,
-		tts:        tts,
-		loc:        loc,
+		cfg:     cfg,
+		st:      st,
+		md:      md,
+		tts:     tts,
+		reports: reports,
+		loc:     loc,
+
+		condFilter: newTradeFilter(cfg.Trades.ExcludeConditions),
 	}
 }
 
 func (e *Engine) Run(ctx context.Context) error {
 	// Set today's key times in NY
 	nowNY := time.Now().In(e.loc)
-	openNY := atTime(nowNY, e.cfg.Market.OpenTime, e.loc)
-	selNY := atTime(nowNY, e.cfg.Market.SelectionTime, e.loc)
-	cutoffNY := atTime(nowNY, e.cfg.Market.VWAPCrossCutoff, e.loc)
-	exitNY := atTime(nowNY, e.cfg.Market.ForceExitTime, e.loc)
+	today := e.SessionFor(nowNY)
+	openNY, selNY, cutoffNY, exitNY := today.OpenNY, today.SelNY, today.CutoffNY, today.ExitNY
 
 	e.st.SetTimes(openNY, selNY, cutoffNY, exitNY)
 
 	e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("Loaded watchlist: %d tickers", len(e.st.Watchlist())), "", "info")
 
+	// (NEW) exchange calendar: nothing to do on a holiday / weekend
+	if !calendar.IsTradingDay(nowNY) {
+		e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("Market closed today (%s).", calendar.Describe(nowNY)), "", "warn")
+		e.st.SetPhase(store.PhaseClosed)
+		return nil
+	}
+	if calendar.EarlyClose(nowNY) {
+		e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("Early close today (13:00): force exit at %s.", exitNY.Format("15:04:05")), "", "warn")
+	}
+
+	// (NEW) previous close + 04:00 → open metrics for the gap filters, started before the open wait
+	// so the previous closes are in by 09:30 (skipped while those filters are off, see premarket.go)
+	var premarketDone chan struct{}
+	pctx, stopPremarket := context.WithCancel(ctx)
+	defer stopPremarket()
+	if premarketFiltersOn(e.st.Filters()) {
+		premarketDone = make(chan struct{})
+		go func() {
+			defer close(premarketDone)
+			e.collectPremarketLive(pctx, openNY)
+		}()
+	}
+	lateStart := !nowNY.Before(selNY)
+
 	// Wait for open
 	if nowNY.Before(openNY) {
 		e.st.SetPhase(store.PhaseWaitingOpen)
@@ -65,59 +107,15 @@ func (e *Engine) Run(ctx context.Context) error {
 	e.st.SetPhase(store.PhaseCollecting5m)
 
 	// Phase 1: Subscribe to minute aggregates for all watchlist tickers
-	wsAgg, err := massive.NewWS(e.massiveKey, e.cfg.Massive.Feed)
+	// (NEW: a dropped stream is resubscribed until 09:35, see feed.go)
+	aggs, err := e.startAggFeed(ctx, openNY, selNY)
 	if err != nil {
 		return err
 	}
-	defer wsAgg.Close()
-
-	// Subscribe in batches (important for 8k)
-	wl := e.st.Watchlist()
-	for i := 0; i < len(wl); i += e.cfg.Massive.WSBatchSize {
-		j := i + e.cfg.Massive.WSBatchSize
-		if j > len(wl) {
-			j = len(wl)
-		}
-		if err := wsAgg.Subscribe(massivews.StocksMinAggs, wl[i:j]...); err != nil {
-			return fmt.Errorf("subscribe minute aggs: %w", err)
-		}
-	}
-
-	if err := wsAgg.Connect(); err != nil {
-		return fmt.Errorf("ws connect: %w", err)
-	}
+	defer aggs.stop()
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", "Collecting 09:30-09:34 minute bars for open-5m metrics...", "", "info")
 
-	// Collect minute bars until selection time
-	done0935 := make(chan struct{})
-	go func() {
-		defer close(done0935)
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case err, ok := <-wsAgg.Error():
-				if !ok {
-					return
-				}
-				if err != nil {
-					e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS error: %v", err), "", "warn")
-					return
-				}
-			case msg, ok := <-wsAgg.Output():
-				if !ok {
-					return
-				}
-				agg, ok := msg.(massive.EquityAgg)
-				if !ok {
-					continue
-				}
-				e.onMinuteAgg(openNY, selNY, agg)
-			}
-		}
-	}()
-
 	// Wait until 09:35
 	if time.Now().In(e.loc).Before(selNY) {
 		timer := time.NewTimer(time.Until(selNY))
@@ -129,26 +127,61 @@ func (e *Engine) Run(ctx context.Context) error {
 		}
 	}
 
+	// premarket metrics get until 09:35 (a session started after it waits for them); when they are
+	// late, or the filters were switched on meanwhile, selection runs without them
+	premarketReady := false
+	if premarketDone != nil {
+		if lateStart {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-premarketDone:
+			}
+		}
+		select {
+		case <-premarketDone:
+			premarketReady = true
+		default:
+		}
+	}
+	if !premarketReady && premarketFiltersOn(e.st.Filters()) {
+		stopPremarket()
+		e.dropPremarketFilters()
+	}
+
 	e.st.SetPhase(store.PhaseSelecting0935)
-	wsAgg.Close()
-	<-done0935
+	if aggs.stop() {
+		// bars missed while the stream was down: the official 09:30–09:34 bars replace the streamed sums
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Minute-agg stream had a gap: refetching open-5m bars via REST for the watchlist...", "", "warn")
+		_ = e.collectOpen5mViaREST(ctx, openNY, selNY)
+	}
 
-	// Select candidates
+	// Select candidates (live, then each shadow profile with its own filters)
 	candidates := e.selectCandidatesAt0935()
-	if len(candidates) == 0 {
+	shadows := e.selectShadows()
+
+	// (NEW) sold-off alerts: minute aggs keep streaming for the watchlist until the scan time, see soldoffwatch.go
+	var watch *soldOffWatch
+	if e.cfg.Alerts.SoldOff {
+		untilNY := minTime(atTime(nowNY, e.st.Filters().SoldOffScanTime, e.loc), atTime(nowNY, calendar.CloseHMS(nowNY), e.loc))
+		if untilNY.After(selNY) && time.Now().Before(untilNY) {
+			watch = e.startSoldOffWatch(ctx, openNY, selNY, untilNY, e.snapshotOpen5mMetricsForWatchlist())
+			defer watch.stop()
+		}
+	}
+
+	if len(candidates) == 0 && len(shadowSymbols(shadows)) == 0 {
 		e.emit(time.Now().In(e.loc), "SYSTEM", "", "No tickers matched open_5m filters at 09:35.", "", "info")
+		if watch != nil {
+			watch.wait(ctx)
+		}
 		e.st.SetPhase(store.PhaseClosed)
 		return nil
 	}
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("09:35 selection: %d tickers matched opening filters (switching to trades)", len(candidates)), "", "info")
 
-	// Create REST client
-	restClient := massive.NewREST(e.massiveKey)
-
-	rest := newRESTShim(restClient)
-
-	tracked, err := e.buildTrackedStates(ctx, rest, openNY, selNY, candidates)
+	tracked, err := e.buildTrackedStates(ctx, openNY, selNY, candidates)
 
 	if err != nil {
 		return err
@@ -157,25 +190,38 @@ func (e *Engine) Run(ctx context.Context) error {
 
 	e.st.SetPhase(store.PhaseTrackingTicks)
 
-	// Phase 2: WebSocket trades for tracked tickers only
-	wsTrades, err := massive.NewWS(e.massiveKey, e.cfg.Massive.Feed)
-	if err != nil {
-		return err
-	}
-	defer wsTrades.Close()
-
-	syms := make([]string, 0, len(tracked))
+	// Phase 2: WebSocket trades for tracked tickers only (live + shadow profiles)
+	symset := make(map[string]struct{}, len(tracked))
 	for sym := range tracked {
+		symset[sym] = struct{}{}
+	}
+	for _, sym := range shadowSymbols(shadows) {
+		symset[sym] = struct{}{}
+	}
+	syms := make([]string, 0, len(symset))
+	for sym := range symset {
 		syms = append(syms, sym)
 	}
 	sort.Strings(syms)
+	sess := Session{OpenNY: openNY, SelNY: selNY, CutoffNY: cutoffNY, ExitNY: exitNY}
 
-	if err := wsTrades.Subscribe(massivews.StocksTrades, syms...); err != nil {
-		return fmt.Errorf("subscribe trades: %w", err)
-	}
-	if err := wsTrades.Connect(); err != nil {
-		return fmt.Errorf("ws trades connect: %w", err)
+	// shadow profiles seed alongside the live feed; their prints are buffered until they are ready
+	e.startShadows(ctx, openNY, selNY, shadows, tracked)
+
+	// (NEW) reconnects with backoff and backfills the gap from REST, see feed.go
+	feed, err := e.startTradeFeed(ctx, syms, exitNY, func(tr marketdata.Trade, live bool) {
+		e.onTrade(openNY, selNY, cutoffNY, exitNY, tr, live)
+		e.shadowTrade(sess, tr, live)
+	})
+	if err != nil {
+		return err
 	}
+	defer feed.stop()
+
+	// (NEW) NBBO quotes for the spread filter and quoted fills, see quotes.go
+	qctx, stopQuotes := context.WithCancel(ctx)
+	defer stopQuotes()
+	go e.runQuotes(qctx, syms, exitNY)
 
 	// 11am timer
 	closed11am := make(chan struct{})
@@ -192,39 +238,24 @@ func (e *Engine) Run(ctx context.Context) error {
 			}
 		}
 		e.onElevenAM(exitNY)
+		e.finishProfiles(exitNY)
 	}()
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", "Tracking tick data (trades) for filtered tickers...", "", "info")
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-closed11am:
-			e.st.SetPhase(store.PhaseClosed)
-			return nil
-		case err, ok := <-wsTrades.Error():
-			if !ok {
-				return nil
-			}
-			if err != nil {
-				return err
-			}
-		case msg, ok := <-wsTrades.Output():
-			if !ok {
-				return nil
-			}
-			tr, ok := msg.(massive.EquityTrade)
-			if !ok {
-				continue
-			}
-			e.onTrade(openNY, selNY, cutoffNY, exitNY, tr)
-		}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-closed11am:
+		feed.stop()
+		stopQuotes()
+		e.st.SetPhase(store.PhaseClosed)
+		return nil
 	}
 }
 
 // ---- Minute aggregates processing (09:30-09:34) ----
-func (e *Engine) onMinuteAgg(openNY, selNY time.Time, agg massive.EquityAgg) {
+func (e *Engine) onMinuteAgg(openNY, selNY time.Time, agg marketdata.Agg) {
 	sym := agg.Symbol
 	if sym == "" {
 		return
@@ -267,7 +298,9 @@ func (e *Engine) onMinuteAgg(openNY, selNY time.Time, agg massive.EquityAgg) {
 }
 
 func (e *Engine) selectCandidatesAt0935() []string {
-	f := e.st.Filters()
+	p := e.simParams()
+	f := p.Filters
+	sess := e.session()
 
 	nowNY := time.Now().In(e.loc)
 	e.emit(nowNY, "SYSTEM", "", "Computing open_5m_range_pct + open_5m_vol and filtering...", "", "info")
@@ -287,7 +320,7 @@ func (e *Engine) selectCandidatesAt0935() []string {
 		vol := t.Open5mVol
 
 		if rng >= f.Open5mRangePctMin && rng <= f.Open5mRangePctMax &&
-			vol >= f.Open5mVolMin && vol <= f.Open5mVolMax {
+			vol >= f.Open5mVolMin && vol <= f.Open5mVolMax && premarketOK(t, f) {
 
 			candidates = append(candidates, sym)
 
@@ -295,6 +328,7 @@ func (e *Engine) selectCandidatesAt0935() []string {
 			e.st.UpsertTicker(sym, func(tt *store.TickerState) {
 				tt.Open5mRangePct = rng
 				tt.Status = "selected"
+				p.strategy().OnOpenRange(tt, p, sess)
 			})
 		}
 	}
@@ -305,11 +339,46 @@ func (e *Engine) selectCandidatesAt0935() []string {
 
 func (e *Engine) buildTrackedStates(
 	ctx context.Context,
-	rest *mrestClientShim,
 	openNY, selNY time.Time,
 	candidates []string,
 ) (map[string]*store.TickerState, error) {
-	// The massive rest client type is in github.com/massive-com/client-go/v2/rest (we keep shim below)
+	// first, create shallow states from store
+	tracked := make(map[string]*store.TickerState, len(candidates))
+	for _, sym := range candidates {
+		t := e.st.GetTicker(sym)
+		if t == nil {
+			continue
+		}
+		t.Status = "tracking"
+		tracked[sym] = t
+	}
+
+	e.applyOpen5mHistory(ctx, openNY, tracked)
+
+	p := e.simParams()
+	for _, sym := range candidates {
+		if ts := tracked[sym]; ts != nil {
+			p.strategy().OnSelection(ts, p, e.session())
+		}
+	}
+
+	// Seed VWAP with trades from open->09:35 for each candidate
+	// (trade-level VWAP is “better data” than bar typical-price approximation)
+	e.seedStates(ctx, openNY, selNY, candidates, tracked, p)
+
+	// Put updated states back in store
+	for sym, ts := range tracked {
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			*t = *ts
+		})
+	}
+
+	return tracked, nil
+}
+
+// applyOpen5mHistory fills Prev10AvgOpen5mVol / Open5mTodayPct on states from the previous
+// sessions' open-5m volumes (worker pool, history.max_workers).
+func (e *Engine) applyOpen5mHistory(ctx context.Context, openNY time.Time, states map[string]*store.TickerState) {
 	cfg := e.cfg
 
 	// worker pool for historical open5m volumes
@@ -328,25 +397,26 @@ func (e *Engine) buildTrackedStates(
 		workerN = 1
 	}
 
+	// workers only read Open5mVol; captured up front so they never touch the map
+	vols := make(map[string]float64, len(states))
+	for sym, t := range states {
+		vols[sym] = t.Open5mVol
+	}
+
 	var wg sync.WaitGroup
 	for i := 0; i < workerN; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for sym := range jobs {
-				t := e.st.GetTicker(sym)
-				if t == nil {
-					results <- result{sym: sym, err: fmt.Errorf("missing ticker state")}
-					continue
-				}
-				avg, err := e.avgPrevSessionsOpen5mVol(ctx, rest, sym, openNY, cfg.History.Open5mLookbackSessions, cfg.History.MaxCalendarLookback)
+				avg, err := e.avgPrevSessionsOpen5mVol(ctx, sym, openNY, cfg.History.Open5mLookbackSessions, cfg.History.MaxCalendarLookback)
 				if err != nil {
 					results <- result{sym: sym, err: err}
 					continue
 				}
 				var pct float64
 				if avg > 0 {
-					pct = (t.Open5mVol / avg) * 100.0
+					pct = (vols[sym] / avg) * 100.0
 				}
 				results <- result{sym: sym, avgPrev10: avg, todayPct: pct, err: nil}
 			}
@@ -355,7 +425,7 @@ func (e *Engine) buildTrackedStates(
 
 	go func() {
 		defer close(jobs)
-		for _, sym := range candidates {
+		for sym := range vols {
 			select {
 			case <-ctx.Done():
 				return
@@ -369,59 +439,43 @@ func (e *Engine) buildTrackedStates(
 		close(results)
 	}()
 
-	// Seed VWAP with trades from open->09:35 for each candidate
-	// (trade-level VWAP is “better data” than bar typical-price approximation)
-	tracked := make(map[string]*store.TickerState, len(candidates))
-
-	// first, create shallow states from store
-	for _, sym := range candidates {
-		t := e.st.GetTicker(sym)
-		if t == nil {
-			continue
-		}
-		t.Status = "tracking"
-		tracked[sym] = t
-	}
-
 	// apply history results
 	for r := range results {
 		if r.err != nil {
 			e.emit(time.Now().In(e.loc), "SYSTEM", r.sym, fmt.Sprintf("History calc failed: %v", r.err), "", "warn")
 			continue
 		}
-		ts := tracked[r.sym]
+		ts := states[r.sym]
 		if ts == nil {
 			continue
 		}
 		ts.Prev10AvgOpen5mVol = r.avgPrev10
 		ts.Open5mTodayPct = r.todayPct
 	}
+}
 
-	// seed VWAP from REST trades (open->09:35) for tracked tickers
-	for _, sym := range candidates {
-		ts := tracked[sym]
+// seedStates replays open → 09:35 trades into each state (in syms order) under p.
+func (e *Engine) seedStates(ctx context.Context, openNY, selNY time.Time, syms []string, states map[string]*store.TickerState, p SimParams) {
+	for _, sym := range syms {
+		ts := states[sym]
 		if ts == nil {
 			continue
 		}
 
-		if err := e.seedVWAPFromTrades(ctx, rest, sym, openNY, selNY, ts); err != nil {
+		if err := e.seedVWAPFromTrades(ctx, sym, openNY, selNY, ts, p); err != nil {
 			e.emit(time.Now().In(e.loc), "SYSTEM", sym, fmt.Sprintf("VWAP seed failed: %v", err), "", "warn")
 		}
 	}
-
-	// Put updated states back in store
-	for sym, ts := range tracked {
-		e.st.UpsertTicker(sym, func(t *store.TickerState) {
-			*t = *ts
-		})
-	}
-
-	return tracked, nil
 }
 
-func (e *Engine) seedVWAPFromTrades(ctx context.Context, rest *mrestClientShim, sym string, startNY, endNY time.Time, ts *store.TickerState) error {
-	it := rest.ListTrades(ctx, sym, startNY, endNY)
-	crossStartNY := startNY.Add(1 * time.Minute) // 09:31 if open is 09:30
+// seedVWAPFromTrades replays open → 09:35 prints through stepTrade without actions, so VWAP,
+// ATR bars and whatever the strategy tracks before selection (e.g. the first VWAP cross from 09:31)
+// match a sweep replaying the same tape.
+func (e *Engine) seedVWAPFromTrades(ctx context.Context, sym string, startNY, endNY time.Time, ts *store.TickerState, p SimParams) error {
+	strat := p.strategy()
+	sess := e.session()
+
+	it := e.md.Trades(ctx, sym, startNY, endNY)
 	for it.Next() {
 		select {
 		case <-ctx.Done():
@@ -429,74 +483,39 @@ func (e *Engine) seedVWAPFromTrades(ctx context.Context, rest *mrestClientShim,
 		default:
 		}
 		tr := it.Item()
-		if tr.Price <= 0 || tr.Size <= 0 {
+		if tr.Timestamp == 0 || !e.condFilter.eligible(ts, tr) {
 			continue
 		}
+		trNY := time.UnixMilli(tr.Timestamp).In(e.loc)
 
-		tsMillis := tradeTimeMillis(tr)
-		if tsMillis == 0 {
-			continue
-		}
-		trNY := time.UnixMilli(tsMillis).In(e.loc)
-
-		// prev values for cross detection (previous trade)
-		prevPrice := ts.LastPrice
-		prevVWAP := ts.VWAP
-
-		// vwap update (trade-level)
-		ts.CumPV += tr.Price * tr.Size
-		ts.CumV += tr.Size
-		if ts.CumV > 0 {
-			ts.VWAP = ts.CumPV / ts.CumV
-		}
-		ts.LastPrice = tr.Price
-		ts.LastTrade = trNY
-
-		// Record first cross-up from below any time in [09:31, 09:35)
-		// (entry itself still won't happen until after 09:35 in processTrade)
-		if !ts.SawCrossInWindow && !trNY.Before(crossStartNY) {
-			cross := prevPrice > 0 && prevVWAP > 0 && prevPrice < prevVWAP && tr.Price >= ts.VWAP
-			if cross {
-				ts.SawCrossInWindow = true
-				ts.FirstCrossTime = trNY
-				ts.FirstCrossPrice = tr.Price
-			}
-		}
-	}
-	if err := it.Err(); err != nil {
-		return err
-	}
-
-	// Ensure the first post-09:35 tick has meaningful "prev" values.
-	if ts.CumV > 0 {
-		ts.PrevVWAP = ts.VWAP
-		ts.PrevPrice = ts.LastPrice
+		// entry itself still won't happen until after 09:35 in processTrade
+		stepTrade(ts, strat, p, sess, trNY, tr.Price, tr.Size, false)
 	}
-	return nil
+	return it.Err()
 }
 
 func (e *Engine) avgPrevSessionsOpen5mVol(
 	ctx context.Context,
-	rest *mrestClientShim,
 	sym string,
 	openNY time.Time,
 	sessionsNeeded int,
 	maxLookbackDays int,
 ) (avg float64, err error) {
 	var vols []float64
-	day := openNY
+	day := openNY.In(e.loc)
+	oldest := dateOnlyInLoc(day, e.loc).AddDate(0, 0, -maxLookbackDays)
 
-	for back := 1; back <= maxLookbackDays && len(vols) < sessionsNeeded; back++ {
+	// (NEW) previous sessions come from the exchange calendar, so weekends and holidays cost no REST calls
+	for d := calendar.PrevTradingDay(day); !d.Before(oldest) && len(vols) < sessionsNeeded; d = calendar.PrevTradingDay(d) {
 		select {
 		case <-ctx.Done():
 			return 0, ctx.Err()
 		default:
 		}
-		d := day.AddDate(0, 0, -back)
 		start := time.Date(d.Year(), d.Month(), d.Day(), 9, 30, 0, 0, e.loc)
 		end := start.Add(5 * time.Minute)
 
-		v, ok, err := rest.Open5mVolume(ctx, sym, start, end)
+		v, ok, err := e.open5mVolume(ctx, sym, start, end)
 		if err != nil {
 			continue
 		}
@@ -517,11 +536,16 @@ func (e *Engine) avgPrevSessionsOpen5mVol(
 }
 
 // ---- Trades processing (tick data) ----
-func (e *Engine) onTrade(openNY, selNY, cutoffNY, exitNY time.Time, tr massive.EquityTrade) {
-	e.processTrade(openNY, selNY, cutoffNY, exitNY, tr.Symbol, tr.Timestamp, tr.Price, float64(tr.Size), true)
+
+// onTrade handles a feed print (live false: backfilled after an outage, see processTrade).
+func (e *Engine) onTrade(openNY, selNY, cutoffNY, exitNY time.Time, tr marketdata.Trade, live bool) {
+	e.processTrade(openNY, selNY, cutoffNY, exitNY, tr.Symbol, tr.Timestamp, tr.Price, tr.Size, tr.Conditions, true, !live)
 }
 
-func (e *Engine) processTrade(openNY, selNY, cutoffNY, exitNY time.Time, sym string, tsMillis int64, price float64, size float64, allowActions bool) {
+// processTrade steps one print through the strategy. With allowActions false it only updates
+// state; late marks a print backfilled after a stream outage: exits still fire (at that print,
+// with a late warning) but entries are dropped, the signal is stale.
+func (e *Engine) processTrade(openNY, selNY, cutoffNY, exitNY time.Time, sym string, tsMillis int64, price float64, size float64, conds []int32, allowActions, late bool) {
 	if sym == "" {
 		return
 	}
@@ -541,191 +565,175 @@ func (e *Engine) processTrade(openNY, selNY, cutoffNY, exitNY time.Time, sym str
 		return
 	}
 
-	minAfterOpen := trNY.Sub(openNY).Seconds() / 60.0
-
-	// update VWAP & last (always, even after exit, so we can compute hold-to-cutoff + MFE/MAE)
-	e.st.UpsertTicker(sym, func(t *store.TickerState) {
-		t.LastTrade = trNY
-		t.MinutesAfterOpen = minAfterOpen
-
-		// prev values for cross detection
-		t.PrevPrice = t.LastPrice
-		t.PrevVWAP = t.VWAP
-
-		// vwap update (trade-level)
-		t.CumPV += price * size
-		t.CumV += size
-		if t.CumV > 0 {
-			t.VWAP = t.CumPV / t.CumV
-		}
-
-		t.LastPrice = price
-
-		// track MFE/MAE after entry, up to whatever data we process (historic ends at cutoff)
-		if t.HasPosition && !t.EntryTime.IsZero() && !trNY.Before(t.EntryTime) {
-			if t.MaxPriceSinceEntry == 0 || price > t.MaxPriceSinceEntry {
-				t.MaxPriceSinceEntry = price
-				t.MaxPriceSinceEntryTime = trNY
-			}
-			if t.MinPriceSinceEntry == 0 || price < t.MinPriceSinceEntry {
-				t.MinPriceSinceEntry = price
-				t.MinPriceSinceEntryTime = trNY
-			}
-		}
-	})
-
-	// Read back for logic checks
-	ts = e.st.GetTicker(sym)
-	if ts == nil {
+	// (NEW) odd lots, out-of-sequence, average-price... prints are only counted (trades.exclude_conditions)
+	if e.condFilter.excluded(conds) {
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			t.ExcludedTrades++
+		})
 		return
 	}
 
-	f := e.st.Filters()
+	// paper fills see the print before the strategy reacts to it (orders fill on the next print)
+	if allowActions {
+		e.feedBroker(sym, trNY, price, size)
+	}
 
-	// ------------------------------------------------------------
-	// Cross detection window (NEW):
-	// - detect cross-ups from below starting at 09:31 (open + 1 min)
-	// - keep detecting until cutoff (09:43)
-	// ------------------------------------------------------------
-	crossStartNY := openNY.Add(1 * time.Minute) // 09:31
-	crossNow := ts.PrevPrice > 0 && ts.PrevVWAP > 0 && ts.PrevPrice < ts.PrevVWAP && price >= ts.VWAP
+	// params are read before taking the store lock below
+	p := e.simParams()
 
-	sawCross := ts.SawCrossInWindow
-	if !ts.HasPosition && !ts.Exited && !sawCross && crossNow &&
-		!trNY.Before(crossStartNY) && trNY.Before(cutoffNY) {
-		sawCross = true
-		e.st.UpsertTicker(sym, func(t *store.TickerState) {
-			if !t.SawCrossInWindow {
-				t.SawCrossInWindow = true
-				t.FirstCrossTime = trNY
-				t.FirstCrossPrice = price
-			}
-		})
+	sess := Session{OpenNY: openNY, SelNY: selNY, CutoffNY: cutoffNY, ExitNY: exitNY}
+
+	var in Intent
+	e.st.UpsertTicker(sym, func(t *store.TickerState) {
+		in = stepTrade(t, p.strategy(), p, sess, trNY, price, size, allowActions)
+	})
+	if !allowActions {
+		return
 	}
 
-	// ------------------------------------------------------------
-	// Entry logic (UPDATED):
-	// - entry still ONLY after 09:35 (selNY)
-	// - BUT it can trigger if the cross happened earlier (>=09:31)
-	// - and (NEW) only if price is >= VWAP after 09:35
-	// ------------------------------------------------------------
-	if allowActions && !ts.HasPosition && !ts.Exited {
-		if trNY.Before(selNY) {
+	switch in.Action {
+	case IntentEnter:
+		if late {
 			return
 		}
-		if !trNY.Before(cutoffNY) {
-			return
+		e.openPosition(trNY, sym, price, in.Side, in.Stop)
+	case IntentExit:
+		// (a bracket leg that filled first already closed it at the broker)
+		if e.closePosition(trNY, sym, in.Reason, exitText(in.Reason, sym), price) {
+			if late {
+				e.emit(time.Now().In(e.loc), "SYSTEM", sym, fmt.Sprintf("%s %s exit is late: the print at %.4f (%s) came in with the backfill after a stream outage.",
+					sym, in.Reason, price, trNY.Format("15:04:05")), "", "warn")
+			}
+			e.submitExitOrder(trNY, sym, in.Reason)
 		}
+	}
+}
 
-		// entry minutes window: keep using your config (default 5..12)
-		if minAfterOpen < float64(f.EntryMinAfterOpen) || minAfterOpen > float64(f.EntryMaxAfterOpen)+0.999 {
-			return
-		}
+func (e *Engine) openPosition(tsNY time.Time, sym string, entry float64, side string, stop float64) {
+	p := e.simParams()
+	openNY, _, _, _ := e.st.Times()
 
-		// must meet open metrics + today pct + entry price filters
-		if ts.Open5mRangePct < f.Open5mRangePctMin || ts.Open5mRangePct > f.Open5mRangePctMax {
-			return
-		}
-		if ts.Open5mVol < f.Open5mVolMin || ts.Open5mVol > f.Open5mVolMax {
-			return
-		}
-		if ts.Open5mTodayPct < f.Open5mTodayPctMin || ts.Open5mTodayPct > f.Open5mTodayPctMax {
+	var (
+		shares   int
+		tp, sl   float64
+		ok, once bool
+	)
+	e.st.UpsertTicker(sym, func(t *store.TickerState) {
+		if ok = enterPosition(t, p, openNY, tsNY, entry, side, stop); ok {
+			shares, tp, sl = t.Shares, t.TakeProfitPrice, t.StopPrice
 			return
 		}
-		if price < f.EntryPriceMin || price > f.EntryPriceMax {
-			return
+		once = t.Status != statusNoSize
+		t.Status = statusNoSize
+	})
+	if !ok {
+		// (NEW) max_notional below one share: the signal is skipped (reported once per ticker)
+		if once {
+			e.emit(tsNY, "SYSTEM", sym, fmt.Sprintf("%s signal at %.4f skipped: sizing.max_notional %.2f is below one share.", sym, entry, p.Sizing.MaxNotional), "", "warn")
 		}
+		return
+	}
 
-		// NEW: must be at/above VWAP after 09:35
-		aboveVWAP := ts.VWAP > 0 && price >= ts.VWAP
-		if !aboveVWAP {
-			return
-		}
+	e.submitEntryOrder(tsNY, sym, side, shares, tp, sl)
 
-		// Entry triggers if we have seen a cross anytime since 09:31 (to cutoff),
-		// even if the cross happened before 09:35.
-		if sawCross {
-			e.openPosition(trNY, sym, price)
-			return
-		}
+	typ, say := "BUY", "Buy. "
+	if side == SideShort {
+		typ, say = "SHORT", "Short. "
 	}
+	msg := fmt.Sprintf("%s %s (%s) · %d shares", typ, sym, nato.SpellNATO(sym), shares)
+	audioID := e.say(tsNY, typ, sym, say+nato.SpellNATO(sym))
+	e.emit(tsNY, typ, sym, msg, audioID, "signal")
+}
 
-	// manage position
-	if allowActions && ts.HasPosition && !ts.Exited {
-		if price >= ts.TakeProfitPrice && ts.TakeProfitPrice > 0 {
-			e.closePosition(trNY, sym, "PROFIT", fmt.Sprintf("PROFIT! %s", sym), price)
-			return
-		}
-		if price <= ts.StopPrice && ts.StopPrice > 0 {
-			e.closePosition(trNY, sym, "STOP", fmt.Sprintf("STOP LOSS HIT! %s", sym), price)
-			return
-		}
+// SessionFor returns dayNY's key times from market config. On early-close days the force exit
+// (and the entry cutoff with it) are clamped to the 13:00 close.
+func (e *Engine) SessionFor(dayNY time.Time) Session {
+	d := dayNY.In(e.loc)
+	s := Session{
+		OpenNY:   atTime(d, e.cfg.Market.OpenTime, e.loc),
+		SelNY:    atTime(d, e.cfg.Market.SelectionTime, e.loc),
+		CutoffNY: atTime(d, e.cfg.Market.VWAPCrossCutoff, e.loc),
+		ExitNY:   atTime(d, e.cfg.Market.ForceExitTime, e.loc),
 	}
+	if closeNY := atTime(d, calendar.CloseHMS(d), e.loc); s.ExitNY.After(closeNY) {
+		s.ExitNY = closeNY
+	}
+	if s.CutoffNY.After(s.ExitNY) {
+		s.CutoffNY = s.ExitNY
+	}
+	return s
 }
 
-func (e *Engine) openPosition(tsNY time.Time, sym string, entry float64) {
-	tp := entry * (1.0 + e.cfg.Risk.TakeProfitPct)
-	sl := entry * (1.0 - e.cfg.Risk.StopLossPct)
-
-	openNY, _, _, _ := e.st.Times()
-	minAfterOpen := tsNY.Sub(openNY).Seconds() / 60.0
-
-	e.st.UpsertTicker(sym, func(t *store.TickerState) {
-		t.HasPosition = true
-		t.EntryPrice = entry
-		t.EntryTime = tsNY
-		t.EntryMinutesAfterOpen = minAfterOpen
-		t.TakeProfitPrice = tp
-		t.StopPrice = sl
-		t.Status = "LONG"
-
-		// initialize excursion trackers at entry
-		t.MaxPriceSinceEntry = entry
-		t.MaxPriceSinceEntryTime = tsNY
-		t.MinPriceSinceEntry = entry
-		t.MinPriceSinceEntryTime = tsNY
-	})
-
-	msg := fmt.Sprintf("BUY %s (%s)", sym, nato.SpellNATO(sym))
-	audioID := e.say(tsNY, "BUY", sym, "Buy. "+nato.SpellNATO(sym))
-	e.emit(tsNY, "BUY", sym, msg, audioID, "signal")
+// session is the current session's key times (set by SetTimes).
+func (e *Engine) session() Session {
+	openNY, selNY, cutoffNY, exitNY := e.st.Times()
+	return Session{OpenNY: openNY, SelNY: selNY, CutoffNY: cutoffNY, ExitNY: exitNY}
 }
 
-func (e *Engine) closePosition(tsNY time.Time, sym, reason, ttsText string, exitPrice float64) {
+// closePosition exits sym's open position and announces it. The tape and a broker leg fill can
+// both decide to close: the check is redone under the store lock, and false means someone else
+// already closed it (nothing is emitted).
+func (e *Engine) closePosition(tsNY time.Time, sym, reason, ttsText string, exitPrice float64) bool {
 	openNY, _, _, _ := e.st.Times()
-	minAfterOpen := tsNY.Sub(openNY).Seconds() / 60.0
 
+	closed := false
 	e.st.UpsertTicker(sym, func(t *store.TickerState) {
-		t.Exited = true
-		t.ExitReason = reason
-		t.ExitTime = tsNY
-		t.ExitPrice = exitPrice
-		t.ExitMinutesAfterOpen = minAfterOpen
-		t.Status = reason
+		if !t.HasPosition || t.Exited {
+			return
+		}
+		exitPosition(t, openNY, tsNY, reason, exitPrice)
+		closed = true
 	})
+	if !closed {
+		return false
+	}
 
 	audioID := e.say(tsNY, reason, sym, ttsText)
 	e.emit(tsNY, reason, sym, ttsText, audioID, "signal")
+	return true
 }
 
 func (e *Engine) onElevenAM(tsNY time.Time) {
 	audioID := e.say(tsNY, "11AM", "", "Eleven a.m. close")
 	e.emit(tsNY, "11AM", "", "11am close", audioID, "info")
 
-	// close any open positions at time exit (use last known price)
+	e.closeOnTimer(tsNY, "11am close")
+	e.flattenBroker(tsNY)
+}
+
+// closeOnTimer runs OnTimer at tsNY (as the session's exit time) for every open position and
+// closes the ones the strategy exits, at the last known price; label prefixes the time-exit event.
+func (e *Engine) closeOnTimer(tsNY time.Time, label string) {
+	p := e.simParams()
+	sess := e.session()
+	sess.ExitNY = tsNY
+
 	wl := e.st.Watchlist()
 	for _, sym := range wl {
 		t := e.st.GetTicker(sym)
-		if t == nil {
+		if t == nil || !t.HasPosition || t.Exited {
 			continue
 		}
-		if t.HasPosition && !t.Exited {
-			px := t.LastPrice
+
+		var (
+			in Intent
+			px float64
+		)
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			in = p.strategy().OnTimer(t, p, sess, tsNY)
+			px = t.LastPrice
 			if px <= 0 {
 				px = t.EntryPrice
 			}
-			e.closePosition(tsNY, sym, "TIME_EXIT", fmt.Sprintf("11am close. %s", sym), px)
+		})
+		if in.Action != IntentExit {
+			continue
+		}
+		msg := fmt.Sprintf("%s. %s", label, sym)
+		if in.Reason != ExitTime {
+			msg = exitText(in.Reason, sym)
 		}
+		e.closePosition(tsNY, sym, in.Reason, msg, px)
 	}
 }
 
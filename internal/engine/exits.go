@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"massive-orb/internal/config"
+	"massive-orb/internal/store"
+)
+
+// Exit reasons (TickerState.ExitReason / HistoricTrade.ExitReason).
+const (
+	ExitProfit    = "PROFIT"
+	ExitStop      = "STOP"      // initial stop
+	ExitTrail     = "TRAIL"     // trailing stop
+	ExitBreakEven = "BREAKEVEN" // stop moved to break-even
+	ExitTimeStop  = "TIME_STOP" // not green N minutes after entry
+	ExitTime      = "TIME_EXIT" // force exit (11:00)
+)
+
+const (
+	TrailNone    = ""
+	TrailPercent = "percent" // stop trails the best price since entry by a fixed percent
+	TrailATR     = "atr"     // stop trails the best price since entry by a multiple of 1-minute ATR
+)
+
+// Exits are the stop-management rules applied on top of the fixed take-profit / stop.
+// The stop only ever ratchets toward the price (up for longs, down for shorts);
+// whichever rule set it last names the exit.
+type Exits struct {
+	TrailMode    string  `json:"trail_mode,omitempty"`
+	TrailPct     float64 `json:"trail_pct,omitempty"`
+	TrailATRMult float64 `json:"trail_atr_mult,omitempty"`
+	ATRPeriod    int     `json:"atr_period,omitempty"` // 1-minute bars
+
+	BreakEvenTriggerPct float64 `json:"break_even_trigger_pct,omitempty"` // 0 = off
+	BreakEvenOffsetPct  float64 `json:"break_even_offset_pct,omitempty"`  // stop = entry moved offset in the trade's favor
+
+	TimeStopMinutes float64 `json:"time_stop_minutes,omitempty"` // 0 = off
+}
+
+func exitsFromConfig(cfg config.Config) Exits {
+	x := cfg.Exits
+	return Exits{
+		TrailMode:           x.TrailMode,
+		TrailPct:            x.TrailPct,
+		TrailATRMult:        x.TrailATRMult,
+		ATRPeriod:           x.ATRPeriod,
+		BreakEvenTriggerPct: x.BreakEvenTriggerPct,
+		BreakEvenOffsetPct:  x.BreakEvenOffsetPct,
+		TimeStopMinutes:     x.TimeStopMinutes,
+	}
+}
+
+// adjustStop ratchets t.StopPrice from the best price since entry (break-even, then trailing):
+// the high for longs, the low for shorts.
+func (x Exits) adjustStop(t *store.TickerState) {
+	dir := sideDir(t.Side)
+	best := t.MaxPriceSinceEntry
+	if t.Side == SideShort {
+		best = t.MinPriceSinceEntry
+	}
+	if best <= 0 || t.EntryPrice <= 0 {
+		return
+	}
+	if x.BreakEvenTriggerPct > 0 && dir*(best-t.EntryPrice)/t.EntryPrice >= x.BreakEvenTriggerPct {
+		tightenStop(t, t.EntryPrice*(1.0+dir*x.BreakEvenOffsetPct), ExitBreakEven)
+	}
+	switch x.TrailMode {
+	case TrailPercent:
+		if x.TrailPct > 0 {
+			tightenStop(t, best*(1.0-dir*x.TrailPct), ExitTrail)
+		}
+	case TrailATR:
+		if t.ATR > 0 && x.TrailATRMult > 0 {
+			tightenStop(t, best-dir*x.TrailATRMult*t.ATR, ExitTrail)
+		}
+	}
+}
+
+// tightenStop moves the stop to px if that is closer to the price (never loosens it).
+func tightenStop(t *store.TickerState, px float64, kind string) {
+	if sideDir(t.Side)*(px-t.StopPrice) > 0 {
+		t.StopPrice = px
+		t.StopKind = kind
+	}
+}
+
+// timeStop reports whether the position is still not green at the first print
+// TimeStopMinutes after entry. Checked once.
+func (x Exits) timeStop(t *store.TickerState, trNY time.Time, price float64) bool {
+	if x.TimeStopMinutes <= 0 || t.TimeStopChecked {
+		return false
+	}
+	if trNY.Sub(t.EntryTime).Minutes() < x.TimeStopMinutes {
+		return false
+	}
+	t.TimeStopChecked = true
+	return sideDir(t.Side)*(price-t.EntryPrice) <= 0
+}
+
+// updateATR folds one print into the ticker's 1-minute bars and Wilder ATR over period bars
+// (a plain average while fewer than period bars have closed).
+func updateATR(t *store.TickerState, trNY time.Time, price float64, period int) {
+	if period <= 0 {
+		period = 14
+	}
+	minute := trNY.Truncate(time.Minute)
+	if t.BarStart.IsZero() {
+		t.BarStart, t.BarHigh, t.BarLow, t.BarClose = minute, price, price, price
+		return
+	}
+	if minute.Equal(t.BarStart) {
+		if price > t.BarHigh {
+			t.BarHigh = price
+		}
+		if price < t.BarLow {
+			t.BarLow = price
+		}
+		t.BarClose = price
+		return
+	}
+
+	// previous bar closed
+	tr := t.BarHigh - t.BarLow
+	if t.PrevBarClose > 0 {
+		tr = max(tr, t.BarHigh-t.PrevBarClose, t.PrevBarClose-t.BarLow)
+	}
+	n := min(t.ATRBars, period-1)
+	t.ATR = (t.ATR*float64(n) + tr) / float64(n+1)
+	t.ATRBars++
+
+	t.PrevBarClose = t.BarClose
+	t.BarStart, t.BarHigh, t.BarLow, t.BarClose = minute, price, price, price
+}
+
+// exitText is the event / TTS line for an exit reason.
+func exitText(reason, sym string) string {
+	switch reason {
+	case ExitProfit:
+		return fmt.Sprintf("PROFIT! %s", sym)
+	case ExitTrail:
+		return fmt.Sprintf("Trailing stop hit. %s", sym)
+	case ExitBreakEven:
+		return fmt.Sprintf("Break-even stop hit. %s", sym)
+	case ExitTimeStop:
+		return fmt.Sprintf("Time stop, not green. %s", sym)
+	}
+	return fmt.Sprintf("STOP LOSS HIT! %s", sym)
+}
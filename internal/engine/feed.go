@@ -0,0 +1,417 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"massive-orb/internal/marketdata"
+)
+
+// Redial backoff for the live websocket streams (doubles per failed attempt).
+const (
+	wsBackoffMin = 1 * time.Second
+	wsBackoffMax = 30 * time.Second
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// redial runs dial until it succeeds, ctx ends or the next retry would land past until
+// (the last dial error is returned). Failed attempts show up as SYSTEM warnings.
+func (e *Engine) redial(ctx context.Context, name string, until time.Time, dial func() error) error {
+	wait := wsBackoffMin
+	for attempt := 1; ; attempt++ {
+		err := dial()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !time.Now().Add(wait).Before(until) {
+			return err
+		}
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS %s: connect attempt %d failed: %v (retry in %s)", name, attempt, err, wait), "", "warn")
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		wait = min(wait*2, wsBackoffMax)
+	}
+}
+
+// isStreamNotice reports provider-side reconnect notices (the stream itself is still usable).
+func isStreamNotice(err error) bool {
+	return errors.Is(err, marketdata.ErrReconnecting) || errors.Is(err, marketdata.ErrReconnected)
+}
+
+// ---- Minute aggregates (09:30-09:34, and the sold-off watch after 09:35) ----
+
+// aggFeed streams minute aggregates for the whole watchlist into apply until `until` and
+// resubscribes when the stream drops. Bars missed while it was down are not replayed: for the
+// open-5m sums stop reports the gap and the caller refetches the open-5m bars from REST.
+type aggFeed struct {
+	e      *Engine
+	until  time.Time // no redial past this
+	apply  func(agg marketdata.Agg)
+	gapFix string // what happens to missed bars, for the reconnect events
+
+	ws     marketdata.AggStream // owned by the run goroutine
+	down   time.Time            // zero while connected
+	gapped bool                 // read after done
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startAggFeed collects the open-5m bars (09:30–09:34) until selNY.
+func (e *Engine) startAggFeed(ctx context.Context, openNY, selNY time.Time) (*aggFeed, error) {
+	return e.streamAggs(ctx, selNY, fmt.Sprintf("missed bars are refetched from REST at %s", selNY.Format("15:04")), func(agg marketdata.Agg) {
+		e.onMinuteAgg(openNY, selNY, agg)
+	})
+}
+
+func (e *Engine) streamAggs(ctx context.Context, until time.Time, gapFix string, apply func(agg marketdata.Agg)) (*aggFeed, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &aggFeed{e: e, until: until, apply: apply, gapFix: gapFix, cancel: cancel, done: make(chan struct{})}
+	if err := e.redial(ctx, "minute aggs", until, f.dial(ctx)); err != nil {
+		cancel()
+		return nil, fmt.Errorf("subscribe minute aggs: %w", err)
+	}
+	go f.run(ctx)
+	return f, nil
+}
+
+// streamAggsAsync is streamAggs for callers that must not wait on the subscribe: the first dial
+// (with its backoff) runs in the background too. If it never connects before until, the feed ends
+// with a warning (done closes, stop still works).
+func (e *Engine) streamAggsAsync(ctx context.Context, until time.Time, gapFix string, apply func(agg marketdata.Agg)) *aggFeed {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &aggFeed{e: e, until: until, apply: apply, gapFix: gapFix, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		if err := e.redial(ctx, "minute aggs", until, f.dial(ctx)); err != nil {
+			if ctx.Err() == nil {
+				e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS minute aggs: gave up before %s: %v", until.Format("15:04"), err), "", "warn")
+			}
+			close(f.done)
+			return
+		}
+		f.run(ctx)
+	}()
+	return f
+}
+
+func (f *aggFeed) dial(ctx context.Context) func() error {
+	return func() error {
+		ws, err := f.e.md.StreamMinuteAggs(ctx, f.e.st.Watchlist())
+		if err != nil {
+			return err
+		}
+		f.ws = ws
+		return nil
+	}
+}
+
+func (f *aggFeed) run(ctx context.Context) {
+	defer close(f.done)
+	defer func() {
+		if f.ws != nil {
+			f.ws.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-f.ws.Err():
+			if err == nil {
+				continue
+			}
+			f.gapped = true
+			if isStreamNotice(err) {
+				f.notice(err)
+				continue
+			}
+			if !f.reconnect(ctx, err) {
+				return
+			}
+		case agg, ok := <-f.ws.Aggs():
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				f.gapped = true
+				if !f.reconnect(ctx, errStreamClosed) {
+					return
+				}
+				continue
+			}
+			f.apply(agg)
+		}
+	}
+}
+
+// notice reports the provider's own reconnects (ErrReconnecting / ErrReconnected).
+func (f *aggFeed) notice(err error) {
+	nowNY := time.Now().In(f.e.loc)
+	if errors.Is(err, marketdata.ErrReconnected) {
+		f.e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS minute aggs: reconnected%s; %s.", downFor(f.down, nowNY), f.gapFix), "", "info")
+		f.down = time.Time{}
+		return
+	}
+	if f.down.IsZero() {
+		f.down = nowNY
+		f.e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS minute aggs: disconnected (%v), reconnecting…", err), "", "warn")
+	}
+}
+
+// reconnect replaces a dead stream until f.until; false means collection is over.
+func (f *aggFeed) reconnect(ctx context.Context, cause error) bool {
+	e := f.e
+	nowNY := time.Now().In(e.loc)
+	if f.down.IsZero() {
+		f.down = nowNY
+	}
+	e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS minute aggs: disconnected (%v), resubscribing %d tickers…", cause, len(e.st.Watchlist())), "", "warn")
+
+	f.ws.Close()
+	f.ws = nil
+	if err := e.redial(ctx, "minute aggs", f.until, f.dial(ctx)); err != nil {
+		if ctx.Err() == nil {
+			e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS minute aggs: gave up before %s: %v", f.until.Format("15:04"), err), "", "warn")
+		}
+		return false
+	}
+	nowNY = time.Now().In(e.loc)
+	e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS minute aggs: resubscribed%s; %s.", downFor(f.down, nowNY), f.gapFix), "", "info")
+	f.down = time.Time{}
+	return true
+}
+
+// stop closes the stream and reports whether any bars may be missing. Safe to call twice.
+func (f *aggFeed) stop() (gapped bool) {
+	f.cancel()
+	<-f.done
+	return f.gapped
+}
+
+// ---- Trades (09:35 → force exit) ----
+
+// tradeFeed streams trades for the tracked tickers and keeps the tape unbroken: when the stream
+// drops it resubscribes with backoff, then replays the missed prints from REST through apply, so
+// VWAP, crosses and open positions see every print. Replayed prints come with live false: a stop,
+// target, trail or time stop crossed during the outage still exits (late, at that print), but
+// entries are dropped since the signal is stale by the time it arrives.
+type tradeFeed struct {
+	e     *Engine
+	syms  []string
+	until time.Time // no redial past this (force exit)
+	apply func(tr marketdata.Trade, live bool)
+
+	// owned by the run goroutine
+	ws       marketdata.TradeStream
+	since    time.Time            // subscribed; backfill start for tickers without a print yet
+	last     map[string]tradeMark // newest applied print per ticker
+	replayed map[string]tradeMark // stream prints at or before this were already replayed from REST
+	down     time.Time            // zero while connected
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// tradeMark is a ticker's position on the tape. Prints in the same millisecond are told apart by
+// sequence number; without one (0) the millisecond is all there is.
+type tradeMark struct {
+	ms, seq int64
+}
+
+func markOf(tr marketdata.Trade) tradeMark { return tradeMark{ms: tr.Timestamp, seq: tr.Sequence} }
+
+// covers reports whether tr is at or before m (already applied).
+func (m tradeMark) covers(tr marketdata.Trade) bool {
+	if m == (tradeMark{}) {
+		return false
+	}
+	if m.seq > 0 && tr.Sequence > 0 {
+		return tr.Sequence <= m.seq
+	}
+	return tr.Timestamp <= m.ms
+}
+
+func (e *Engine) startTradeFeed(ctx context.Context, syms []string, until time.Time, apply func(tr marketdata.Trade, live bool)) (*tradeFeed, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &tradeFeed{
+		e:        e,
+		syms:     syms,
+		until:    until,
+		apply:    apply,
+		since:    time.Now().In(e.loc),
+		last:     make(map[string]tradeMark, len(syms)),
+		replayed: make(map[string]tradeMark),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	if err := e.redial(ctx, "trades", until, f.dial(ctx)); err != nil {
+		cancel()
+		return nil, fmt.Errorf("subscribe trades: %w", err)
+	}
+	go f.run(ctx)
+	return f, nil
+}
+
+func (f *tradeFeed) dial(ctx context.Context) func() error {
+	return func() error {
+		ws, err := f.e.md.StreamTrades(ctx, f.syms)
+		if err != nil {
+			return err
+		}
+		f.ws = ws
+		return nil
+	}
+}
+
+func (f *tradeFeed) run(ctx context.Context) {
+	defer close(f.done)
+	defer func() {
+		if f.ws != nil {
+			f.ws.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-f.ws.Err():
+			if err == nil {
+				continue
+			}
+			if isStreamNotice(err) {
+				f.notice(ctx, err)
+				continue
+			}
+			if !f.reconnect(ctx, err) {
+				return
+			}
+		case tr, ok := <-f.ws.Trades():
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				if !f.reconnect(ctx, errStreamClosed) {
+					return
+				}
+				continue
+			}
+			f.take(tr)
+		}
+	}
+}
+
+// take applies one streamed print unless the last backfill already replayed it.
+func (f *tradeFeed) take(tr marketdata.Trade) {
+	if f.replayed[tr.Symbol].covers(tr) {
+		return
+	}
+	if !f.last[tr.Symbol].covers(tr) {
+		f.last[tr.Symbol] = markOf(tr)
+	}
+	f.apply(tr, true)
+}
+
+// notice handles the provider's own reconnects: the same stream resumes, the gap still needs a backfill.
+func (f *tradeFeed) notice(ctx context.Context, err error) {
+	nowNY := time.Now().In(f.e.loc)
+	if !errors.Is(err, marketdata.ErrReconnected) {
+		if f.down.IsZero() {
+			f.down = nowNY
+			f.e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS trades: disconnected (%v), reconnecting…", err), "", "warn")
+		}
+		return
+	}
+	f.e.emit(nowNY, "SYSTEM", "", "WS trades: reconnected"+downFor(f.down, nowNY)+".", "", "info")
+	f.down = time.Time{}
+	f.backfill(ctx)
+}
+
+// reconnect replaces a dead stream (same tickers, same batches) and backfills the gap;
+// false means the feed is over (ctx done or force exit reached).
+func (f *tradeFeed) reconnect(ctx context.Context, cause error) bool {
+	e := f.e
+	nowNY := time.Now().In(e.loc)
+	if f.down.IsZero() {
+		f.down = nowNY
+	}
+	e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("WS trades: disconnected (%v), resubscribing %d tickers…", cause, len(f.syms)), "", "warn")
+
+	f.ws.Close()
+	f.ws = nil
+	if err := e.redial(ctx, "trades", f.until, f.dial(ctx)); err != nil {
+		if ctx.Err() == nil {
+			e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS trades: gave up before %s: %v", f.until.Format("15:04"), err), "", "warn")
+		}
+		return false
+	}
+	nowNY = time.Now().In(e.loc)
+	e.emit(nowNY, "SYSTEM", "", "WS trades: resubscribed"+downFor(f.down, nowNY)+".", "", "info")
+	f.down = time.Time{}
+	f.backfill(ctx)
+	return true
+}
+
+// backfill replays each ticker's prints after its last applied one up to now from REST.
+// The new stream is already buffering, so whatever REST returns is marked as replayed and
+// the stream copy of those prints is skipped (take).
+func (f *tradeFeed) backfill(ctx context.Context) {
+	e := f.e
+	toNY := time.Now().In(e.loc)
+	e.emit(toNY, "SYSTEM", "", fmt.Sprintf("Backfilling missed trades via REST for %d tickers (up to %s)…", len(f.syms), toNY.Format("15:04:05")), "", "info")
+
+	n := 0
+	for _, sym := range f.syms {
+		from := f.since
+		if m := f.last[sym]; m.ms > 0 {
+			from = time.UnixMilli(m.ms).In(e.loc) // same-ms prints come back too; covers drops the applied ones
+		}
+
+		it := e.md.Trades(ctx, sym, from, toNY)
+		for it.Next() {
+			if ctx.Err() != nil {
+				return
+			}
+			tr := it.Item()
+			if tr.Timestamp == 0 || f.last[sym].covers(tr) {
+				continue
+			}
+			tr.Symbol = sym
+			f.last[sym] = markOf(tr)
+			f.apply(tr, false)
+			n++
+		}
+		if err := it.Err(); err != nil {
+			e.emit(time.Now().In(e.loc), "SYSTEM", sym, fmt.Sprintf("Trade backfill failed: %v", err), "", "warn")
+		}
+		f.replayed[sym] = f.last[sym]
+	}
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Backfill done: %d missed trades replayed.", n), "", "info")
+}
+
+// stop closes the stream. Safe to call twice.
+func (f *tradeFeed) stop() {
+	f.cancel()
+	<-f.done
+}
+
+// downFor is " after 12s" for a known outage start, "" otherwise.
+func downFor(down, nowNY time.Time) string {
+	if down.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(" after %s", nowNY.Sub(down).Round(time.Second))
+}
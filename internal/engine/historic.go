@@ -4,20 +4,18 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/massive-com/client-go/v2/rest/models"
-	massivews "github.com/massive-com/client-go/v2/websocket"
+	"gopkg.in/yaml.v3"
 
-	"massive-orb/internal/massive"
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/reportdb"
 	"massive-orb/internal/store"
 )
 
-const historicShares = 1000
-
-const soldOffScanHMS = "10:30:00" // fixed per your request (“by 10:30am”)
-
 type open5mMetric struct {
 	Open0930  float64
 	ORHigh    float64
@@ -62,206 +60,17 @@ func minTime(a, b time.Time) time.Time {
 	return b
 }
 
-func (e *Engine) scanSoldOff(ctx context.Context, rest *mrestClientShim, openNY, scanEndNY time.Time, openMetrics map[string]open5mMetric) ([]store.HistoricSoldOff, error) {
-	f := e.st.Filters()
-
-	if scanEndNY.Before(openNY.Add(1 * time.Minute)) {
-		return nil, nil
-	}
-
-	// Stage 0: eligible by open-5m range (cheap)
-	syms := make([]string, 0, len(openMetrics))
-	for sym, m := range openMetrics {
-		if m.Open0930 <= 0 || m.Open5mVol <= 0 {
-			continue
-		}
-		if m.RangePct < f.SoldOffOpen5mRangePctMin {
-			continue
-		}
-		syms = append(syms, sym)
-	}
-	sort.Strings(syms)
-	if len(syms) == 0 {
-		return nil, nil
-	}
-
-	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Sold-off scan: checking %d tickers (09:30 → %s)…", len(syms), scanEndNY.In(e.loc).Format("15:04:05")), "", "info")
-
-	// Stage 1: fetch min low + last close in [open, scanEnd)
-	type s1 struct {
-		sym     string
-		low     float64
-		lowTime time.Time
-		last    float64
-		ok      bool
-		err     error
-	}
-
-	jobs := make(chan string)
-	results := make(chan s1)
-
-	workerN := e.cfg.History.MaxWorkers
-	if workerN < 1 {
-		workerN = 1
-	}
-	if workerN > len(syms) {
-		workerN = len(syms)
-	}
-
-	var wg sync.WaitGroup
-	for i := 0; i < workerN; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for sym := range jobs {
-				low, lowTime, last, ok, err := rest.MinLowAndLastClose(ctx, sym, openNY, scanEndNY)
-				results <- s1{sym: sym, low: low, lowTime: lowTime, last: last, ok: ok, err: err}
-			}
-		}()
-	}
-
-	go func() {
-		defer close(jobs)
-		for _, sym := range syms {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs <- sym:
-			}
-		}
-	}()
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	type pre struct {
-		sym     string
-		m       open5mMetric
-		low     float64
-		lowTime time.Time
-		last    float64
-		drop    float64
-	}
-
-	preBySym := make(map[string]pre, 64)
-	for r := range results {
-		if r.err != nil || !r.ok {
-			continue
-		}
-		m := openMetrics[r.sym]
-		if m.Open0930 <= 0 || r.low <= 0 {
-			continue
-		}
-		drop := (m.Open0930 - r.low) / m.Open0930
-		if drop < f.SoldOffFromOpenPctMin {
-			continue
-		}
-		preBySym[r.sym] = pre{sym: r.sym, m: m, low: r.low, lowTime: r.lowTime, last: r.last, drop: drop}
-	}
-
-	if len(preBySym) == 0 {
-		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Sold-off scan: 0 tickers matched the drop + Open5m Range% filters.", "", "info")
-		return nil, nil
-	}
-
-	// Stage 2: compute Open5mToday% for the pre-filtered set only (expensive)
-	preSyms := make([]string, 0, len(preBySym))
-	for sym := range preBySym {
-		preSyms = append(preSyms, sym)
-	}
-	sort.Strings(preSyms)
-
-	type s2 struct {
-		sym string
-		pct float64
-		err error
-	}
-	jobs2 := make(chan string)
-	results2 := make(chan s2)
-
-	workerN2 := e.cfg.History.MaxWorkers
-	if workerN2 < 1 {
-		workerN2 = 1
-	}
-	if workerN2 > len(preSyms) {
-		workerN2 = len(preSyms)
-	}
-
-	var wg2 sync.WaitGroup
-	for i := 0; i < workerN2; i++ {
-		wg2.Add(1)
-		go func() {
-			defer wg2.Done()
-			for sym := range jobs2 {
-				p := preBySym[sym]
-				avg, err := e.avgPrevSessionsOpen5mVol(ctx, rest, sym, openNY, e.cfg.History.Open5mLookbackSessions, e.cfg.History.MaxCalendarLookback)
-				if err != nil || avg <= 0 {
-					results2 <- s2{sym: sym, err: err}
-					continue
-				}
-				pct := (p.m.Open5mVol / avg) * 100.0
-				results2 <- s2{sym: sym, pct: pct, err: nil}
-			}
-		}()
-	}
-	go func() {
-		defer close(jobs2)
-		for _, sym := range preSyms {
-			select {
-			case <-ctx.Done():
-				return
-			case jobs2 <- sym:
-			}
-		}
-	}()
-	go func() {
-		wg2.Wait()
-		close(results2)
-	}()
-
-	out := make([]store.HistoricSoldOff, 0, len(preSyms))
-	for r := range results2 {
-		if r.err != nil {
-			continue
-		}
-		if r.pct < f.SoldOffOpen5mTodayPctMin {
-			continue
-		}
-		p := preBySym[r.sym]
-		out = append(out, store.HistoricSoldOff{
-			Symbol:          p.sym,
-			Open0930:        p.m.Open0930,
-			LowPrice:        p.low,
-			LowTimeNY:       p.lowTime.In(e.loc).Format("15:04:05"),
-			PriceAtScanTime: p.last,
-			DropPct:         p.drop,
-			Open5mVol:       p.m.Open5mVol,
-			Open5mRangePct:  p.m.RangePct,
-			Open5mTodayPct:  r.pct,
-		})
-	}
-
-	sort.Slice(out, func(i, j int) bool {
-		if out[i].DropPct == out[j].DropPct {
-			return out[i].Symbol < out[j].Symbol
-		}
-		return out[i].DropPct > out[j].DropPct
-	})
-
-	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Sold-off scan: %d tickers matched.", len(out)), "", "info")
-	return out, nil
-}
-
 // correctCandidatesOpen5mViaREST re-fetches the official 09:30–09:35 bars for the selected tickers only,
 // then re-applies the current open5m filters. This is used in "start after 09:30" scenarios to avoid
 // REST-calling the full watchlist but still make final candidates accurate.
-func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, rest *mrestClientShim, openNY, selNY time.Time, candidates []string) ([]string, error) {
+func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, openNY, selNY time.Time, candidates []string) ([]string, error) {
 	if len(candidates) == 0 {
 		return candidates, nil
 	}
 
-	f := e.st.Filters()
+	p := e.simParams()
+	f := p.Filters
+	sess := e.session()
 
 	type res struct {
 		sym string
@@ -290,7 +99,7 @@ func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, rest *mrest
 		go func() {
 			defer wg.Done()
 			for sym := range jobs {
-				o, hi, lo, vol, ok, err := rest.Open5mMetrics(ctx, sym, openNY, selNY)
+				o, hi, lo, vol, ok, err := e.open5mMetrics(ctx, sym, openNY, selNY)
 				results <- res{sym: sym, o: o, hi: hi, lo: lo, vol: vol, ok: ok, err: err}
 			}
 		}()
@@ -319,6 +128,7 @@ func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, rest *mrest
 		}
 		rng := (r.hi - r.lo) / r.o
 
+		pass := false
 		e.st.UpsertTicker(r.sym, func(t *store.TickerState) {
 			t.Open0930 = r.o
 			t.Open0930Estimated = false
@@ -326,10 +136,15 @@ func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, rest *mrest
 			t.ORLow = r.lo
 			t.Open5mVol = r.vol
 			t.Open5mRangePct = rng
-		})
 
-		if rng >= f.Open5mRangePctMin && rng <= f.Open5mRangePctMax &&
-			r.vol >= f.Open5mVolMin && r.vol <= f.Open5mVolMax {
+			pass = rng >= f.Open5mRangePctMin && rng <= f.Open5mRangePctMax &&
+				r.vol >= f.Open5mVolMin && r.vol <= f.Open5mVolMax && premarketOK(t, f)
+			if pass {
+				// the 09:35 OnOpenRange saw the estimated range; run it again on the official one
+				p.strategy().OnOpenRange(t, p, sess)
+			}
+		})
+		if pass {
 			out = append(out, r.sym)
 		}
 	}
@@ -341,7 +156,7 @@ func (e *Engine) correctCandidatesOpen5mViaREST(ctx context.Context, rest *mrest
 // If the requested session is "today" and current time is before the configured 11:00 force exit,
 // we replay/catch-up what we can with REST (without emitting past BUY/SELL actions),
 // then switch to live websockets and run until 11:00.
-func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim, sessionDayNY time.Time, openNY, selNY, cutoffNY, exitNY time.Time) error {
+func (e *Engine) runHistoricLiveToday(ctx context.Context, sessionDayNY time.Time, openNY, selNY, cutoffNY, exitNY time.Time) error {
 	// Wait for open if needed
 	nowNY := time.Now().In(e.loc)
 	if nowNY.Before(openNY) {
@@ -362,53 +177,11 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 		e.st.SetPhase(store.PhaseCollecting5m)
 		e.emit(nowNY, "SYSTEM", "", "HISTORIC-LIVE: collecting minute bars via WebSocket until 09:35…", "", "info")
 
-		wsAgg, err := massive.NewWS(e.massiveKey, e.cfg.Massive.Feed)
+		aggs, err := e.startAggFeed(ctx, openNY, selNY)
 		if err != nil {
 			return err
 		}
-		defer wsAgg.Close()
-
-		wl := e.st.Watchlist()
-		for i := 0; i < len(wl); i += e.cfg.Massive.WSBatchSize {
-			j := i + e.cfg.Massive.WSBatchSize
-			if j > len(wl) {
-				j = len(wl)
-			}
-			if err := wsAgg.Subscribe(massivews.StocksMinAggs, wl[i:j]...); err != nil {
-				return fmt.Errorf("subscribe minute aggs: %w", err)
-			}
-		}
-		if err := wsAgg.Connect(); err != nil {
-			return fmt.Errorf("ws connect: %w", err)
-		}
-
-		done0935 := make(chan struct{})
-		go func() {
-			defer close(done0935)
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case err, ok := <-wsAgg.Error():
-					if !ok {
-						return
-					}
-					if err != nil {
-						e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS error: %v", err), "", "warn")
-						return
-					}
-				case msg, ok := <-wsAgg.Output():
-					if !ok {
-						return
-					}
-					agg, ok := msg.(massive.EquityAgg)
-					if !ok {
-						continue
-					}
-					e.onMinuteAgg(openNY, selNY, agg)
-				}
-			}
-		}()
+		defer aggs.stop()
 
 		timer := time.NewTimer(time.Until(selNY))
 		defer timer.Stop()
@@ -417,17 +190,22 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 			return nil
 		case <-timer.C:
 		}
-		wsAgg.Close()
-		<-done0935
+		if aggs.stop() {
+			e.emit(time.Now().In(e.loc), "SYSTEM", "", "HISTORIC-LIVE: minute-agg stream had a gap — refetching 09:30–09:34 minute bars via REST…", "", "warn")
+			if err := e.collectOpen5mViaREST(ctx, openNY, selNY); err != nil {
+				return err
+			}
+		}
 	} else {
 		e.st.SetPhase(store.PhaseCollecting5m)
 		e.emit(nowNY, "SYSTEM", "", "HISTORIC-LIVE: started after 09:35 — fetching 09:30–09:34 minute bars via REST…", "", "info")
-		if err := e.collectOpen5mViaREST(ctx, rest, openNY, selNY); err != nil {
+		if err := e.collectOpen5mViaREST(ctx, openNY, selNY); err != nil {
 			return err
 		}
 	}
 
 	// Candidate selection
+	e.collectPremarket(ctx, openNY)
 	openMetricsAll := e.snapshotOpen5mMetricsForWatchlist()
 	e.st.SetPhase(store.PhaseSelecting0935)
 	candidates := e.selectCandidatesAt0935()
@@ -436,30 +214,22 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 		e.emit(endNY, "SYSTEM", "", "No tickers matched open_5m filters at 09:35.", "", "info")
 		e.st.SetPhase(store.PhaseClosed)
 
-		scanNY := atTime(sessionDayNY, soldOffScanHMS, e.loc)
-		scanEnd := minTime(scanNY, endNY)
-		soldOff, _ := e.scanSoldOff(ctx, rest, openNY, scanEnd, openMetricsAll)
-
 		rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
-		rep.SoldOff = soldOff
-		e.st.SetHistoricReport(&rep)
+		e.attachSoldOff(ctx, &rep, sessionDayNY, openNY, endNY, openMetricsAll)
+		e.publishHistoricReport(&rep)
 		return nil
 	}
 
 	// If we started after 09:30 and Open0930 may be estimated, correct candidates only (cheap).
-	corrected, _ := e.correctCandidatesOpen5mViaREST(ctx, rest, openNY, selNY, candidates)
+	corrected, _ := e.correctCandidatesOpen5mViaREST(ctx, openNY, selNY, candidates)
 	if len(corrected) == 0 {
 		endNY := time.Now().In(e.loc)
 		e.emit(endNY, "SYSTEM", "", "After REST correction, no tickers matched open_5m filters.", "", "info")
 		e.st.SetPhase(store.PhaseClosed)
 
-		scanNY := atTime(sessionDayNY, soldOffScanHMS, e.loc)
-		scanEnd := minTime(scanNY, endNY)
-		soldOff, _ := e.scanSoldOff(ctx, rest, openNY, scanEnd, openMetricsAll)
-
 		rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
-		rep.SoldOff = soldOff
-		e.st.SetHistoricReport(&rep)
+		e.attachSoldOff(ctx, &rep, sessionDayNY, openNY, endNY, openMetricsAll)
+		e.publishHistoricReport(&rep)
 		return nil
 	}
 	candidates = corrected
@@ -467,7 +237,7 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("09:35 selection: %d tickers matched (live tracking to 11:00).", len(candidates)), "", "info")
 
-	tracked, err := e.buildTrackedStates(ctx, rest, openNY, selNY, candidates)
+	tracked, err := e.buildTrackedStates(ctx, openNY, selNY, candidates)
 	if err != nil {
 		return err
 	}
@@ -485,7 +255,7 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 		e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("Catch-up (no actions): replaying trades via REST (%s → %s) for %d tickers…",
 			selNY.Format("15:04:05"), nowNY.Format("15:04:05"), len(syms)), "", "info")
 		for _, sym := range syms {
-			it := rest.ListTrades(ctx, sym, selNY, nowNY)
+			it := e.md.Trades(ctx, sym, selNY, nowNY)
 			for it.Next() {
 				select {
 				case <-ctx.Done():
@@ -493,29 +263,28 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 				default:
 				}
 				tr := it.Item()
-				tsMillis := tradeTimeMillis(tr)
-				if tsMillis == 0 {
+				if tr.Timestamp == 0 {
 					continue
 				}
-				e.processTrade(openNY, selNY, cutoffNY, exitNY, sym, tsMillis, tr.Price, tr.Size, false)
+				e.processTrade(openNY, selNY, cutoffNY, exitNY, sym, tr.Timestamp, tr.Price, tr.Size, tr.Conditions, false, false)
 			}
 			_ = it.Err()
 		}
 	}
 
-	// Live trades via WebSocket until 11:00
-	wsTrades, err := massive.NewWS(e.massiveKey, e.cfg.Massive.Feed)
+	// Live trades via WebSocket until 11:00 (reconnect + REST backfill, see feed.go)
+	feed, err := e.startTradeFeed(ctx, syms, exitNY, func(tr marketdata.Trade, live bool) {
+		e.onTrade(openNY, selNY, cutoffNY, exitNY, tr, live)
+	})
 	if err != nil {
 		return err
 	}
-	defer wsTrades.Close()
+	defer feed.stop()
 
-	if err := wsTrades.Subscribe(massivews.StocksTrades, syms...); err != nil {
-		return fmt.Errorf("subscribe trades: %w", err)
-	}
-	if err := wsTrades.Connect(); err != nil {
-		return fmt.Errorf("ws trades connect: %w", err)
-	}
+	// (NEW) NBBO quotes for the spread filter and quoted fills, see quotes.go
+	qctx, stopQuotes := context.WithCancel(ctx)
+	defer stopQuotes()
+	go e.runQuotes(qctx, syms, exitNY)
 
 	closed11am := make(chan struct{})
 	go func() {
@@ -535,39 +304,19 @@ func (e *Engine) runHistoricLiveToday(ctx context.Context, rest *mrestClientShim
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", "HISTORIC-LIVE: tracking live trades (VWAP cross logic active)…", "", "info")
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-closed11am:
-			e.st.SetPhase(store.PhaseClosed)
-
-			scanNY := atTime(sessionDayNY, soldOffScanHMS, e.loc)
-			scanEnd := minTime(scanNY, exitNY)
-			soldOff, _ := e.scanSoldOff(ctx, rest, openNY, scanEnd, openMetricsAll)
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-closed11am:
+		feed.stop()
+		stopQuotes()
+		e.st.SetPhase(store.PhaseClosed)
 
-			rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, exitNY)
-			rep.SoldOff = soldOff
-			e.st.SetHistoricReport(&rep)
-			e.emit(time.Now().In(e.loc), "SYSTEM", "", "Historic report ready (see the web UI).", "", "info")
-			return nil
-		case err, ok := <-wsTrades.Error():
-			if !ok {
-				return nil
-			}
-			if err != nil {
-				return err
-			}
-		case msg, ok := <-wsTrades.Output():
-			if !ok {
-				return nil
-			}
-			tr, ok := msg.(massive.EquityTrade)
-			if !ok {
-				continue
-			}
-			e.onTrade(openNY, selNY, cutoffNY, exitNY, tr)
-		}
+		rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, exitNY)
+		e.attachSoldOff(ctx, &rep, sessionDayNY, openNY, exitNY, openMetricsAll)
+		e.publishHistoricReport(&rep)
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Historic report ready (see the web UI).", "", "info")
+		return nil
 	}
 }
 
@@ -577,23 +326,22 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 	asOfNY := time.Now().In(e.loc)
 	targetDayNY := dateOnlyInLoc(targetDateNY, e.loc)
 
-	restClient := massive.NewREST(e.massiveKey)
-	rest := newRESTShim(restClient)
-
-	resolvedDayNY, note, err := e.resolveHistoricSessionDate(ctx, rest, targetDayNY, asOfNY)
+	resolvedDayNY, note, err := e.resolveHistoricSessionDate(targetDayNY, asOfNY)
 	if err != nil {
 		e.st.SetPhase(store.PhaseClosed)
 		e.emit(asOfNY, "SYSTEM", "", fmt.Sprintf("Historic date resolution failed for %s: %v", targetDayNY.Format("2006-01-02"), err), "", "warn")
 		return err
 	}
 
+	sess := e.SessionFor(resolvedDayNY) // (NEW) force exit clamped on early-close days
+	openNY, selNY, cutoffNY, exitNY := sess.OpenNY, sess.SelNY, sess.CutoffNY, sess.ExitNY
+	if calendar.EarlyClose(resolvedDayNY) {
+		note = strings.TrimSpace(note + fmt.Sprintf(" Early close (13:00): force exit at %s.", exitNY.Format("15:04:05")))
+	}
+
 	// Reset store for a clean replay + UI session boundary
 	e.st.ResetForHistoricRun(targetDayNY, resolvedDayNY, note)
-
-	openNY := atTime(resolvedDayNY, e.cfg.Market.OpenTime, e.loc)
-	selNY := atTime(resolvedDayNY, e.cfg.Market.SelectionTime, e.loc)
-	cutoffNY := atTime(resolvedDayNY, e.cfg.Market.VWAPCrossCutoff, e.loc)
-	exitNY := atTime(resolvedDayNY, e.cfg.Market.ForceExitTime, e.loc)
+	e.st.SetHistoricRangeReport(nil)
 
 	// NEW: If "today" and before 11:00, switch to hybrid live mode:
 	// - collect open5m (WS if before 09:35, otherwise REST)
@@ -607,7 +355,14 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 			e.emit(asOfNY, "SYSTEM", "", note, "", "info")
 		}
 		e.emit(asOfNY, "SYSTEM", "", "Audio alerts disabled in historic mode.", "", "info")
-		return e.runHistoricLiveToday(ctx, rest, resolvedDayNY, openNY, selNY, cutoffNY, exitNY)
+		return e.runHistoricLiveToday(ctx, resolvedDayNY, openNY, selNY, cutoffNY, exitNY)
+	}
+
+	// (NEW) no NBBO in a REST replay
+	if err := replayQuotesErr(e.simParams()); err != nil {
+		e.st.SetPhase(store.PhaseClosed)
+		e.emit(asOfNY, "SYSTEM", "", fmt.Sprintf("Historic replay refused: %v", err), "", "warn")
+		return err
 	}
 
 	// IMPORTANT: avoid lookahead only when replaying "today".
@@ -616,9 +371,6 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 		endNY = asOfNY
 	}
 
-	e.st.SetTimes(openNY, selNY, cutoffNY, exitNY)
-	e.st.SetPhase(store.PhaseCollecting5m)
-
 	e.emit(asOfNY, "SYSTEM", "", fmt.Sprintf("HISTORIC mode: replaying %s from %s → %s (force exit %s).",
 		resolvedDayNY.Format("2006-01-02"),
 		openNY.Format("15:04:05"),
@@ -630,13 +382,24 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 	}
 	e.emit(asOfNY, "SYSTEM", "", "Audio alerts disabled in historic mode.", "", "info")
 
+	_, err = e.replaySession(ctx, resolvedDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
+	return err
+}
+
+// replaySession replays one resolved session via REST from open → endNY, publishes the
+// report to the store and returns it. The store must already be reset for this session.
+func (e *Engine) replaySession(ctx context.Context, sessionDayNY, openNY, selNY, cutoffNY, exitNY, endNY time.Time) (*store.HistoricReport, error) {
+	e.st.SetTimes(openNY, selNY, cutoffNY, exitNY)
+	e.st.SetPhase(store.PhaseCollecting5m)
+
 	// Phase 1: build open-5m metrics via REST aggs
-	e.emit(asOfNY, "SYSTEM", "", "Fetching 09:30–09:34 minute bars via REST for open-5m metrics...", "", "info")
-	if err := e.collectOpen5mViaREST(ctx, rest, openNY, selNY); err != nil {
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", "Fetching 09:30–09:34 minute bars via REST for open-5m metrics...", "", "info")
+	if err := e.collectOpen5mViaREST(ctx, openNY, selNY); err != nil {
 		e.st.SetPhase(store.PhaseClosed)
-		return err
+		return nil, err
 	}
 
+	e.collectPremarket(ctx, openNY)
 	openMetricsAll := e.snapshotOpen5mMetricsForWatchlist()
 
 	e.st.SetPhase(store.PhaseSelecting0935)
@@ -645,20 +408,17 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 		e.emit(time.Now().In(e.loc), "SYSTEM", "", "No tickers matched open_5m filters at 09:35.", "", "info")
 		e.st.SetPhase(store.PhaseClosed)
 
-		rep := e.buildHistoricReport(resolvedDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
-		scanNY := atTime(resolvedDayNY, soldOffScanHMS, e.loc)
-		scanEnd := minTime(scanNY, endNY)
-		soldOff, _ := e.scanSoldOff(ctx, rest, openNY, scanEnd, openMetricsAll)
-		rep.SoldOff = soldOff
-		e.st.SetHistoricReport(&rep)
-		return nil
+		rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
+		e.attachSoldOff(ctx, &rep, sessionDayNY, openNY, endNY, openMetricsAll)
+		e.publishHistoricReport(&rep)
+		return &rep, nil
 	}
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("09:35 selection: %d tickers matched opening filters (REST replay continues)", len(candidates)), "", "info")
 
-	tracked, err := e.buildTrackedStates(ctx, rest, openNY, selNY, candidates)
+	tracked, err := e.buildTrackedStates(ctx, openNY, selNY, candidates)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	e.st.SetTrackedTickers(tracked)
 	e.st.SetPhase(store.PhaseTrackingTicks)
@@ -679,22 +439,21 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 	for _, sym := range syms {
 		select {
 		case <-ctx.Done():
-			return nil
+			return nil, ctx.Err()
 		default:
 		}
-		it := rest.ListTrades(ctx, sym, selNY, endNY)
+		it := e.md.Trades(ctx, sym, selNY, endNY)
 		for it.Next() {
 			select {
 			case <-ctx.Done():
-				return nil
+				return nil, ctx.Err()
 			default:
 			}
 			tr := it.Item()
-			tsMillis := tradeTimeMillis(tr)
-			if tsMillis == 0 {
+			if tr.Timestamp == 0 {
 				continue
 			}
-			e.processTrade(openNY, selNY, cutoffNY, endNY, sym, tsMillis, tr.Price, tr.Size, true)
+			e.processTrade(openNY, selNY, cutoffNY, endNY, sym, tr.Timestamp, tr.Price, tr.Size, tr.Conditions, true, false)
 		}
 		if err := it.Err(); err != nil {
 			e.emit(time.Now().In(e.loc), "SYSTEM", sym, fmt.Sprintf("trade replay failed: %v", err), "", "warn")
@@ -712,15 +471,12 @@ func (e *Engine) RunHistoricForDate(ctx context.Context, targetDateNY time.Time)
 
 	e.st.SetPhase(store.PhaseClosed)
 
-	rep := e.buildHistoricReport(resolvedDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
-	scanNY := atTime(resolvedDayNY, soldOffScanHMS, e.loc)
-	scanEnd := minTime(scanNY, endNY)
-	soldOff, _ := e.scanSoldOff(ctx, rest, openNY, scanEnd, openMetricsAll)
-	rep.SoldOff = soldOff
-	e.st.SetHistoricReport(&rep)
+	rep := e.buildHistoricReport(sessionDayNY, openNY, selNY, cutoffNY, exitNY, endNY)
+	e.attachSoldOff(ctx, &rep, sessionDayNY, openNY, endNY, openMetricsAll)
+	e.publishHistoricReport(&rep)
 
 	e.emit(time.Now().In(e.loc), "SYSTEM", "", "Historic report ready (see the web UI).", "", "info")
-	return nil
+	return &rep, nil
 }
 
 func dateOnlyInLoc(t time.Time, loc *time.Location) time.Time {
@@ -734,65 +490,31 @@ func sameDayInLoc(a, b time.Time, loc *time.Location) bool {
 	return aa.Year() == bb.Year() && aa.Month() == bb.Month() && aa.Day() == bb.Day()
 }
 
-// resolveHistoricSessionDate returns a trading session date to use:
-// - clamps future dates
-// - skips weekends
-// - detects holidays/closed days by probing for open-5m data; falls back to prior session
-func (e *Engine) resolveHistoricSessionDate(ctx context.Context, rest *mrestClientShim, targetDayNY, asOfNY time.Time) (resolvedDayNY time.Time, note string, err error) {
+// resolveHistoricSessionDate returns the trading session to use for targetDayNY (internal/calendar):
+// - future dates are an error
+// - weekends and exchange holidays fall back to the previous session
+// - today before the open falls back too (its session has not started)
+func (e *Engine) resolveHistoricSessionDate(targetDayNY, asOfNY time.Time) (resolvedDayNY time.Time, note string, err error) {
 	targetDayNY = dateOnlyInLoc(targetDayNY, e.loc)
 	todayNY := dateOnlyInLoc(asOfNY, e.loc)
 	if targetDayNY.After(todayNY) {
 		return time.Time{}, "", fmt.Errorf("date is in the future (max %s)", todayNY.Format("2006-01-02"))
 	}
 
-	d := targetDayNY
-	// start by skipping weekends fast
-	if d.Weekday() == time.Saturday {
-		d = d.AddDate(0, 0, -1)
-	} else if d.Weekday() == time.Sunday {
-		d = d.AddDate(0, 0, -2)
-	}
-
-	maxAttempts := e.cfg.History.MaxCalendarLookback + 15
-	if maxAttempts < 10 {
-		maxAttempts = 10
+	d := calendar.LastTradingDay(targetDayNY)
+	if !sameDayInLoc(d, targetDayNY, e.loc) {
+		return d, fmt.Sprintf("Requested %s; market closed (%s) — showing %s.",
+			targetDayNY.Format("2006-01-02"), calendar.Describe(targetDayNY), d.Format("2006-01-02")), nil
 	}
-
-	for i := 0; i < maxAttempts; i++ {
-		select {
-		case <-ctx.Done():
-			return time.Time{}, "", ctx.Err()
-		default:
-		}
-
-		openNY := atTime(d, e.cfg.Market.OpenTime, e.loc)
-		selNY := atTime(d, e.cfg.Market.SelectionTime, e.loc)
-
-		ok, derr := e.hasOpen5mData(ctx, rest, openNY, selNY)
-		if derr != nil {
-			return time.Time{}, "", derr
-		}
-		if ok {
-			if !sameDayInLoc(d, targetDayNY, e.loc) {
-				return d, fmt.Sprintf("Requested %s; market closed / no data — showing %s.", targetDayNY.Format("2006-01-02"), d.Format("2006-01-02")), nil
-			}
-			return d, "", nil
-		}
-
-		// step back and skip weekends
-		d = d.AddDate(0, 0, -1)
-		for d.Weekday() == time.Saturday {
-			d = d.AddDate(0, 0, -1)
-		}
-		for d.Weekday() == time.Sunday {
-			d = d.AddDate(0, 0, -2)
-		}
+	if sameDayInLoc(d, asOfNY, e.loc) && asOfNY.Before(e.SessionFor(d).OpenNY) {
+		prev := calendar.PrevTradingDay(d)
+		return prev, fmt.Sprintf("Requested %s; the session has not opened yet — showing %s.",
+			targetDayNY.Format("2006-01-02"), prev.Format("2006-01-02")), nil
 	}
-
-	return time.Time{}, "", fmt.Errorf("no market session data found within %d days of %s", maxAttempts, targetDayNY.Format("2006-01-02"))
+	return d, "", nil
 }
 
-func (e *Engine) hasOpen5mData(ctx context.Context, rest *mrestClientShim, openNY, selNY time.Time) (bool, error) {
+func (e *Engine) hasOpen5mData(ctx context.Context, openNY, selNY time.Time) (bool, error) {
 	wl := e.st.Watchlist()
 	if len(wl) == 0 {
 		return false, fmt.Errorf("watchlist is empty")
@@ -810,7 +532,7 @@ func (e *Engine) hasOpen5mData(ctx context.Context, rest *mrestClientShim, openN
 			return false, ctx.Err()
 		default:
 		}
-		o, hi, lo, vol, ok, err := rest.Open5mMetrics(ctx, wl[i], openNY, selNY)
+		o, hi, lo, vol, ok, err := e.open5mMetrics(ctx, wl[i], openNY, selNY)
 		if err != nil {
 			lastErr = err
 			continue
@@ -826,9 +548,21 @@ func (e *Engine) hasOpen5mData(ctx context.Context, rest *mrestClientShim, openN
 	return false, nil
 }
 
-func (e *Engine) collectOpen5mViaREST(ctx context.Context, rest *mrestClientShim, openNY, selNY time.Time) error {
-	wl := e.st.Watchlist()
+func (e *Engine) collectOpen5mViaREST(ctx context.Context, openNY, selNY time.Time) error {
+	for sym, m := range e.fetchOpen5mMetrics(ctx, e.st.Watchlist(), openNY, selNY) {
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			t.Open0930 = m.Open0930
+			t.ORHigh = m.ORHigh
+			t.ORLow = m.ORLow
+			t.Open5mVol = m.Open5mVol
+		})
+	}
+	return nil
+}
 
+// fetchOpen5mMetrics pulls 09:30–09:34 bars for syms via REST (worker pool) without touching the store.
+// Symbols with no usable open bars are left out.
+func (e *Engine) fetchOpen5mMetrics(ctx context.Context, syms []string, openNY, selNY time.Time) map[string]open5mMetric {
 	type res struct {
 		sym  string
 		o930 float64
@@ -853,7 +587,7 @@ func (e *Engine) collectOpen5mViaREST(ctx context.Context, rest *mrestClientShim
 		go func() {
 			defer wg.Done()
 			for sym := range jobs {
-				o, hi, lo, vol, ok, err := rest.Open5mMetrics(ctx, sym, openNY, selNY)
+				o, hi, lo, vol, ok, err := e.open5mMetrics(ctx, sym, openNY, selNY)
 				results <- res{sym: sym, o930: o, hi: hi, lo: lo, vol: vol, ok: ok, err: err}
 			}
 		}()
@@ -861,7 +595,7 @@ func (e *Engine) collectOpen5mViaREST(ctx context.Context, rest *mrestClientShim
 
 	go func() {
 		defer close(jobs)
-		for _, sym := range wl {
+		for _, sym := range syms {
 			select {
 			case <-ctx.Done():
 				return
@@ -875,6 +609,7 @@ func (e *Engine) collectOpen5mViaREST(ctx context.Context, rest *mrestClientShim
 		close(results)
 	}()
 
+	out := make(map[string]open5mMetric, len(syms))
 	for r := range results {
 		if r.err != nil {
 			continue
@@ -882,181 +617,78 @@ func (e *Engine) collectOpen5mViaREST(ctx context.Context, rest *mrestClientShim
 		if !r.ok || r.o930 <= 0 || r.hi <= 0 || r.lo <= 0 {
 			continue
 		}
-		e.st.UpsertTicker(r.sym, func(t *store.TickerState) {
-			t.Open0930 = r.o930
-			t.ORHigh = r.hi
-			t.ORLow = r.lo
-			t.Open5mVol = r.vol
-		})
-	}
-
-	return nil
-}
-
-func (e *Engine) closeAllOpenPositionsAt(tsNY time.Time) {
-	wl := e.st.Watchlist()
-	for _, sym := range wl {
-		t := e.st.GetTicker(sym)
-		if t == nil {
-			continue
-		}
-		if t.HasPosition && !t.Exited {
-			px := t.LastPrice
-			if px <= 0 {
-				px = t.EntryPrice
-			}
-			e.closePosition(tsNY, sym, "TIME_EXIT", fmt.Sprintf("Close at %s. %s", tsNY.Format("15:04"), sym), px)
+		out[r.sym] = open5mMetric{
+			Open0930:  r.o930,
+			ORHigh:    r.hi,
+			ORLow:     r.lo,
+			Open5mVol: r.vol,
+			RangePct:  (r.hi - r.lo) / r.o930,
 		}
 	}
+	return out
 }
 
-// tradeTimeMillis picks the best available trade timestamp from the REST model and returns Unix ms.
-func tradeTimeMillis(tr models.Trade) int64 {
-	// Prefer SIP timestamp (best “tape time”), then participant, then TRF.
-	if !time.Time(tr.SipTimestamp).IsZero() {
-		return time.Time(tr.SipTimestamp).UnixMilli()
-	}
-	if !time.Time(tr.ParticipantTimestamp).IsZero() {
-		return time.Time(tr.ParticipantTimestamp).UnixMilli()
-	}
-	if !time.Time(tr.TrfTimestamp).IsZero() {
-		return time.Time(tr.TrfTimestamp).UnixMilli()
-	}
-	return 0
+// closeAllOpenPositionsAt ends a run cut short at tsNY: the strategy's timer exits run as if
+// tsNY were force_exit_time (positions it keeps are marked at endNY in the report).
+func (e *Engine) closeAllOpenPositionsAt(tsNY time.Time) {
+	e.closeOnTimer(tsNY, "Close at "+tsNY.Format("15:04"))
 }
 
 func (e *Engine) buildHistoricReport(sessionDateNY, openNY, selNY, cutoffNY, exitNY, endNY time.Time) store.HistoricReport {
-	f := e.st.Filters()
+	return e.reportFromStates(e.st.TickerStates(), e.simParams(), sessionDateNY, openNY, selNY, cutoffNY, endNY)
+}
 
-	states := e.st.TickerStates()
+// reportFromStates builds a session report from tracked states evaluated under p
+// (the store's for live/historic runs, a shadow profile's own copies otherwise).
+func (e *Engine) reportFromStates(states []store.TickerState, p SimParams, sessionDateNY, openNY, selNY, cutoffNY, endNY time.Time) store.HistoricReport {
+	f := p.Filters
 
 	trades := make([]store.HistoricTrade, 0, 32)
 	noEntries := make([]store.HistoricNoEntry, 0, 64)
 
-	sumPnL := 0.0
-	sumNotional := 0.0
-	sumPct := 0.0
-
-	sumWinAmt := 0.0
-	sumLossAmt := 0.0
-	sumWinPct := 0.0
-	sumLossPct := 0.0
-	winN := 0
-	lossN := 0
-	timeExitN := 0
-
-	bestPct := 0.0
-	worstPct := 0.0
-	firstTrade := true
-
 	for _, t := range states {
-		if t.HasPosition && t.EntryPrice > 0 && !t.EntryTime.IsZero() {
-			entry := t.EntryPrice
-
-			exitPx := t.ExitPrice
-			exitTime := t.ExitTime
-			if exitPx <= 0 {
-				// if still open, approximate with last known
-				exitPx = t.LastPrice
-				exitTime = endNY
-			}
-
-			realPct := (exitPx - entry) / entry
-			realAmt := (exitPx - entry) * float64(historicShares)
-
-			holdPx := t.LastPrice
-			holdPct := (holdPx - entry) / entry
-			holdAmt := (holdPx - entry) * float64(historicShares)
-
-			mfePx := t.MaxPriceSinceEntry
-			mfeTime := t.MaxPriceSinceEntryTime
-			if mfePx <= 0 {
-				mfePx = entry
-				mfeTime = t.EntryTime
-			}
-			mfePct := (mfePx - entry) / entry
-			mfeAmt := (mfePx - entry) * float64(historicShares)
-
-			maePx := t.MinPriceSinceEntry
-			maeTime := t.MinPriceSinceEntryTime
-			if maePx <= 0 {
-				maePx = entry
-				maeTime = t.EntryTime
-			}
-			maePct := (maePx - entry) / entry
-			maeAmt := (maePx - entry) * float64(historicShares)
-
-			entryTimeNY := t.EntryTime.In(e.loc).Format("15:04:05")
-			exitTimeNY := exitTime.In(e.loc).Format("15:04:05")
-
-			trades = append(trades, store.HistoricTrade{
-				Symbol:                t.Symbol,
-				EntryTimeNY:           entryTimeNY,
-				EntryPrice:            entry,
-				EntryMinutesAfterOpen: t.EntryMinutesAfterOpen,
-				TakeProfitPrice:       t.TakeProfitPrice,
-				StopPrice:             t.StopPrice,
-				ExitTimeNY:            exitTimeNY,
-				ExitPrice:             exitPx,
-				ExitMinutesAfterOpen:  t.ExitMinutesAfterOpen,
-				ExitReason:            t.ExitReason,
-				Shares:                historicShares,
-				RealizedPnLPct:        realPct,
-				RealizedPnL:           realAmt,
-				HoldPrice:             holdPx,
-				HoldPnLPct:            holdPct,
-				HoldPnL:               holdAmt,
-				MFEPrice:              mfePx,
-				MFETimeNY:             mfeTime.In(e.loc).Format("15:04:05"),
-				MFEPnLPct:             mfePct,
-				MFEPnL:                mfeAmt,
-				MAEPrice:              maePx,
-				MAETimeNY:             maeTime.In(e.loc).Format("15:04:05"),
-				MAEPnLPct:             maePct,
-				MAEPnL:                maeAmt,
-			})
-
-			sumPnL += realAmt
-			sumNotional += entry * float64(historicShares)
-			sumPct += realPct
-
-			if t.ExitReason == "TIME_EXIT" {
-				timeExitN++
-			}
-
-			if realAmt >= 0 {
-				sumWinAmt += realAmt
-				sumWinPct += realPct
-				winN++
-			} else {
-				sumLossAmt += realAmt // negative
-				sumLossPct += realPct
-				lossN++
+		if tr, ok := historicTradeFromState(t, endNY, e.loc, p.Costs); ok {
+			trades = append(trades, tr)
+		} else {
+			// Selected but no entry (cross fields follow the side(s) being traded)
+			crossed, crossTime, crossPx, crossName := t.SawCrossInWindow, t.FirstCrossTime, t.FirstCrossPrice, "VWAP cross"
+			switch {
+			case f.Side == SideShort:
+				crossed, crossTime, crossPx, crossName = t.SawCrossDownInWindow, t.FirstCrossDownTime, t.FirstCrossDownPrice, "VWAP cross-down"
+			case f.Side == SideBoth && !crossed:
+				crossed, crossTime, crossPx, crossName = t.SawCrossDownInWindow, t.FirstCrossDownTime, t.FirstCrossDownPrice, "VWAP cross (up or down)"
 			}
 
-			if firstTrade {
-				bestPct = realPct
-				worstPct = realPct
-				firstTrade = false
-			} else {
-				if realPct > bestPct {
-					bestPct = realPct
-				}
-				if realPct < worstPct {
-					worstPct = realPct
+			crossStart := openNY.Add(1 * time.Minute)
+			if f.EntryMode == EntryORBreakout {
+				// (NEW) the trigger is the first print through the opening range after 09:35
+				crossed, crossTime, crossPx, crossName = !t.BreakoutUpTime.IsZero(), t.BreakoutUpTime, t.BreakoutUpPrice, "trade through OR high"
+				switch {
+				case f.Side == SideShort:
+					crossed, crossTime, crossPx, crossName = !t.BreakoutDownTime.IsZero(), t.BreakoutDownTime, t.BreakoutDownPrice, "trade under OR low"
+				case f.Side == SideBoth && !crossed:
+					crossed, crossTime, crossPx, crossName = !t.BreakoutDownTime.IsZero(), t.BreakoutDownTime, t.BreakoutDownPrice, "opening-range breakout"
 				}
+				crossStart = selNY
 			}
-		} else {
-			// Selected but no entry
+
 			reason := ""
-			if !t.SawCrossInWindow {
-				start := openNY.Add(1 * time.Minute).Format("15:04")
+			if !crossed {
+				start := crossStart.Format("15:04")
 				end := cutoffNY.Format("15:04")
-				reason = fmt.Sprintf("No VWAP cross between %s and %s", start, end)
+				reason = fmt.Sprintf("No %s between %s and %s", crossName, start, end)
 			} else if t.Open5mTodayPct < f.Open5mTodayPctMin || t.Open5mTodayPct > f.Open5mTodayPctMax {
 				reason = "Open5mToday% filter failed"
-			} else if t.FirstCrossPrice > 0 && (t.FirstCrossPrice < f.EntryPriceMin || t.FirstCrossPrice > f.EntryPriceMax) {
-				reason = "VWAP cross occurred but price filter failed"
+			} else if !premarketOK(&t, f) {
+				reason = fmt.Sprintf("Gap / premarket volume filter failed (gap %.2f%%, premarket vol %.0f)", t.GapPct*100, t.PremarketVol)
+			} else if crossPx > 0 && (crossPx < f.EntryPriceMin || crossPx > f.EntryPriceMax) {
+				reason = fmt.Sprintf("%s occurred but price filter failed", crossName)
+			} else if crossPx > 0 && p.Sizing.shares(crossPx, 0) == 0 {
+				reason = fmt.Sprintf("%s occurred but sizing.max_notional %.2f is below one share", crossName, p.Sizing.MaxNotional)
+			} else if f.MaxSpreadPct > 0 && t.Ask > 0 && t.SpreadPct > f.MaxSpreadPct {
+				reason = fmt.Sprintf("%s occurred but spread too wide (last %.2f%% > %.2f%%)", crossName, t.SpreadPct*100, f.MaxSpreadPct*100)
+			} else if f.EntryMode == EntryORBreakout && f.BreakoutVolMult > 0 {
+				reason = "No entry (breakout never confirmed by volume inside the entry window)"
 			} else {
 				reason = "No entry (filters + cross never aligned)"
 			}
@@ -1066,14 +698,14 @@ func (e *Engine) buildHistoricReport(sessionDateNY, openNY, selNY, cutoffNY, exi
 				Open5mVol:        t.Open5mVol,
 				Open5mRangePct:   t.Open5mRangePct,
 				Open5mTodayPct:   t.Open5mTodayPct,
-				SawCrossInWindow: t.SawCrossInWindow,
+				SawCrossInWindow: crossed,
 				FirstCrossTimeNY: func() string {
-					if t.FirstCrossTime.IsZero() {
+					if crossTime.IsZero() {
 						return ""
 					}
-					return t.FirstCrossTime.In(e.loc).Format("15:04:05")
+					return crossTime.In(e.loc).Format("15:04:05")
 				}(),
-				FirstCrossPrice: t.FirstCrossPrice,
+				FirstCrossPrice: crossPx,
 				Reason:          reason,
 			})
 		}
@@ -1087,6 +719,96 @@ func (e *Engine) buildHistoricReport(sessionDateNY, openNY, selNY, cutoffNY, exi
 		return noEntries[i].Symbol < noEntries[j].Symbol
 	})
 
+	summary := summarizeTrades(trades)
+	summary.DateNY = sessionDateNY.Format("2006-01-02")
+	summary.WindowStartNY = openNY.Format("15:04:05")
+	summary.WindowEndNY = endNY.Format("15:04:05")
+	summary.Sizing = p.Sizing.String()
+	summary.CostModel = p.Costs.String()
+	summary.Candidates = len(states)
+	summary.NoEntry = len(noEntries)
+	for _, t := range states {
+		summary.ExcludedTrades += t.ExcludedTrades
+	}
+
+	return store.HistoricReport{
+		Summary:   summary,
+		Trades:    trades,
+		NoEntries: noEntries,
+	}
+}
+
+// summarizeTrades fills the trade-derived HistoricSummary fields (counts, net/gross P&L, costs, returns,
+// profit factor, best/worst). Session fields (date, window, candidates...) are left to the caller.
+func summarizeTrades(trades []store.HistoricTrade) store.HistoricSummary {
+	sumPnL := 0.0
+	sumGross := 0.0
+	sumCosts := 0.0
+	sumNotional := 0.0
+	sumPct := 0.0
+
+	sumWinAmt := 0.0
+	sumLossAmt := 0.0
+	sumWinPct := 0.0
+	sumLossPct := 0.0
+	winN := 0
+	lossN := 0
+	byReason := make(map[string]int, 6)
+
+	type sideStats struct {
+		trades, wins int
+		pnl          float64
+	}
+	bySide := map[string]*sideStats{SideLong: {}, SideShort: {}}
+
+	bestPct := 0.0
+	worstPct := 0.0
+
+	shares := 0
+	for i, tr := range trades {
+		switch {
+		case i == 0:
+			shares = tr.Shares
+		case tr.Shares != shares:
+			shares = 0
+		}
+
+		sumPnL += tr.RealizedPnL
+		sumGross += tr.GrossPnL
+		sumCosts += tr.Slippage + tr.Commission + tr.Fees
+		sumNotional += tr.EntryPrice * float64(tr.Shares)
+		sumPct += tr.RealizedPnLPct
+
+		byReason[tr.ExitReason]++
+
+		ss := bySide[SideLong]
+		if tr.Side == SideShort {
+			ss = bySide[SideShort]
+		}
+		ss.trades++
+		ss.pnl += tr.RealizedPnL
+		if tr.RealizedPnL >= 0 {
+			ss.wins++
+		}
+
+		if tr.RealizedPnL >= 0 {
+			sumWinAmt += tr.RealizedPnL
+			sumWinPct += tr.RealizedPnLPct
+			winN++
+		} else {
+			sumLossAmt += tr.RealizedPnL // negative
+			sumLossPct += tr.RealizedPnLPct
+			lossN++
+		}
+
+		if i == 0 || tr.RealizedPnLPct > bestPct {
+			bestPct = tr.RealizedPnLPct
+		}
+		if i == 0 || tr.RealizedPnLPct < worstPct {
+			worstPct = tr.RealizedPnLPct
+		}
+	}
+
 	tradeN := len(trades)
 	winRate := 0.0
 	if tradeN > 0 {
@@ -1112,6 +834,13 @@ func (e *Engine) buildHistoricReport(sessionDateNY, openNY, selNY, cutoffNY, exi
 		avgLoss = sumLossPct / float64(lossN)
 	}
 
+	sideWinRate := func(ss *sideStats) float64 {
+		if ss.trades == 0 {
+			return 0
+		}
+		return float64(ss.wins) / float64(ss.trades)
+	}
+
 	profitFactor := 0.0
 	if sumLossAmt < 0 {
 		profitFactor = sumWinAmt / (-sumLossAmt)
@@ -1119,32 +848,171 @@ func (e *Engine) buildHistoricReport(sessionDateNY, openNY, selNY, cutoffNY, exi
 		profitFactor = 999.0
 	}
 
-	summary := store.HistoricSummary{
-		DateNY:        sessionDateNY.Format("2006-01-02"),
-		WindowStartNY: openNY.Format("15:04:05"),
-		WindowEndNY:   endNY.Format("15:04:05"),
-		Shares:        historicShares,
-		Candidates:    len(states),
-		TradesTaken:   tradeN,
-		NoEntry:       len(noEntries),
-		Wins:          winN,
-		Losses:        lossN,
-		TimeExits:     timeExitN,
-		WinRate:       winRate,
-		NetPnL:        sumPnL,
-		TotalNotional: sumNotional,
-		NetReturnPct:  netRet,
-		AvgReturnPct:  avgRet,
-		AvgWinPct:     avgWin,
-		AvgLossPct:    avgLoss,
-		ProfitFactor:  profitFactor,
-		BestTradePct:  bestPct,
-		WorstTradePct: worstPct,
+	return store.HistoricSummary{
+		Shares:         shares,
+		TradesTaken:    tradeN,
+		Wins:           winN,
+		Losses:         lossN,
+		TimeExits:      byReason[ExitTime],
+		ProfitExits:    byReason[ExitProfit],
+		StopExits:      byReason[ExitStop],
+		TrailExits:     byReason[ExitTrail],
+		BreakEvenExits: byReason[ExitBreakEven],
+		TimeStopExits:  byReason[ExitTimeStop],
+		WinRate:        winRate,
+		NetPnL:         sumPnL,
+		GrossPnL:       sumGross,
+		TotalCosts:     sumCosts,
+		TotalNotional:  sumNotional,
+		NetReturnPct:   netRet,
+		AvgReturnPct:   avgRet,
+		AvgWinPct:      avgWin,
+		AvgLossPct:     avgLoss,
+		ProfitFactor:   profitFactor,
+		BestTradePct:   bestPct,
+		WorstTradePct:  worstPct,
+
+		LongTrades:   bySide[SideLong].trades,
+		LongWins:     bySide[SideLong].wins,
+		LongWinRate:  sideWinRate(bySide[SideLong]),
+		LongNetPnL:   bySide[SideLong].pnl,
+		ShortTrades:  bySide[SideShort].trades,
+		ShortWins:    bySide[SideShort].wins,
+		ShortWinRate: sideWinRate(bySide[SideShort]),
+		ShortNetPnL:  bySide[SideShort].pnl,
 	}
+}
 
-	return store.HistoricReport{
-		Summary:   summary,
-		Trades:    trades,
-		NoEntries: noEntries,
+// historicTradeFromState turns a ticker that entered into a report row (sized at entry, t.Shares).
+// Positions still open are marked at the last price as of endNY. Realized P&L is net of c;
+// hold/MFE/MAE stay gross (they are excursions, not fills). Shorts flip the sign of every P&L,
+// and their MFE is the low since entry (MAE the high).
+func historicTradeFromState(t store.TickerState, endNY time.Time, loc *time.Location, c Costs) (store.HistoricTrade, bool) {
+	if !t.HasPosition || t.EntryPrice <= 0 || t.EntryTime.IsZero() {
+		return store.HistoricTrade{}, false
+	}
+	side := t.Side
+	if side == "" {
+		side = SideLong
+	}
+	dir := sideDir(side)
+	entry := t.EntryPrice
+	shares := float64(t.Shares)
+
+	exitPx := t.ExitPrice
+	exitTime := t.ExitTime
+	exitQuote := t.ExitQuotePrice
+	if exitPx <= 0 {
+		// if still open, approximate with last known
+		exitPx = t.LastPrice
+		exitTime = endNY
+		exitQuote = quoteFillPrice(&t, side == SideShort)
+	}
+
+	grossAmt := dir * (exitPx - entry) * shares
+	cost := c.roundTrip(side, entry, exitPx, t.Shares, t.EntryQuotePrice, exitQuote)
+	realAmt := grossAmt - cost.total()
+	realPct := realAmt / (entry * shares)
+
+	holdPx := t.LastPrice
+	holdPct := dir * (holdPx - entry) / entry
+	holdAmt := dir * (holdPx - entry) * shares
+
+	mfePx, mfeTime := t.MaxPriceSinceEntry, t.MaxPriceSinceEntryTime
+	maePx, maeTime := t.MinPriceSinceEntry, t.MinPriceSinceEntryTime
+	if side == SideShort {
+		mfePx, mfeTime, maePx, maeTime = maePx, maeTime, mfePx, mfeTime
+	}
+	if mfePx <= 0 {
+		mfePx = entry
+		mfeTime = t.EntryTime
+	}
+	mfePct := dir * (mfePx - entry) / entry
+	mfeAmt := dir * (mfePx - entry) * shares
+
+	if maePx <= 0 {
+		maePx = entry
+		maeTime = t.EntryTime
+	}
+	maePct := dir * (maePx - entry) / entry
+	maeAmt := dir * (maePx - entry) * shares
+
+	initialStop := t.InitialStopPrice
+	if initialStop <= 0 {
+		initialStop = t.StopPrice
+	}
+
+	entryTimeNY := t.EntryTime.In(loc).Format("15:04:05")
+	exitTimeNY := exitTime.In(loc).Format("15:04:05")
+
+	return store.HistoricTrade{
+		DateNY:                t.EntryTime.In(loc).Format("2006-01-02"),
+		Symbol:                t.Symbol,
+		Side:                  side,
+		EntryTimeNY:           entryTimeNY,
+		EntryPrice:            entry,
+		EntryMinutesAfterOpen: t.EntryMinutesAfterOpen,
+		TakeProfitPrice:       t.TakeProfitPrice,
+		StopPrice:             initialStop,
+		FinalStopPrice:        t.StopPrice,
+		ExitTimeNY:            exitTimeNY,
+		ExitPrice:             exitPx,
+		ExitMinutesAfterOpen:  t.ExitMinutesAfterOpen,
+		ExitReason:            t.ExitReason,
+		Shares:                t.Shares,
+		RealizedPnLPct:        realPct,
+		RealizedPnL:           realAmt,
+		GrossPnLPct:           dir * (exitPx - entry) / entry,
+		GrossPnL:              grossAmt,
+		Slippage:              cost.Slippage,
+		Commission:            cost.Commission,
+		Fees:                  cost.Fees,
+		HoldPrice:             holdPx,
+		HoldPnLPct:            holdPct,
+		HoldPnL:               holdAmt,
+		MFEPrice:              mfePx,
+		MFETimeNY:             mfeTime.In(loc).Format("15:04:05"),
+		MFEPnLPct:             mfePct,
+		MFEPnL:                mfeAmt,
+		MAEPrice:              maePx,
+		MAETimeNY:             maeTime.In(loc).Format("15:04:05"),
+		MAEPnLPct:             maePct,
+		MAEPnL:                maeAmt,
+	}, true
+}
+
+// publishHistoricReport shows a finished session report in the UI and saves it to the report DB (if enabled).
+func (e *Engine) publishHistoricReport(rep *store.HistoricReport) {
+	e.st.SetHistoricReport(rep)
+	e.saveReport(&reportdb.Record{
+		Meta: reportdb.Meta{
+			Kind:    reportdb.KindSession,
+			DateNY:  rep.Summary.DateNY,
+			Note:    e.st.HistoricNote(),
+			Summary: rep.Summary,
+		},
+		Report: rep,
+	})
+}
+
+// saveReport stamps rec with the filters/risk/config in effect and writes it.
+// A failed save only warns: the report is still in memory for the UI.
+func (e *Engine) saveReport(rec *reportdb.Record) {
+	e.saveReportWith(rec, e.simParams())
+}
+
+// saveReportWith is saveReport for a run evaluated under p (shadow profiles).
+func (e *Engine) saveReportWith(rec *reportdb.Record, p SimParams) {
+	if e.reports == nil {
+		return
+	}
+	rec.Filters = p.Filters
+	rec.TakeProfitPct = p.TakeProfitPct
+	rec.StopLossPct = p.StopLossPct
+	if b, err := yaml.Marshal(e.cfg); err == nil {
+		rec.ConfigYAML = string(b)
+	}
+	if _, err := e.reports.Save(rec); err != nil {
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Saving report failed: %v", err), "", "warn")
 	}
 }
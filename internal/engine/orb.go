@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"time"
+
+	"massive-orb/internal/store"
+)
+
+// orbStrategy is the opening-range strategy: tickers picked at 09:35 on their open-5m metrics,
+// entry on a VWAP cross (or an opening-range breakout, filters.entry_mode) inside the entry
+// window, exits from risk + exits, everything flat at force_exit_time.
+type orbStrategy struct{}
+
+func (orbStrategy) Name() string { return "orb" }
+
+func (orbStrategy) OnOpenRange(t *store.TickerState, p SimParams, s Session) {}
+
+func (orbStrategy) OnSelection(t *store.TickerState, p SimParams, s Session) {}
+
+func (orbStrategy) OnTrade(t *store.TickerState, p SimParams, s Session, trNY time.Time, price, size float64, allowActions bool) Intent {
+	f := p.Filters
+	minAfterOpen := t.MinutesAfterOpen
+
+	// ------------------------------------------------------------
+	// Cross detection window (NEW):
+	// - detect cross-ups from below starting at 09:31 (open + 1 min)
+	// - keep detecting until cutoff (09:43)
+	// - (NEW) cross-downs from above too, for the short side
+	// ------------------------------------------------------------
+	crossStartNY := s.OpenNY.Add(1 * time.Minute) // 09:31
+	crossNow := t.PrevPrice > 0 && t.PrevVWAP > 0 && t.PrevPrice < t.PrevVWAP && price >= t.VWAP
+	crossDownNow := t.PrevPrice > 0 && t.PrevVWAP > 0 && t.PrevPrice > t.PrevVWAP && price <= t.VWAP
+	inCrossWindow := !t.HasPosition && !t.Exited && !trNY.Before(crossStartNY) && trNY.Before(s.CutoffNY)
+
+	if inCrossWindow && crossNow && !t.SawCrossInWindow {
+		t.SawCrossInWindow = true
+		t.FirstCrossTime = trNY
+		t.FirstCrossPrice = price
+	}
+	if inCrossWindow && crossDownNow && !t.SawCrossDownInWindow {
+		t.SawCrossDownInWindow = true
+		t.FirstCrossDownTime = trNY
+		t.FirstCrossDownPrice = price
+	}
+
+	// (NEW) minute volume + first print through the opening range, for the or_breakout entry
+	trackBreakout(t, trNY, price, size, !t.HasPosition && !t.Exited && !trNY.Before(s.SelNY) && trNY.Before(s.CutoffNY))
+
+	// ------------------------------------------------------------
+	// Entry logic (UPDATED):
+	// - entry still ONLY after 09:35 (selNY)
+	// - BUT it can trigger if the cross happened earlier (>=09:31)
+	// - and (NEW) only if price is >= VWAP after 09:35
+	// ------------------------------------------------------------
+	if allowActions && !t.HasPosition && !t.Exited {
+		if trNY.Before(s.SelNY) {
+			return Intent{}
+		}
+		if !trNY.Before(s.CutoffNY) {
+			return Intent{}
+		}
+
+		// entry minutes window: keep using your config (default 5..12)
+		if minAfterOpen < float64(f.EntryMinAfterOpen) || minAfterOpen > float64(f.EntryMaxAfterOpen)+0.999 {
+			return Intent{}
+		}
+
+		// must meet open metrics + today pct + entry price filters
+		if t.Open5mRangePct < f.Open5mRangePctMin || t.Open5mRangePct > f.Open5mRangePctMax {
+			return Intent{}
+		}
+		if t.Open5mVol < f.Open5mVolMin || t.Open5mVol > f.Open5mVolMax {
+			return Intent{}
+		}
+		if t.Open5mTodayPct < f.Open5mTodayPctMin || t.Open5mTodayPct > f.Open5mTodayPctMax {
+			return Intent{}
+		}
+		// (NEW) gap vs previous close + premarket volume
+		if !premarketOK(t, f) {
+			return Intent{}
+		}
+		if price < f.EntryPriceMin || price > f.EntryPriceMax {
+			return Intent{}
+		}
+		// (NEW) live only: skip while the quoted spread is too wide to capture the move
+		if !spreadOK(t, f.MaxSpreadPct) {
+			return Intent{}
+		}
+
+		// (NEW) or_breakout: a print through the opening range, stop at its other side
+		if f.EntryMode == EntryORBreakout {
+			return breakoutIntent(t, f, price)
+		}
+
+		// Entry triggers if we have seen a cross anytime since 09:31 (to cutoff),
+		// even if the cross happened before 09:35.
+		// NEW: long must be at/above VWAP after 09:35; short (mirror) at/below it.
+		// side both trades only the direction of the first cross.
+		switch crossSide(t, f.Side) {
+		case SideLong:
+			if t.VWAP > 0 && price >= t.VWAP {
+				return enterIntent(SideLong)
+			}
+		case SideShort:
+			if t.VWAP > 0 && price <= t.VWAP {
+				return enterIntent(SideShort)
+			}
+		}
+	}
+
+	if allowActions && t.HasPosition && !t.Exited {
+		return manageExit(t, p, trNY, price)
+	}
+	return Intent{}
+}
+
+// crossSide is the side a VWAP-cross entry may take for filters.side ("" = no cross yet).
+// For both it is the earlier of the first cross-up and the first cross-down.
+func crossSide(t *store.TickerState, side string) string {
+	up, down := t.SawCrossInWindow, t.SawCrossDownInWindow
+	switch side {
+	case SideShort:
+		up = false
+	case SideBoth:
+		if up && down {
+			if t.FirstCrossDownTime.Before(t.FirstCrossTime) {
+				return SideShort
+			}
+			return SideLong
+		}
+	default:
+		down = false
+	}
+	switch {
+	case up:
+		return SideLong
+	case down:
+		return SideShort
+	}
+	return ""
+}
+
+// OnTimer: flat at force_exit_time (11:00).
+func (orbStrategy) OnTimer(t *store.TickerState, p SimParams, s Session, nowNY time.Time) Intent {
+	if t.HasPosition && !t.Exited && !nowNY.Before(s.ExitNY) {
+		return exitIntent(ExitTime)
+	}
+	return Intent{}
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"massive-orb/internal/broker"
+	"massive-orb/internal/store"
+)
+
+// SetBroker routes live BUY / exit signals to b as orders. Call before Run; nil = alerts only.
+func (e *Engine) SetBroker(b broker.Broker) {
+	e.broker = b
+	if n, ok := b.(broker.Notifier); ok {
+		n.OnUpdate(e.onOrderUpdate)
+	}
+}
+
+func (e *Engine) Broker() broker.Broker { return e.broker }
+
+// routeOrders: only live sessions place orders; historic replays never do.
+func (e *Engine) routeOrders() bool {
+	return e.broker != nil && e.st.Mode() == store.ModeRealtime
+}
+
+// feedBroker hands each live print to brokers that fill against the tape (paper).
+func (e *Engine) feedBroker(sym string, trNY time.Time, price, size float64) {
+	if !e.routeOrders() {
+		return
+	}
+	if f, ok := e.broker.(broker.PriceFeed); ok {
+		f.OnTrade(sym, trNY, price, size)
+	}
+}
+
+// submitEntryOrder sends the entry as a bracket: market buy (sell short) + take-profit limit +
+// stop-loss stop, so the exits live at the broker even if this process stalls.
+func (e *Engine) submitEntryOrder(tsNY time.Time, sym, side string, shares int, takeProfit, stop float64) {
+	if !e.routeOrders() {
+		return
+	}
+	bs, kind := broker.Buy, "BUY"
+	if side == SideShort {
+		bs, kind = broker.Sell, "SHORT"
+	}
+	e.submitOrder(tsNY, broker.OrderRequest{
+		Symbol:     sym,
+		Side:       bs,
+		Type:       broker.Market,
+		Qty:        shares,
+		ClientID:   clientOrderID(kind, sym, tsNY),
+		TakeProfit: takeProfit,
+		StopLoss:   stop,
+	})
+}
+
+// submitExitOrder follows an engine-detected exit. An unfilled entry is canceled; an armed
+// bracket is left to exit at the broker when the exit is one of its legs (PROFIT / initial STOP);
+// otherwise (trailing, break-even, time stop) the legs are canceled and the position sold at market.
+func (e *Engine) submitExitOrder(tsNY time.Time, sym, reason string) {
+	if !e.routeOrders() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	legExit := reason == ExitProfit || reason == ExitStop
+	armed := false
+	for _, o := range e.broker.Orders() {
+		if o.Symbol != sym || !o.Open() {
+			continue
+		}
+		if o.Leg != "" && legExit {
+			armed = true
+			continue
+		}
+		if o.Bracket() || o.Leg != "" {
+			// canceling one OCO leg may already have canceled its sibling
+			if err := e.broker.Cancel(ctx, o.ID); err != nil && !errors.Is(err, broker.ErrNotOpen) {
+				log.Printf("broker: cancel %s failed: %v", o.ID, err)
+			}
+		}
+	}
+	if armed {
+		return
+	}
+
+	qty := 0
+	for _, p := range e.broker.Positions() {
+		if p.Symbol == sym {
+			qty = p.Qty
+		}
+	}
+	if qty == 0 {
+		return
+	}
+	side := broker.Sell
+	if qty < 0 {
+		side, qty = broker.Buy, -qty // cover a short
+	}
+	e.submitOrder(tsNY, broker.OrderRequest{
+		Symbol:   sym,
+		Side:     side,
+		Type:     broker.Market,
+		Qty:      qty,
+		ClientID: clientOrderID(reason, sym, tsNY),
+	})
+}
+
+// flattenBroker cancels everything open and closes every position (11:00 time exit).
+func (e *Engine) flattenBroker(tsNY time.Time) {
+	if !e.routeOrders() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := e.broker.CloseAll(ctx); err != nil {
+		e.emit(tsNY, "ORDER", "", fmt.Sprintf("%s close-all failed: %v", e.broker.Name(), err), "", "warn")
+		return
+	}
+	e.emit(tsNY, "ORDER", "", fmt.Sprintf("%s: canceled open orders and closed all positions", e.broker.Name()), "", "info")
+}
+
+func (e *Engine) submitOrder(tsNY time.Time, req broker.OrderRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	o, err := e.broker.Submit(ctx, req)
+	if err != nil {
+		// rejections are also reported through onOrderUpdate when the broker supports it
+		if _, ok := e.broker.(broker.Notifier); !ok || o.ID == "" {
+			e.emit(tsNY, "ORDER", req.Symbol, fmt.Sprintf("%s order failed: %s %d %s: %v", e.broker.Name(), req.Side, req.Qty, req.Symbol, err), "", "warn")
+		}
+		e.syncTickerOrder(req.Symbol, func(t *store.TickerState) { t.OrderStatus = string(broker.StatusRejected) })
+		return
+	}
+	msg := fmt.Sprintf("%s order %s: %s %d %s %s", e.broker.Name(), o.ID, req.Side, req.Qty, req.Symbol, req.Type)
+	if req.Bracket() {
+		msg += fmt.Sprintf(" (bracket TP %.4f / SL %.4f)", req.TakeProfit, req.StopLoss)
+		e.syncTickerOrder(req.Symbol, func(t *store.TickerState) { t.OrderStatus = string(o.Status) })
+	}
+	e.emit(tsNY, "ORDER", req.Symbol, msg, "", "info")
+}
+
+// onOrderUpdate reports broker-side changes and syncs fills into the ticker. A bracket leg
+// that fills before the engine saw the exit on its own tape closes the position here.
+func (e *Engine) onOrderUpdate(o broker.Order) {
+	nowNY := time.Now().In(e.loc)
+	name := e.broker.Name()
+	switch o.Status {
+	case broker.StatusFilled:
+		e.emit(nowNY, "FILL", o.Symbol, fmt.Sprintf("%s FILLED %s %d %s @ %.4f", name, o.Side, o.FilledQty, o.Symbol, o.FilledAvgPrice), "", "info")
+	case broker.StatusRejected:
+		e.emit(nowNY, "ORDER", o.Symbol, fmt.Sprintf("%s REJECTED %s %d %s: %s", name, o.Side, o.Qty, o.Symbol, o.Reason), "", "warn")
+	case broker.StatusCanceled:
+		e.emit(nowNY, "ORDER", o.Symbol, fmt.Sprintf("%s canceled %s %d %s", name, o.Side, o.Qty, o.Symbol), "", "info")
+	}
+
+	var closeReason string
+	e.syncTickerOrder(o.Symbol, func(t *store.TickerState) {
+		switch {
+		case o.Bracket():
+			t.OrderStatus = string(o.Status)
+			if o.FilledQty > 0 {
+				t.FillEntryPrice = o.FilledAvgPrice
+			}
+		case o.Status == broker.StatusFilled:
+			t.FillExitPrice = o.FilledAvgPrice
+			if t.HasPosition && !t.Exited {
+				switch o.Leg {
+				case broker.LegTakeProfit:
+					closeReason = ExitProfit
+				case broker.LegStopLoss:
+					closeReason = ExitStop
+				}
+			}
+		}
+	})
+
+	if closeReason != "" {
+		e.closePosition(nowNY, o.Symbol, closeReason, exitText(closeReason, o.Symbol), o.FilledAvgPrice)
+	}
+}
+
+// clientOrderID tags our orders; brokers require it to be unique per account, hence the timestamp.
+func clientOrderID(kind, sym string, tsNY time.Time) string {
+	return fmt.Sprintf("ORB-%s-%s-%d", kind, sym, tsNY.UnixMilli())
+}
+
+// syncTickerOrder updates a tracked ticker only (manual orders for other symbols are ignored).
+func (e *Engine) syncTickerOrder(sym string, fn func(t *store.TickerState)) {
+	if e.st.GetTicker(sym) == nil {
+		return
+	}
+	e.st.UpsertTicker(sym, fn)
+}
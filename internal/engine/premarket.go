@@ -0,0 +1,387 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/store"
+)
+
+const (
+	premarketStartHMS = "04:00:00" // extended-hours minute aggs start here
+
+	// prevCloseMaxSessions bounds the walk back when a symbol did not trade the previous session.
+	prevCloseMaxSessions = 3
+)
+
+// premarketMetric is one symbol's picture before open_time: the previous session's close and
+// the 04:00 → open tape.
+type premarketMetric struct {
+	PrevClose       float64
+	PrevCloseDateNY string
+	High            float64
+	Low             float64
+	Vol             float64
+	Last            float64 // last premarket close (0 = no premarket trading)
+}
+
+// gapPct is the last premarket price against the previous close (0 when either is unknown).
+func (m premarketMetric) gapPct() float64 {
+	if m.PrevClose <= 0 || m.Last <= 0 {
+		return 0
+	}
+	return m.Last/m.PrevClose - 1
+}
+
+func applyPremarket(t *store.TickerState, m premarketMetric) {
+	t.PrevClose = m.PrevClose
+	t.PrevCloseDateNY = m.PrevCloseDateNY
+	t.PremarketHigh = m.High
+	t.PremarketLow = m.Low
+	t.PremarketVol = m.Vol
+	t.PremarketLast = m.Last
+	t.GapPct = m.gapPct()
+}
+
+// premarketOK applies gap_pct_min/max and premarket_vol_min. Each bound is off at 0; a ticker
+// whose gap is unknown (no previous close or no premarket print) fails an active gap bound.
+func premarketOK(t *store.TickerState, f store.RuntimeFilters) bool {
+	if f.GapPctMin != 0 || f.GapPctMax != 0 {
+		if t.PrevClose <= 0 || t.PremarketLast <= 0 {
+			return false
+		}
+		if f.GapPctMin != 0 && t.GapPct < f.GapPctMin {
+			return false
+		}
+		if f.GapPctMax != 0 && t.GapPct > f.GapPctMax {
+			return false
+		}
+	}
+	return f.PremarketVolMin <= 0 || t.PremarketVol >= f.PremarketVolMin
+}
+
+// premarketFiltersOn reports whether any gap / premarket volume bound is set.
+func premarketFiltersOn(f store.RuntimeFilters) bool {
+	return f.GapPctMin != 0 || f.GapPctMax != 0 || f.PremarketVolMin > 0
+}
+
+// prevCloses is the previous session's close for syms: one grouped daily request per session for
+// the whole list, walking back up to prevCloseMaxSessions for symbols that did not trade. Without
+// grouped dailies (or when the first request fails) every symbol falls back to prevCloseMetric.
+func (e *Engine) prevCloses(ctx context.Context, syms []string, openNY time.Time) map[string]premarketMetric {
+	out := make(map[string]premarketMetric, len(syms))
+	if ref, ok := e.md.(marketdata.Reference); ok {
+		want := make(map[string]bool, len(syms))
+		for _, sym := range syms {
+			want[sym] = true
+		}
+		d := dateOnlyInLoc(openNY, e.loc)
+		for i := 0; i < prevCloseMaxSessions && len(out) < len(want); i++ {
+			d = calendar.PrevTradingDay(d)
+			bars, err := ref.GroupedDaily(ctx, d)
+			if err != nil {
+				if i == 0 {
+					break // fall back below
+				}
+				return out
+			}
+			for _, b := range bars {
+				sym := strings.ToUpper(strings.TrimSpace(b.Symbol))
+				if _, done := out[sym]; done || !want[sym] || b.Close <= 0 {
+					continue
+				}
+				out[sym] = premarketMetric{PrevClose: b.Close, PrevCloseDateNY: d.Format("2006-01-02")}
+			}
+			if i == prevCloseMaxSessions-1 || len(out) == len(want) {
+				return out
+			}
+		}
+		if ctx.Err() != nil {
+			return out
+		}
+	}
+
+	return e.fetchPremarket(ctx, syms, func(sym string) (premarketMetric, error) {
+		return e.prevCloseMetric(ctx, sym, openNY)
+	})
+}
+
+// prevCloseMetric is prevCloses' fallback for one symbol: the last regular-session minute bar
+// (13:00 on early-close days) of the previous sessions.
+func (e *Engine) prevCloseMetric(ctx context.Context, sym string, openNY time.Time) (premarketMetric, error) {
+	var m premarketMetric
+
+	d := dateOnlyInLoc(openNY, e.loc)
+	for i := 0; i < prevCloseMaxSessions && m.PrevClose <= 0; i++ {
+		if err := ctx.Err(); err != nil {
+			return m, err
+		}
+		d = calendar.PrevTradingDay(d)
+		bars, err := e.md.MinuteBars(ctx, sym, atTime(d, e.cfg.Market.OpenTime, e.loc), atTime(d, calendar.CloseHMS(d), e.loc))
+		if err != nil {
+			return m, err
+		}
+		for i := len(bars) - 1; i >= 0; i-- {
+			if bars[i].Close > 0 {
+				m.PrevClose = bars[i].Close
+				m.PrevCloseDateNY = d.Format("2006-01-02")
+				break
+			}
+		}
+	}
+	if m.PrevClose <= 0 {
+		return m, fmt.Errorf("no previous close in the last %d sessions", prevCloseMaxSessions)
+	}
+	return m, nil
+}
+
+// premarketBars adds the 04:00 → endNY minute bars of openNY's day to m.
+func (e *Engine) premarketBars(ctx context.Context, sym string, openNY, endNY time.Time, m premarketMetric) (premarketMetric, error) {
+	startNY := atTime(openNY, premarketStartHMS, e.loc)
+	if !endNY.After(startNY) {
+		return m, nil
+	}
+	bars, err := e.md.MinuteBars(ctx, sym, startNY, endNY)
+	if err != nil {
+		return m, err
+	}
+	for _, b := range bars {
+		if b.Close <= 0 {
+			continue
+		}
+		if m.High == 0 || b.High > m.High {
+			m.High = b.High
+		}
+		if m.Low == 0 || (b.Low > 0 && b.Low < m.Low) {
+			m.Low = b.Low
+		}
+		m.Vol += b.Volume
+		m.Last = b.Close
+	}
+	return m, nil
+}
+
+// fetchPremarketMetrics is the previous close and the 04:00 → endNY bars of openNY's day for syms.
+// Symbols without a previous close are left out.
+func (e *Engine) fetchPremarketMetrics(ctx context.Context, syms []string, openNY, endNY time.Time) map[string]premarketMetric {
+	return e.premarketWindow(ctx, e.prevCloses(ctx, syms, openNY), openNY, endNY)
+}
+
+// premarketWindow adds the 04:00 → endNY minute bars to each previous close in prev
+// (one minute-bar request per symbol, on the worker pool).
+func (e *Engine) premarketWindow(ctx context.Context, prev map[string]premarketMetric, openNY, endNY time.Time) map[string]premarketMetric {
+	syms := make([]string, 0, len(prev))
+	for sym := range prev {
+		syms = append(syms, sym)
+	}
+	sort.Strings(syms)
+	return e.fetchPremarket(ctx, syms, func(sym string) (premarketMetric, error) {
+		return e.premarketBars(ctx, sym, openNY, endNY, prev[sym])
+	})
+}
+
+// fetchPremarket runs fetch for syms on the worker pool; failures and symbols without a previous
+// close are left out.
+func (e *Engine) fetchPremarket(ctx context.Context, syms []string, fetch func(sym string) (premarketMetric, error)) map[string]premarketMetric {
+	type res struct {
+		sym string
+		m   premarketMetric
+		err error
+	}
+
+	jobs := make(chan string)
+	results := make(chan res)
+
+	workerN := e.cfg.History.MaxWorkers
+	if workerN < 1 {
+		workerN = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerN; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range jobs {
+				m, err := fetch(sym)
+				results <- res{sym: sym, m: m, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, sym := range syms {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- sym:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make(map[string]premarketMetric, len(syms))
+	for r := range results {
+		if r.err != nil || r.m.PrevClose <= 0 {
+			continue
+		}
+		out[r.sym] = r.m
+	}
+	return out
+}
+
+// collectPremarket fills the watchlist's premarket metrics (04:00 → open) in the store, ahead
+// of the 09:35 selection. Skipped while gap_pct_min/max and premarket_vol_min are all off, so the
+// prev close / gap / premarket volume columns are only filled when one of them is set.
+func (e *Engine) collectPremarket(ctx context.Context, openNY time.Time) {
+	if !premarketFiltersOn(e.st.Filters()) {
+		return
+	}
+	wl := e.st.Watchlist()
+	metrics := e.fetchPremarketMetrics(ctx, wl, openNY, openNY)
+	for sym, m := range metrics {
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			applyPremarket(t, m)
+		})
+	}
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Premarket: previous close + 04:00–%s metrics for %d/%d tickers.",
+		openNY.Format("15:04"), len(metrics), len(wl)), "", "info")
+}
+
+// collectPremarketLive is collectPremarket for the realtime session. Run starts it before the
+// open: previous closes are fetched while waiting, the 04:00 → open bars once the open is reached.
+func (e *Engine) collectPremarketLive(ctx context.Context, openNY time.Time) {
+	wl := e.st.Watchlist()
+	prev := e.prevCloses(ctx, wl, openNY)
+
+	if d := time.Until(openNY); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	metrics := e.premarketWindow(ctx, prev, openNY, openNY)
+	if ctx.Err() != nil {
+		return
+	}
+	for sym, m := range metrics {
+		e.st.UpsertTicker(sym, func(t *store.TickerState) {
+			applyPremarket(t, m)
+		})
+	}
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Premarket: previous close + 04:00–%s metrics for %d/%d tickers.",
+		openNY.Format("15:04"), len(metrics), len(wl)), "", "info")
+}
+
+// dropPremarketFilters turns the gap / premarket volume bounds off when their metrics are not in
+// by 09:35: selection and entries run without them instead of rejecting every ticker.
+func (e *Engine) dropPremarketFilters() {
+	_, _ = e.st.UpdateFilters(func(f *store.RuntimeFilters) error {
+		f.GapPctMin, f.GapPctMax, f.PremarketVolMin = 0, 0, 0
+		return nil
+	})
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", "Premarket metrics not ready at 09:35: gap_pct_min/max and premarket_vol_min are off for this session.", "", "warn")
+}
+
+// PremarketRow is one watchlist symbol in the pre-open scan.
+type PremarketRow struct {
+	Symbol          string  `json:"symbol"`
+	PrevClose       float64 `json:"prev_close"`
+	PrevCloseDateNY string  `json:"prev_close_date_ny"`
+	PremarketLast   float64 `json:"premarket_last"`
+	PremarketHigh   float64 `json:"premarket_high"`
+	PremarketLow    float64 `json:"premarket_low"`
+	PremarketVol    float64 `json:"premarket_vol"`
+	GapPct          float64 `json:"gap_pct"`
+	Pass            bool    `json:"pass"` // passes the current gap_pct / premarket_vol filters
+}
+
+// PremarketScan is the watchlist's premarket picture for one day, largest gaps first.
+type PremarketScan struct {
+	DateNY  string `json:"date_ny"`
+	AsOfNY  string `json:"as_of_ny"` // end of the premarket window used (now, or open_time)
+	Filters struct {
+		GapPctMin       float64 `json:"gap_pct_min"`
+		GapPctMax       float64 `json:"gap_pct_max"`
+		PremarketVolMin float64 `json:"premarket_vol_min"`
+	} `json:"filters"`
+	Symbols int            `json:"symbols"` // watchlist size
+	Missing []string       `json:"missing,omitempty"`
+	Rows    []PremarketRow `json:"rows"`
+}
+
+// ScanPremarket scans the watchlist's premarket for dayNY: through now while today is still
+// before open_time, through open_time otherwise. Future days are an error.
+func (e *Engine) ScanPremarket(ctx context.Context, dayNY time.Time) (*PremarketScan, error) {
+	nowNY := time.Now().In(e.loc)
+	openNY := atTime(dayNY, e.cfg.Market.OpenTime, e.loc)
+	endNY := openNY
+	if dateOnlyInLoc(dayNY, e.loc).After(dateOnlyInLoc(nowNY, e.loc)) {
+		return nil, errors.New("date is in the future")
+	}
+	if !calendar.IsTradingDay(dayNY.In(e.loc)) {
+		return nil, fmt.Errorf("market closed on %s (%s)", dateOnlyInLoc(dayNY, e.loc).Format("2006-01-02"), calendar.Describe(dayNY.In(e.loc)))
+	}
+	if nowNY.Before(openNY) {
+		endNY = nowNY
+	}
+
+	f := e.st.Filters()
+	wl := e.st.Watchlist()
+	metrics := e.fetchPremarketMetrics(ctx, wl, openNY, endNY)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	scan := &PremarketScan{
+		DateNY:  dateOnlyInLoc(dayNY, e.loc).Format("2006-01-02"),
+		AsOfNY:  endNY.Format("15:04:05"),
+		Symbols: len(wl),
+		Rows:    make([]PremarketRow, 0, len(metrics)),
+	}
+	scan.Filters.GapPctMin = f.GapPctMin
+	scan.Filters.GapPctMax = f.GapPctMax
+	scan.Filters.PremarketVolMin = f.PremarketVolMin
+
+	for _, sym := range wl {
+		m, ok := metrics[sym]
+		if !ok {
+			scan.Missing = append(scan.Missing, sym)
+			continue
+		}
+		var t store.TickerState
+		applyPremarket(&t, m)
+		scan.Rows = append(scan.Rows, PremarketRow{
+			Symbol:          sym,
+			PrevClose:       m.PrevClose,
+			PrevCloseDateNY: m.PrevCloseDateNY,
+			PremarketLast:   m.Last,
+			PremarketHigh:   m.High,
+			PremarketLow:    m.Low,
+			PremarketVol:    m.Vol,
+			GapPct:          t.GapPct,
+			Pass:            premarketOK(&t, f),
+		})
+	}
+	sort.SliceStable(scan.Rows, func(i, j int) bool {
+		return math.Abs(scan.Rows[i].GapPct) > math.Abs(scan.Rows[j].GapPct)
+	})
+	return scan, nil
+}
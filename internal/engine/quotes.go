@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"massive-orb/internal/broker"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/store"
+)
+
+// applyQuote keeps the ticker's NBBO current. One-sided, empty and crossed quotes are ignored.
+func applyQuote(t *store.TickerState, q marketdata.Quote, qNY time.Time) {
+	if q.BidPrice <= 0 || q.AskPrice <= 0 || q.AskPrice < q.BidPrice {
+		return
+	}
+	t.Bid, t.Ask = q.BidPrice, q.AskPrice
+	t.BidSize, t.AskSize = q.BidSize, q.AskSize
+	t.SpreadPct = (q.AskPrice - q.BidPrice) / ((q.AskPrice + q.BidPrice) / 2)
+	t.QuoteTime = qNY
+}
+
+// quoteFillPrice is where a marketable order would fill against the current quote:
+// the ask for a buy, the bid for a sell (0 without a quote).
+func quoteFillPrice(t *store.TickerState, buy bool) float64 {
+	if t.Bid <= 0 || t.Ask <= 0 {
+		return 0
+	}
+	if buy {
+		return t.Ask
+	}
+	return t.Bid
+}
+
+// spreadOK is the max_spread_pct entry filter. Without a quote it passes: live tickers get their
+// first one right after 09:35 (replays and sweeps refuse the setting, see replayQuotesErr).
+func spreadOK(t *store.TickerState, maxPct float64) bool {
+	return maxPct <= 0 || t.Ask <= 0 || t.SpreadPct <= maxPct
+}
+
+// replayQuotesErr rejects the quote-driven settings for runs without NBBO (REST replays, ranges,
+// sweeps, walk-forward): there they would silently do nothing and the report would not say so.
+func replayQuotesErr(p SimParams) error {
+	var on []string
+	if p.Filters.MaxSpreadPct > 0 {
+		on = append(on, "max_spread_pct")
+	}
+	if p.Costs.SlippageMode == SlippageQuote {
+		on = append(on, "slippage_mode quote")
+	}
+	if len(on) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s needs live quotes; replays and sweeps have none (set max_spread_pct to 0 and slippage_mode to spread)", strings.Join(on, " and "))
+}
+
+// onQuote updates the live ticker, every shadow copy and a quote-aware paper broker.
+func (e *Engine) onQuote(q marketdata.Quote) {
+	if q.Symbol == "" {
+		return
+	}
+	qNY := time.UnixMilli(q.Timestamp).In(e.loc)
+	if e.st.GetTicker(q.Symbol) != nil {
+		e.st.UpsertTicker(q.Symbol, func(t *store.TickerState) {
+			applyQuote(t, q, qNY)
+		})
+	}
+	e.shadowQuote(q, qNY)
+
+	if e.routeOrders() {
+		if f, ok := e.broker.(broker.QuoteFeed); ok {
+			f.OnQuote(q.Symbol, qNY, q.BidPrice, q.AskPrice)
+		}
+	}
+}
+
+// runQuotes streams NBBO for the tracked tickers until ctx ends, resubscribing with backoff
+// when the stream drops (quotes need no backfill: only the latest one matters). Quotes are
+// optional: if they cannot be subscribed at all the session runs on trades alone.
+func (e *Engine) runQuotes(ctx context.Context, syms []string, until time.Time) {
+	var qs marketdata.QuoteStream
+	dial := func() error {
+		s, err := e.md.StreamQuotes(ctx, syms)
+		if err != nil {
+			return err
+		}
+		qs = s
+		return nil
+	}
+	if err := e.redial(ctx, "quotes", until, dial); err != nil {
+		if ctx.Err() == nil {
+			e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Quotes unavailable (%v): spread filter and quoted fills are off.", err), "", "warn")
+		}
+		return
+	}
+	defer func() {
+		if qs != nil {
+			qs.Close()
+		}
+	}()
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Streaming NBBO quotes for %d tickers.", len(syms)), "", "info")
+
+	for {
+		var cause error
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-qs.Err():
+			if err == nil || isStreamNotice(err) {
+				continue
+			}
+			cause = err
+		case q, ok := <-qs.Quotes():
+			if ok {
+				e.onQuote(q)
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			cause = errStreamClosed
+		}
+
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("WS quotes: disconnected (%v), resubscribing…", cause), "", "warn")
+		qs.Close()
+		qs = nil
+		if err := e.redial(ctx, "quotes", until, dial); err != nil {
+			return
+		}
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "WS quotes: resubscribed.", "", "info")
+	}
+}
@@ -0,0 +1,263 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/config"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/store"
+)
+
+// Rebound entries (rebound.entry).
+const (
+	ReboundVWAPReclaim = "vwap_reclaim" // first 1-minute close back above the session VWAP after the scan
+	ReboundBounce      = "bounce"       // first 1-minute close crossing up through low so far * (1 + bounce_pct)
+)
+
+// reboundRule is the rebound backtest run on the sold-off names (config rebound section).
+type reboundRule struct {
+	Entry          string
+	BouncePct      float64
+	TakeProfitPct  float64
+	StopLossPct    float64
+	EntryCutoffHMS string
+}
+
+func reboundRuleFromConfig(cfg config.Config) reboundRule {
+	r := cfg.Rebound
+	return reboundRule{
+		Entry:          r.Entry,
+		BouncePct:      r.BouncePct,
+		TakeProfitPct:  r.TakeProfitPct,
+		StopLossPct:    r.StopLossPct,
+		EntryCutoffHMS: r.EntryCutoffTime,
+	}
+}
+
+// describe is the rule as shown in reports, e.g.
+// "VWAP reclaim after 10:30 · TP 5.00% / SL 3.00% · entries until 15:00, flat at 16:00".
+func (r reboundRule) describe(scanNY, cutoffNY, closeNY time.Time) string {
+	entry := "VWAP reclaim"
+	if r.Entry == ReboundBounce {
+		entry = fmt.Sprintf("%.1f%% bounce off the low", r.BouncePct*100)
+	}
+	return fmt.Sprintf("%s after %s · TP %.2f%% / SL %.2f%% · entries until %s, flat at %s",
+		entry, scanNY.Format("15:04"), r.TakeProfitPct*100, r.StopLossPct*100, cutoffNY.Format("15:04"), closeNY.Format("15:04"))
+}
+
+// backtestRebound runs the rebound rule on the names sold off by scanNY, from the day's 1-minute
+// bars, sized and costed like the ORB trades. Nothing is written to the store. While the session
+// is still open it only returns the rule with a note.
+func (e *Engine) backtestRebound(ctx context.Context, dayNY, openNY, scanNY time.Time, soldOff []store.HistoricSoldOff) *store.HistoricRebound {
+	rule := reboundRuleFromConfig(e.cfg)
+	day := dateOnlyInLoc(dayNY, e.loc)
+	closeNY := atTime(day, calendar.CloseHMS(day), e.loc)
+	cutoffNY := minTime(atTime(day, rule.EntryCutoffHMS, e.loc), closeNY)
+
+	p := e.simParams()
+	p.TakeProfitPct = rule.TakeProfitPct
+	p.StopLossPct = rule.StopLossPct
+	p.Exits = Exits{}
+
+	rb := &store.HistoricRebound{
+		Rule:   rule.describe(scanNY, cutoffNY, closeNY),
+		Trades: []store.HistoricTrade{},
+	}
+	fill := func() {
+		rb.Summary = summarizeTrades(rb.Trades)
+		rb.Summary.DateNY = day.Format("2006-01-02")
+		rb.Summary.WindowStartNY = scanNY.Format("15:04:05")
+		rb.Summary.WindowEndNY = closeNY.Format("15:04:05")
+		rb.Summary.Sizing = p.Sizing.String()
+		rb.Summary.CostModel = p.Costs.String()
+		rb.Summary.Candidates = len(soldOff)
+		rb.Summary.NoEntry = len(soldOff) - len(rb.Trades)
+	}
+
+	if len(soldOff) == 0 {
+		fill()
+		return rb
+	}
+	if time.Now().In(e.loc).Before(closeNY) {
+		rb.Note = fmt.Sprintf("Session still open: the rebound backtest needs bars through %s.", closeNY.Format("15:04"))
+		fill()
+		return rb
+	}
+
+	type res struct {
+		tr  store.HistoricTrade
+		ok  bool
+		err error
+	}
+
+	jobs := make(chan string)
+	results := make(chan res)
+
+	workerN := e.cfg.History.MaxWorkers
+	if workerN < 1 {
+		workerN = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerN; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range jobs {
+				bars, err := e.md.MinuteBars(ctx, sym, openNY, closeNY)
+				if err != nil {
+					results <- res{err: err}
+					continue
+				}
+				tr, ok := simulateRebound(sym, bars, rule, p, openNY, scanNY, cutoffNY, closeNY, e.loc)
+				results <- res{tr: tr, ok: ok}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, so := range soldOff {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- so.Symbol:
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	failed := 0
+	for r := range results {
+		if r.err != nil {
+			failed++
+			continue
+		}
+		if r.ok {
+			rb.Trades = append(rb.Trades, r.tr)
+		}
+	}
+	if failed > 0 {
+		rb.Note = fmt.Sprintf("Bars unavailable for %d of %d names (counted as no entry).", failed, len(soldOff))
+	}
+
+	sort.Slice(rb.Trades, func(i, j int) bool {
+		return rb.Trades[i].RealizedPnLPct > rb.Trades[j].RealizedPnLPct
+	})
+	fill()
+
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Rebound backtest: %d/%d sold-off names entered, net P&L %.2f.",
+		len(rb.Trades), len(soldOff), rb.Summary.NetPnL), "", "info")
+	return rb
+}
+
+// simulateRebound replays one name's 1-minute bars (oldest first) under the rebound rule.
+// VWAP runs from the open on each bar's VWAP (typical price when missing) × volume. Entry is the
+// trigger bar's close, on a bar starting in [scanNY, cutoffNY); from the next bar on, a bar whose
+// low reaches the stop exits there (at its open if it gapped through) before its high is checked
+// against the target; whatever is left is sold at the last close before closeNY. MFE/MAE and the
+// hold price run to closeNY.
+func simulateRebound(sym string, bars []marketdata.Bar, r reboundRule, p SimParams, openNY, scanNY, cutoffNY, closeNY time.Time, loc *time.Location) (store.HistoricTrade, bool) {
+	t := store.TickerState{Symbol: sym}
+	var cumPV, cumV, low float64
+	prevAbove := false
+	prevBelowBounce := false // last close under the bounce level: the bounce has to happen after the scan
+
+	for _, b := range bars {
+		if !b.Start.Before(closeNY) {
+			break
+		}
+		if b.Close <= 0 {
+			continue
+		}
+		px := b.VWAP
+		if px <= 0 {
+			px = (b.High + b.Low + b.Close) / 3
+		}
+		cumPV += px * b.Volume
+		cumV += b.Volume
+		vwap := b.Close
+		if cumV > 0 {
+			vwap = cumPV / cumV
+		}
+
+		if t.HasPosition {
+			if b.High > t.MaxPriceSinceEntry {
+				t.MaxPriceSinceEntry = b.High
+				t.MaxPriceSinceEntryTime = b.Start
+			}
+			if b.Low > 0 && b.Low < t.MinPriceSinceEntry {
+				t.MinPriceSinceEntry = b.Low
+				t.MinPriceSinceEntryTime = b.Start
+			}
+			t.LastPrice = b.Close
+			if t.Exited {
+				continue
+			}
+			switch {
+			case b.Low > 0 && b.Low <= t.StopPrice:
+				exitPosition(&t, openNY, b.Start, ExitStop, min(b.Open, t.StopPrice))
+			case b.High >= t.TakeProfitPrice:
+				exitPosition(&t, openNY, b.Start, ExitProfit, max(b.Open, t.TakeProfitPrice))
+			}
+			continue
+		}
+
+		if b.Low > 0 && (low == 0 || b.Low < low) {
+			low = b.Low
+		}
+		above := b.Close > vwap
+		belowBounce := low > 0 && b.Close < low*(1.0+r.BouncePct)
+		if !b.Start.Before(scanNY) && b.Start.Before(cutoffNY) {
+			trigger := false
+			switch r.Entry {
+			case ReboundBounce:
+				trigger = low > 0 && !belowBounce && prevBelowBounce
+			default:
+				trigger = above && !prevAbove
+			}
+			if trigger {
+				if enterPosition(&t, p, openNY, b.Start.Add(time.Minute), b.Close, SideLong, 0) {
+					t.LastPrice = b.Close
+				}
+			}
+		}
+		prevAbove = above
+		prevBelowBounce = belowBounce
+	}
+
+	if t.HasPosition && !t.Exited {
+		exitPosition(&t, openNY, closeNY, ExitTime, t.LastPrice)
+	}
+	return historicTradeFromState(t, closeNY, loc, p.Costs)
+}
+
+// buildReboundRange aggregates the sessions' rebound backtests for a range report (nil when none ran).
+func buildReboundRange(fromISO, toISO string, sessions []*store.HistoricRebound) *store.HistoricRebound {
+	if len(sessions) == 0 {
+		return nil
+	}
+	rb := &store.HistoricRebound{Rule: sessions[0].Rule, Trades: []store.HistoricTrade{}}
+	for _, s := range sessions {
+		rb.Trades = append(rb.Trades, s.Trades...)
+	}
+	rb.Summary = summarizeTrades(rb.Trades)
+	rb.Summary.DateNY = fromISO + " → " + toISO
+	rb.Summary.WindowStartNY = sessions[0].Summary.WindowStartNY
+	rb.Summary.WindowEndNY = sessions[0].Summary.WindowEndNY
+	rb.Summary.Sizing = sessions[0].Summary.Sizing
+	rb.Summary.CostModel = sessions[0].Summary.CostModel
+	for _, s := range sessions {
+		rb.Summary.Candidates += s.Summary.Candidates
+		rb.Summary.NoEntry += s.Summary.NoEntry
+	}
+	return rb
+}
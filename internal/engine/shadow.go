@@ -0,0 +1,342 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"massive-orb/internal/config"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/reportdb"
+	"massive-orb/internal/store"
+)
+
+// shadowProfile re-runs the live strategy under another filters/risk set on the live trade
+// stream, silently: its own TickerState copies and positions, no events, audio or orders.
+type shadowProfile struct {
+	name string
+	p    SimParams
+
+	// guarded by Engine.shadowMu
+	syms    []string
+	tickers map[string]*store.TickerState
+	final   *store.HistoricReport // end-of-day report (set at force exit)
+}
+
+// ProfileReport is one realtime profile's session so far, or its end-of-day report once Final.
+type ProfileReport struct {
+	Name   string               `json:"name"`
+	Live   bool                 `json:"live"`
+	Params SimParams            `json:"params"`
+	Final  bool                 `json:"final"`
+	Report store.HistoricReport `json:"report"`
+}
+
+// shadowParams layers a profile's overrides on top of the live params.
+// Filter keys go through JSON so they match /api/filters exactly (unknown keys are errors).
+func shadowParams(base SimParams, sp config.ShadowProfile) (SimParams, error) {
+	p := base
+	if len(sp.Filters) > 0 {
+		b, err := json.Marshal(sp.Filters)
+		if err != nil {
+			return p, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p.Filters); err != nil {
+			return p, fmt.Errorf("filters: %w", err)
+		}
+		if err := p.Filters.Validate(); err != nil {
+			return p, err
+		}
+	}
+	if sp.Risk.TakeProfitPct > 0 {
+		p.TakeProfitPct = sp.Risk.TakeProfitPct
+	}
+	if sp.Risk.StopLossPct > 0 {
+		p.StopLossPct = sp.Risk.StopLossPct
+	}
+	return p, nil
+}
+
+// selectShadows builds the configured profiles on top of the live params at 09:35 and picks
+// each one's tickers with its own open-5m filters. Must run before SetTrackedTickers
+// (it reads the watchlist's open-5m metrics from the store).
+func (e *Engine) selectShadows() []*shadowProfile {
+	if len(e.cfg.ShadowProfiles) == 0 {
+		return nil
+	}
+	base := e.simParams()
+	sess := e.session()
+	nowNY := time.Now().In(e.loc)
+
+	out := make([]*shadowProfile, 0, len(e.cfg.ShadowProfiles))
+	for _, sp := range e.cfg.ShadowProfiles {
+		p, err := shadowParams(base, sp)
+		if err != nil {
+			e.emit(nowNY, "SYSTEM", "", fmt.Sprintf("Shadow profile %q skipped: %v", sp.Name, err), "", "warn")
+			continue
+		}
+		sh := &shadowProfile{name: sp.Name, p: p, tickers: make(map[string]*store.TickerState)}
+
+		f := p.Filters
+		for _, sym := range e.st.Watchlist() {
+			t := e.st.GetTicker(sym)
+			if t == nil || t.Open0930 <= 0 || t.ORHigh <= 0 || t.ORLow <= 0 {
+				continue
+			}
+			rng := (t.ORHigh - t.ORLow) / t.Open0930
+			if rng < f.Open5mRangePctMin || rng > f.Open5mRangePctMax ||
+				t.Open5mVol < f.Open5mVolMin || t.Open5mVol > f.Open5mVolMax || !premarketOK(t, f) {
+				continue
+			}
+			t.Open5mRangePct = rng
+			t.Status = "tracking"
+			p.strategy().OnOpenRange(t, p, sess)
+			sh.syms = append(sh.syms, sym)
+			sh.tickers[sym] = t
+		}
+		sort.Strings(sh.syms)
+		out = append(out, sh)
+	}
+	return out
+}
+
+// startShadows prepares the profiles in the background so the live trade feed does not wait on
+// their REST seeding; shadowTrade buffers prints until prepareShadows hands the profiles over.
+func (e *Engine) startShadows(ctx context.Context, openNY, selNY time.Time, shadows []*shadowProfile, tracked map[string]*store.TickerState) {
+	e.shadowMu.Lock()
+	e.shadows = nil
+	e.liveFinal = nil
+	e.shadowSeeding = len(shadows) > 0
+	e.shadowBuf = nil
+	e.shadowMu.Unlock()
+
+	if len(shadows) == 0 {
+		return
+	}
+	go e.prepareShadows(ctx, openNY, selNY, shadows, tracked)
+}
+
+// prepareShadows fills the shadow states' open-5m history (reusing the live tracked values,
+// fetching the rest once) and seeds each profile's copies from open → 09:35 under its own params.
+func (e *Engine) prepareShadows(ctx context.Context, openNY, selNY time.Time, shadows []*shadowProfile, tracked map[string]*store.TickerState) {
+	need := make(map[string]*store.TickerState)
+	for _, sh := range shadows {
+		for sym, t := range sh.tickers {
+			if lt := tracked[sym]; lt != nil {
+				t.Prev10AvgOpen5mVol, t.Open5mTodayPct = lt.Prev10AvgOpen5mVol, lt.Open5mTodayPct
+				continue
+			}
+			if need[sym] == nil {
+				cp := *t
+				need[sym] = &cp
+			}
+		}
+	}
+	e.applyOpen5mHistory(ctx, openNY, need)
+
+	sess := e.session()
+	for _, sh := range shadows {
+		for sym, t := range sh.tickers {
+			if n := need[sym]; n != nil {
+				t.Prev10AvgOpen5mVol, t.Open5mTodayPct = n.Prev10AvgOpen5mVol, n.Open5mTodayPct
+			}
+			sh.p.strategy().OnSelection(t, sh.p, sess)
+		}
+		e.seedStates(ctx, openNY, selNY, sh.syms, sh.tickers, sh.p)
+	}
+
+	e.shadowMu.Lock()
+	if ctx.Err() != nil || !e.shadowSeeding { // cancelled, or the session closed first (finishProfiles)
+		e.shadowSeeding, e.shadowBuf = false, nil
+		e.shadowMu.Unlock()
+		return
+	}
+	e.shadows = shadows
+	e.liveFinal = nil
+	// prints that arrived while seeding, in feed order
+	for _, sp := range e.shadowBuf {
+		e.shadowTradeLocked(sess, sp.tr, sp.live)
+	}
+	e.shadowSeeding, e.shadowBuf = false, nil
+	e.shadowMu.Unlock()
+
+	if len(shadows) > 0 {
+		parts := make([]string, 0, len(shadows))
+		for _, sh := range shadows {
+			parts = append(parts, fmt.Sprintf("%s (%d)", sh.name, len(sh.syms)))
+		}
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Shadow profiles tracking: "+strings.Join(parts, ", "), "", "info")
+	}
+}
+
+// shadowSymbols is every ticker some shadow profile tracks (they join the trades subscription).
+func shadowSymbols(shadows []*shadowProfile) []string {
+	out := make([]string, 0, 16)
+	for _, sh := range shadows {
+		out = append(out, sh.syms...)
+	}
+	return out
+}
+
+// shadowPrint is a feed print held back while the profiles seed.
+type shadowPrint struct {
+	tr   marketdata.Trade
+	live bool
+}
+
+// shadowTrade steps every shadow profile's copy of the ticker through one feed print
+// (live false: backfilled after an outage; exits still fire, entries are dropped).
+func (e *Engine) shadowTrade(sess Session, tr marketdata.Trade, live bool) {
+	e.shadowMu.Lock()
+	defer e.shadowMu.Unlock()
+	if e.shadowSeeding {
+		e.shadowBuf = append(e.shadowBuf, shadowPrint{tr: tr, live: live})
+		return
+	}
+	e.shadowTradeLocked(sess, tr, live)
+}
+
+func (e *Engine) shadowTradeLocked(sess Session, tr marketdata.Trade, live bool) {
+	if len(e.shadows) == 0 || tr.Price <= 0 || tr.Size <= 0 {
+		return
+	}
+	trNY := time.UnixMilli(tr.Timestamp).In(e.loc)
+	if trNY.Before(sess.OpenNY) || trNY.After(sess.ExitNY.Add(5*time.Minute)) {
+		return
+	}
+
+	for _, sh := range e.shadows {
+		t := sh.tickers[tr.Symbol]
+		if t == nil || sh.final != nil || !e.condFilter.eligible(t, tr) {
+			continue
+		}
+		in := stepTrade(t, sh.p.strategy(), sh.p, sess, trNY, tr.Price, tr.Size, true)
+		switch in.Action {
+		case IntentEnter:
+			if !live {
+				continue // backfilled after an outage: stale signal
+			}
+			_ = enterPosition(t, sh.p, sess.OpenNY, trNY, tr.Price, in.Side, in.Stop) // false: below one share, no trade
+		case IntentExit:
+			exitPosition(t, sess.OpenNY, trNY, in.Reason, tr.Price)
+		}
+	}
+}
+
+// shadowQuote keeps the shadow copies' NBBO current (spread filter, quoted fills).
+func (e *Engine) shadowQuote(q marketdata.Quote, qNY time.Time) {
+	e.shadowMu.Lock()
+	defer e.shadowMu.Unlock()
+	for _, sh := range e.shadows {
+		if t := sh.tickers[q.Symbol]; t != nil {
+			applyQuote(t, q, qNY)
+		}
+	}
+}
+
+// finishProfiles runs after the 11am close: shadow positions go through the strategy's
+// force-exit timer, then live and every shadow get an end-of-day report (event + saved report).
+func (e *Engine) finishProfiles(tsNY time.Time) {
+	e.shadowMu.Lock()
+	defer e.shadowMu.Unlock()
+	if e.shadowSeeding {
+		e.shadowSeeding, e.shadowBuf = false, nil
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Shadow profiles were still seeding at the force exit; no profile reports this session.", "", "warn")
+		return
+	}
+	if len(e.shadows) == 0 {
+		return
+	}
+
+	sess := e.session()
+	day := dateOnlyInLoc(sess.OpenNY, e.loc)
+
+	live := e.reportFromStates(e.st.TickerStates(), e.simParams(), day, sess.OpenNY, sess.SelNY, sess.CutoffNY, tsNY)
+	e.liveFinal = &live
+	e.emitProfileSummary("live", live.Summary)
+	e.saveProfileReport("live", e.simParams(), &live)
+
+	for _, sh := range e.shadows {
+		states := make([]store.TickerState, 0, len(sh.syms))
+		for _, sym := range sh.syms {
+			t := sh.tickers[sym]
+			if t.HasPosition && !t.Exited {
+				if in := sh.p.strategy().OnTimer(t, sh.p, sess, tsNY); in.Action == IntentExit {
+					px := t.LastPrice
+					if px <= 0 {
+						px = t.EntryPrice
+					}
+					exitPosition(t, sess.OpenNY, tsNY, in.Reason, px)
+				}
+			}
+			states = append(states, *t)
+		}
+
+		rep := e.reportFromStates(states, sh.p, day, sess.OpenNY, sess.SelNY, sess.CutoffNY, tsNY)
+		sh.final = &rep
+		e.emitProfileSummary(sh.name, rep.Summary)
+		e.saveProfileReport(sh.name, sh.p, &rep)
+	}
+}
+
+func (e *Engine) emitProfileSummary(name string, s store.HistoricSummary) {
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Profile %s: %d trades · win %.0f%% · net $%.2f (gross $%.2f)",
+		name, s.TradesTaken, s.WinRate*100, s.NetPnL, s.GrossPnL), "", "info")
+}
+
+func (e *Engine) saveProfileReport(name string, p SimParams, rep *store.HistoricReport) {
+	e.saveReportWith(&reportdb.Record{
+		Meta: reportdb.Meta{
+			Kind:    reportdb.KindSession,
+			DateNY:  rep.Summary.DateNY,
+			Note:    fmt.Sprintf("Realtime profile %q", name),
+			Summary: rep.Summary,
+		},
+		Report: rep,
+	}, p)
+}
+
+// ProfileReports lists live and every shadow profile for the current realtime session:
+// trades so far (open positions valued at the last print) until 11:00, then the final reports.
+// Empty when no shadow profiles run.
+func (e *Engine) ProfileReports() []ProfileReport {
+	e.shadowMu.Lock()
+	defer e.shadowMu.Unlock()
+	if len(e.shadows) == 0 {
+		return []ProfileReport{}
+	}
+
+	sess := e.session()
+	day := dateOnlyInLoc(sess.OpenNY, e.loc)
+	endNY := minTime(time.Now().In(e.loc), sess.ExitNY)
+
+	out := make([]ProfileReport, 0, len(e.shadows)+1)
+	live := ProfileReport{Name: "live", Live: true, Params: e.simParams()}
+	if e.liveFinal != nil {
+		live.Final, live.Report = true, *e.liveFinal
+	} else {
+		live.Report = e.reportFromStates(e.st.TickerStates(), live.Params, day, sess.OpenNY, sess.SelNY, sess.CutoffNY, endNY)
+	}
+	out = append(out, live)
+
+	for _, sh := range e.shadows {
+		pr := ProfileReport{Name: sh.name, Params: sh.p}
+		if sh.final != nil {
+			pr.Final, pr.Report = true, *sh.final
+		} else {
+			states := make([]store.TickerState, 0, len(sh.syms))
+			for _, sym := range sh.syms {
+				states = append(states, *sh.tickers[sym])
+			}
+			pr.Report = e.reportFromStates(states, sh.p, day, sess.OpenNY, sess.SelNY, sess.CutoffNY, endNY)
+		}
+		out = append(out, pr)
+	}
+	return out
+}
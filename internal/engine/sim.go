@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"time"
+
+	"massive-orb/internal/store"
+)
+
+// SimParams are the knobs a session is evaluated under: the strategy, runtime filters, risk, exits, sizing and costs.
+// Live/historic runs build them from the store + config; sweeps build one per combination.
+type SimParams struct {
+	Strategy      string               `json:"strategy"`
+	Filters       store.RuntimeFilters `json:"filters"`
+	TakeProfitPct float64              `json:"take_profit_pct"`
+	StopLossPct   float64              `json:"stop_loss_pct"`
+	Sizing        Sizing               `json:"sizing"`
+	Costs         Costs                `json:"costs"`
+	Exits         Exits                `json:"exits"`
+}
+
+func (e *Engine) simParams() SimParams {
+	z := sizingFromConfig(e.cfg)
+	// live orders size equity_pct off the real account when a broker is connected
+	if e.routeOrders() {
+		if eq := e.broker.Account().Equity; eq > 0 {
+			z.AccountEquity = eq
+		}
+	}
+	return SimParams{
+		Strategy:      e.cfg.Strategy,
+		Filters:       e.st.Filters(),
+		TakeProfitPct: e.cfg.Risk.TakeProfitPct,
+		StopLossPct:   e.cfg.Risk.StopLossPct,
+		Sizing:        z,
+		Costs:         costsFromConfig(e.cfg),
+		Exits:         exitsFromConfig(e.cfg),
+	}
+}
+
+func (p SimParams) strategy() Strategy { return strategyFor(p.Strategy) }
+
+const (
+	SideLong  = "long"
+	SideShort = "short"
+	SideBoth  = "both" // RuntimeFilters.Side only: take whichever cross comes first
+)
+
+// sideDir is +1 for longs and -1 for shorts: P&L = dir * (exit - entry) * shares.
+func sideDir(side string) float64 {
+	if side == SideShort {
+		return -1
+	}
+	return 1
+}
+
+// stepTrade applies one print to t (VWAP, last price, MFE/MAE, ATR bars) and hands it to the
+// strategy, returning the intent it asks for. It never opens or closes positions itself; callers
+// apply the intent with enterPosition/exitPosition so live runs can emit events and sweeps can stay silent.
+func stepTrade(t *store.TickerState, strat Strategy, p SimParams, s Session, trNY time.Time, price, size float64, allowActions bool) Intent {
+	// update VWAP & last (always, even after exit, so we can compute hold-to-cutoff + MFE/MAE)
+	t.LastTrade = trNY
+	t.MinutesAfterOpen = trNY.Sub(s.OpenNY).Seconds() / 60.0
+
+	// prev values for cross detection
+	t.PrevPrice = t.LastPrice
+	t.PrevVWAP = t.VWAP
+
+	// vwap update (trade-level)
+	t.CumPV += price * size
+	t.CumV += size
+	if t.CumV > 0 {
+		t.VWAP = t.CumPV / t.CumV
+	}
+
+	t.LastPrice = price
+
+	if p.Exits.TrailMode == TrailATR {
+		updateATR(t, trNY, price, p.Exits.ATRPeriod)
+	}
+
+	// track MFE/MAE after entry, up to whatever data we process (historic ends at cutoff)
+	if t.HasPosition && !t.EntryTime.IsZero() && !trNY.Before(t.EntryTime) {
+		if t.MaxPriceSinceEntry == 0 || price > t.MaxPriceSinceEntry {
+			t.MaxPriceSinceEntry = price
+			t.MaxPriceSinceEntryTime = trNY
+		}
+		if t.MinPriceSinceEntry == 0 || price < t.MinPriceSinceEntry {
+			t.MinPriceSinceEntry = price
+			t.MinPriceSinceEntryTime = trNY
+		}
+	}
+
+	return strat.OnTrade(t, p, s, trNY, price, size, allowActions)
+}
+
+// enterPosition opens a long or short at entry with TP/SL and size from p.
+// stop > 0 replaces the stop_loss_pct stop (e.g. the other side of the opening range).
+// False (t untouched) when sizing gives 0 shares: max_notional is below one share.
+func enterPosition(t *store.TickerState, p SimParams, openNY, tsNY time.Time, entry float64, side string, stop float64) bool {
+	dir := sideDir(side)
+	stopPx := entry * (1.0 - dir*p.StopLossPct)
+	if stop > 0 {
+		stopPx = stop
+	}
+	shares := p.Sizing.shares(entry, stopPx)
+	if shares <= 0 {
+		return false
+	}
+
+	t.HasPosition = true
+	t.Side = side
+	t.EntryPrice = entry
+	t.EntryTime = tsNY
+	t.EntryMinutesAfterOpen = tsNY.Sub(openNY).Seconds() / 60.0
+	t.TakeProfitPrice = entry * (1.0 + dir*p.TakeProfitPct)
+	t.StopPrice = stopPx
+	t.InitialStopPrice = t.StopPrice
+	t.StopKind = ExitStop
+	t.Shares = shares
+	t.EntryQuotePrice = quoteFillPrice(t, side != SideShort)
+	t.Status = "LONG"
+	if side == SideShort {
+		t.Status = "SHORT"
+	}
+
+	// initialize excursion trackers at entry
+	t.MaxPriceSinceEntry = entry
+	t.MaxPriceSinceEntryTime = tsNY
+	t.MinPriceSinceEntry = entry
+	t.MinPriceSinceEntryTime = tsNY
+	return true
+}
+
+func exitPosition(t *store.TickerState, openNY, tsNY time.Time, reason string, exitPrice float64) {
+	t.Exited = true
+	t.ExitReason = reason
+	t.ExitTime = tsNY
+	t.ExitPrice = exitPrice
+	t.ExitQuotePrice = quoteFillPrice(t, t.Side == SideShort)
+	t.ExitMinutesAfterOpen = tsNY.Sub(openNY).Seconds() / 60.0
+	t.Status = reason
+}
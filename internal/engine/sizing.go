@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"massive-orb/internal/config"
+)
+
+const (
+	SizingShares    = "shares"     // fixed share count
+	SizingNotional  = "notional"   // fixed dollars per trade
+	SizingRisk      = "risk"       // fixed dollars lost if the stop is hit
+	SizingEquityPct = "equity_pct" // fraction of account equity per trade
+
+	statusNoSize = "no size" // ticker status when max_notional left less than one share
+)
+
+// Sizing decides how many shares an entry buys. It is part of SimParams so live runs,
+// historic replays and sweeps all size trades the same way.
+type Sizing struct {
+	Mode          string  `json:"mode"`
+	Shares        int     `json:"shares,omitempty"`
+	Notional      float64 `json:"notional,omitempty"`
+	RiskDollars   float64 `json:"risk_dollars,omitempty"`
+	EquityPct     float64 `json:"equity_pct,omitempty"`
+	AccountEquity float64 `json:"account_equity,omitempty"`
+	MaxNotional   float64 `json:"max_notional,omitempty"` // cap for any mode (0 = none)
+}
+
+func sizingFromConfig(cfg config.Config) Sizing {
+	z := cfg.Sizing
+	return Sizing{
+		Mode:          z.Mode,
+		Shares:        z.Shares,
+		Notional:      z.Notional,
+		RiskDollars:   z.RiskDollars,
+		EquityPct:     z.EquityPct,
+		AccountEquity: z.AccountEquity,
+		MaxNotional:   z.MaxNotional,
+	}
+}
+
+// shares for an entry at entry with its stop at stop (either side). Sizes below one share round
+// up to 1, except when max_notional is below one share's price: then 0 (no entry).
+func (z Sizing) shares(entry, stop float64) int {
+	if entry <= 0 {
+		return 0
+	}
+
+	var n float64
+	switch z.Mode {
+	case SizingNotional:
+		n = z.Notional / entry
+	case SizingRisk:
+		if perShare := math.Abs(entry - stop); perShare > 0 {
+			n = z.RiskDollars / perShare
+		}
+	case SizingEquityPct:
+		n = z.AccountEquity * z.EquityPct / entry
+	default:
+		n = float64(z.Shares)
+	}
+
+	if n < 1 {
+		n = 1
+	}
+	if z.MaxNotional > 0 && n*entry > z.MaxNotional {
+		n = z.MaxNotional / entry
+		if n < 1 {
+			return 0
+		}
+	}
+	return int(math.Floor(n))
+}
+
+// String is the short label shown next to reports ("1000 shares", "$200 risk", ...).
+func (z Sizing) String() string {
+	var s string
+	switch z.Mode {
+	case SizingNotional:
+		s = fmt.Sprintf("$%.0f per trade", z.Notional)
+	case SizingRisk:
+		s = fmt.Sprintf("$%.0f risk per trade", z.RiskDollars)
+	case SizingEquityPct:
+		s = fmt.Sprintf("%.1f%% of $%.0f equity", z.EquityPct*100, z.AccountEquity)
+	default:
+		s = fmt.Sprintf("%d shares", z.Shares)
+	}
+	if z.MaxNotional > 0 {
+		s += fmt.Sprintf(" (max $%.0f)", z.MaxNotional)
+	}
+	return s
+}
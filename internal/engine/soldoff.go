@@ -0,0 +1,361 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/store"
+)
+
+// scanSoldOff is the “sold off by sold_off_scan_time” scan over the watchlist's open-5m metrics,
+// with bars up to scanEndNY.
+func (e *Engine) scanSoldOff(ctx context.Context, openNY, scanEndNY time.Time, openMetrics map[string]open5mMetric) ([]store.HistoricSoldOff, error) {
+	lists, err := e.scanSoldOffAt(ctx, openNY, []time.Time{scanEndNY}, openMetrics, e.st.Filters())
+	if err != nil || len(lists) == 0 {
+		return nil, err
+	}
+	return lists[0], nil
+}
+
+// attachSoldOff puts the sold-off scan (through sold_off_scan_time, or endNY if the run ended
+// earlier) and the rebound backtest on its names on rep.
+func (e *Engine) attachSoldOff(ctx context.Context, rep *store.HistoricReport, sessionDayNY, openNY, endNY time.Time, openMetrics map[string]open5mMetric) {
+	scanNY := atTime(sessionDayNY, e.st.Filters().SoldOffScanTime, e.loc)
+	scanEnd := minTime(scanNY, endNY)
+	soldOff, _ := e.scanSoldOff(ctx, openNY, scanEnd, openMetrics)
+	rep.SoldOff = soldOff
+	rep.SoldOffScanNY = scanEnd.Format("15:04:05")
+	rep.Rebound = e.backtestRebound(ctx, sessionDayNY, openNY, scanEnd, soldOff)
+}
+
+// scanSoldOffAt runs the sold-off scan at several checkpoints (ascending) in one pass: each symbol's
+// bars are fetched once up to the last checkpoint and its Open5mToday% once, and the result holds
+// one list per checkpoint, biggest drop first.
+func (e *Engine) scanSoldOffAt(ctx context.Context, openNY time.Time, checkpoints []time.Time, openMetrics map[string]open5mMetric, f store.RuntimeFilters) ([][]store.HistoricSoldOff, error) {
+	out := make([][]store.HistoricSoldOff, len(checkpoints))
+	var lastNY time.Time
+	for _, cp := range checkpoints {
+		if cp.After(lastNY) {
+			lastNY = cp
+		}
+	}
+	if lastNY.Before(openNY.Add(1 * time.Minute)) {
+		return out, nil
+	}
+
+	// Stage 0: eligible by open-5m range (cheap)
+	syms := make([]string, 0, len(openMetrics))
+	for sym, m := range openMetrics {
+		if m.Open0930 <= 0 || m.Open5mVol <= 0 {
+			continue
+		}
+		if m.RangePct < f.SoldOffOpen5mRangePctMin {
+			continue
+		}
+		syms = append(syms, sym)
+	}
+	sort.Strings(syms)
+	if len(syms) == 0 {
+		return out, nil
+	}
+
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Sold-off scan: checking %d tickers (09:30 → %s)…", len(syms), clockList(checkpoints, e.loc)), "", "info")
+
+	// Stage 1: min low + last close in [open, checkpoint) for every checkpoint, from one bar fetch
+	type low struct {
+		low     float64
+		lowTime time.Time
+		last    float64
+		drop    float64 // 0 when below sold_off_from_open_pct_min
+	}
+	type s1 struct {
+		sym  string
+		lows []low
+		hit  bool
+		err  error
+	}
+
+	jobs := make(chan string)
+	results := make(chan s1)
+
+	workerN := e.cfg.History.MaxWorkers
+	if workerN < 1 {
+		workerN = 1
+	}
+	if workerN > len(syms) {
+		workerN = len(syms)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerN; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range jobs {
+				bars, err := e.md.MinuteBars(ctx, sym, openNY, lastNY)
+				if err != nil {
+					results <- s1{sym: sym, err: err}
+					continue
+				}
+				m := openMetrics[sym]
+				r := s1{sym: sym, lows: make([]low, len(checkpoints))}
+				for i, cp := range checkpoints {
+					lo, loTime, last, ok := minLowAndLastClose(bars, cp)
+					if !ok {
+						continue
+					}
+					l := low{low: lo, lowTime: loTime, last: last}
+					if drop := (m.Open0930 - lo) / m.Open0930; drop >= f.SoldOffFromOpenPctMin {
+						l.drop = drop
+						r.hit = true
+					}
+					r.lows[i] = l
+				}
+				results <- r
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, sym := range syms {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- sym:
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	lowsBySym := make(map[string][]low, 64)
+	for r := range results {
+		if r.err != nil || !r.hit {
+			continue
+		}
+		lowsBySym[r.sym] = r.lows
+	}
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+
+	if len(lowsBySym) == 0 {
+		e.emit(time.Now().In(e.loc), "SYSTEM", "", "Sold-off scan: 0 tickers matched the drop + Open5m Range% filters.", "", "info")
+		return out, nil
+	}
+
+	// Stage 2: compute Open5mToday% for the pre-filtered set only (expensive)
+	preSyms := make([]string, 0, len(lowsBySym))
+	for sym := range lowsBySym {
+		preSyms = append(preSyms, sym)
+	}
+	sort.Strings(preSyms)
+
+	type s2 struct {
+		sym string
+		pct float64
+		err error
+	}
+	jobs2 := make(chan string)
+	results2 := make(chan s2)
+
+	workerN2 := e.cfg.History.MaxWorkers
+	if workerN2 < 1 {
+		workerN2 = 1
+	}
+	if workerN2 > len(preSyms) {
+		workerN2 = len(preSyms)
+	}
+
+	var wg2 sync.WaitGroup
+	for i := 0; i < workerN2; i++ {
+		wg2.Add(1)
+		go func() {
+			defer wg2.Done()
+			for sym := range jobs2 {
+				avg, err := e.avgPrevSessionsOpen5mVol(ctx, sym, openNY, e.cfg.History.Open5mLookbackSessions, e.cfg.History.MaxCalendarLookback)
+				if err != nil || avg <= 0 {
+					results2 <- s2{sym: sym, err: err}
+					continue
+				}
+				pct := (openMetrics[sym].Open5mVol / avg) * 100.0
+				results2 <- s2{sym: sym, pct: pct, err: nil}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs2)
+		for _, sym := range preSyms {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs2 <- sym:
+			}
+		}
+	}()
+	go func() {
+		wg2.Wait()
+		close(results2)
+	}()
+
+	for r := range results2 {
+		if r.err != nil {
+			continue
+		}
+		if r.pct < f.SoldOffOpen5mTodayPctMin {
+			continue
+		}
+		m := openMetrics[r.sym]
+		for i, l := range lowsBySym[r.sym] {
+			if l.drop <= 0 {
+				continue
+			}
+			out[i] = append(out[i], store.HistoricSoldOff{
+				Symbol:          r.sym,
+				Open0930:        m.Open0930,
+				LowPrice:        l.low,
+				LowTimeNY:       l.lowTime.In(e.loc).Format("15:04:05"),
+				PriceAtScanTime: l.last,
+				DropPct:         l.drop,
+				Open5mVol:       m.Open5mVol,
+				Open5mRangePct:  m.RangePct,
+				Open5mTodayPct:  r.pct,
+			})
+		}
+	}
+
+	n := 0
+	for _, list := range out {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].DropPct == list[j].DropPct {
+				return list[i].Symbol < list[j].Symbol
+			}
+			return list[i].DropPct > list[j].DropPct
+		})
+		n = max(n, len(list))
+	}
+
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Sold-off scan: %d tickers matched.", n), "", "info")
+	return out, nil
+}
+
+func clockList(ts []time.Time, loc *time.Location) string {
+	parts := make([]string, len(ts))
+	for i, t := range ts {
+		parts[i] = t.In(loc).Format("15:04:05")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SoldOffCheckpoint is the scan result “sold off by UntilNY”.
+type SoldOffCheckpoint struct {
+	UntilNY string                  `json:"until_ny"`
+	Pending bool                    `json:"pending,omitempty"` // later than now today: not scanned yet
+	Rows    []store.HistoricSoldOff `json:"rows"`
+	Rebound *store.HistoricRebound  `json:"rebound,omitempty"` // rebound backtest on Rows, entered after UntilNY
+}
+
+// SoldOffScan is the standalone sold-off scan of the watchlist for one day, at one or more
+// checkpoints.
+type SoldOffScan struct {
+	DateNY  string `json:"date_ny"`
+	Filters struct {
+		FromOpenPctMin    float64 `json:"sold_off_from_open_pct_min"`
+		Open5mRangePctMin float64 `json:"sold_off_open5m_range_pct_min"`
+		Open5mTodayPctMin float64 `json:"sold_off_open5m_today_pct_min"`
+	} `json:"filters"`
+	Symbols     int                 `json:"symbols"`     // watchlist size
+	WithOpen5m  int                 `json:"with_open5m"` // symbols with 09:30–09:35 bars
+	Checkpoints []SoldOffCheckpoint `json:"checkpoints"`
+}
+
+// ScanSoldOff runs the sold-off scan for dayNY without an ORB replay: open-5m metrics come from
+// REST and the store is left alone. untils are "HH:MM[:SS]" checkpoints (default: the
+// sold_off_scan_time filter); checkpoints past the close are capped at the close, and those still
+// ahead today come back pending. Each scanned checkpoint carries the rebound backtest on its names.
+func (e *Engine) ScanSoldOff(ctx context.Context, dayNY time.Time, untils []string) (*SoldOffScan, error) {
+	nowNY := time.Now().In(e.loc)
+	day := dateOnlyInLoc(dayNY, e.loc)
+	if day.After(dateOnlyInLoc(nowNY, e.loc)) {
+		return nil, errors.New("date is in the future")
+	}
+	if !calendar.IsTradingDay(day) {
+		return nil, fmt.Errorf("market closed on %s (%s)", day.Format("2006-01-02"), calendar.Describe(day))
+	}
+	sess := e.SessionFor(day)
+	if nowNY.Before(sess.SelNY) {
+		return nil, fmt.Errorf("opening 5 minutes not complete yet (selection at %s)", sess.SelNY.Format("15:04:05"))
+	}
+
+	f := e.st.Filters()
+	if len(untils) == 0 {
+		untils = []string{f.SoldOffScanTime}
+	}
+	closeNY := atTime(day, calendar.CloseHMS(day), e.loc)
+	seen := make(map[time.Time]bool, len(untils))
+	var checkpoints []time.Time
+	for _, u := range untils {
+		u = strings.TrimSpace(u)
+		if _, err := time.Parse("15:04:05", u); err != nil {
+			if _, err := time.Parse("15:04", u); err != nil {
+				return nil, fmt.Errorf("until %q invalid (HH:MM or HH:MM:SS)", u)
+			}
+		}
+		cp := minTime(atTime(day, u, e.loc), closeNY)
+		if !cp.After(sess.OpenNY) {
+			return nil, fmt.Errorf("until %q invalid (must be after open_time %s)", u, sess.OpenNY.Format("15:04:05"))
+		}
+		if !seen[cp] {
+			seen[cp] = true
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].Before(checkpoints[j]) })
+
+	wl := e.st.Watchlist()
+	metrics := e.fetchOpen5mMetrics(ctx, wl, sess.OpenNY, sess.SelNY)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	scan := &SoldOffScan{
+		DateNY:      day.Format("2006-01-02"),
+		Symbols:     len(wl),
+		WithOpen5m:  len(metrics),
+		Checkpoints: make([]SoldOffCheckpoint, len(checkpoints)),
+	}
+	scan.Filters.FromOpenPctMin = f.SoldOffFromOpenPctMin
+	scan.Filters.Open5mRangePctMin = f.SoldOffOpen5mRangePctMin
+	scan.Filters.Open5mTodayPctMin = f.SoldOffOpen5mTodayPctMin
+
+	due := make([]time.Time, 0, len(checkpoints))
+	for i, cp := range checkpoints {
+		scan.Checkpoints[i] = SoldOffCheckpoint{UntilNY: cp.Format("15:04:05"), Rows: []store.HistoricSoldOff{}}
+		if cp.After(nowNY) {
+			scan.Checkpoints[i].Pending = true
+			continue
+		}
+		due = append(due, cp)
+	}
+
+	lists, err := e.scanSoldOffAt(ctx, sess.OpenNY, due, metrics, f)
+	if err != nil {
+		return nil, err
+	}
+	for i, cp := range due {
+		if lists[i] != nil {
+			scan.Checkpoints[i].Rows = lists[i]
+		}
+		scan.Checkpoints[i].Rebound = e.backtestRebound(ctx, day, sess.OpenNY, cp, lists[i])
+	}
+	return scan, nil
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/nato"
+)
+
+// soldOffGrace keeps the minute-agg stream open past the scan time so the last bar before it
+// (published when it closes) still arrives.
+const soldOffGrace = 90 * time.Second
+
+// soldOffWatch is the realtime side of the sold-off scan (alerts.sold_off): after 09:35 the minute
+// aggs keep streaming for the whole watchlist, and a name whose open-5m passes
+// sold_off_open5m_range_pct_min / sold_off_open5m_today_pct_min gets one SOLD_OFF event the first
+// time a bar's low is sold_off_from_open_pct_min below its Open0930, until sold_off_scan_time.
+// Filters are read live, so UI changes apply to the next bar; a name stays eligible until it alerts.
+type soldOffWatch struct {
+	e               *Engine
+	openNY, untilNY time.Time
+	open            map[string]open5mMetric // watchlist open-5m, snapshot at 09:35
+
+	feed  *aggFeed
+	timer *time.Timer
+
+	mu      sync.Mutex
+	seen    map[string]bool    // alerted
+	pending map[string]bool    // lookup queued or running
+	pct     map[string]float64 // Today% once known (a failed lookup is retried on the next bar)
+
+	jobs     chan soldOffLookup // Today% lookups, history.max_workers at a time
+	jobsOnce sync.Once
+	wg       sync.WaitGroup // lookups queued or running
+}
+
+// soldOffLookup is one breaching low waiting for its Today% check.
+type soldOffLookup struct {
+	sym    string
+	m      open5mMetric
+	low    float64
+	barNY  time.Time
+	drop   float64
+	open5m bool
+}
+
+// startSoldOffWatch starts the watch; open must be taken before SetTrackedTickers replaces the
+// watchlist's ticker states. The stream subscribes in the background so tracking never waits on it.
+func (e *Engine) startSoldOffWatch(ctx context.Context, openNY, selNY, untilNY time.Time, open map[string]open5mMetric) *soldOffWatch {
+	w := &soldOffWatch{
+		e: e, openNY: openNY, untilNY: untilNY, open: open,
+		seen:    make(map[string]bool),
+		pending: make(map[string]bool),
+		pct:     make(map[string]float64),
+		jobs:    make(chan soldOffLookup, len(open)), // one pending lookup per symbol: never blocks
+	}
+	workerN := e.cfg.History.MaxWorkers
+	if workerN < 1 {
+		workerN = 1
+	}
+	for i := 0; i < workerN; i++ {
+		go w.lookups(ctx)
+	}
+
+	f := e.st.Filters()
+	n := 0
+	for _, m := range open {
+		if m.RangePct >= f.SoldOffOpen5mRangePctMin {
+			n++
+		}
+	}
+
+	// already below the bar during 09:30–09:34
+	for sym, m := range open {
+		w.check(ctx, sym, m.ORLow, selNY.Add(-time.Minute), true)
+	}
+
+	feed := e.streamAggsAsync(ctx, untilNY.Add(soldOffGrace), "bars missed meanwhile are not replayed into the sold-off watch", func(agg marketdata.Agg) {
+		start := time.UnixMilli(agg.StartTimestamp).In(e.loc)
+		if start.Before(selNY) {
+			return
+		}
+		w.check(ctx, agg.Symbol, agg.Low, start, false)
+	})
+	w.feed = feed
+	w.timer = time.AfterFunc(time.Until(untilNY.Add(soldOffGrace)), func() { feed.stop() })
+
+	e.emit(time.Now().In(e.loc), "SYSTEM", "", fmt.Sprintf("Sold-off watch: %d/%d tickers passed the open-5m range; alerting on a %.2f%% drop from the 09:30 open until %s.",
+		n, len(open), f.SoldOffFromOpenPctMin*100, untilNY.Format("15:04")), "", "info")
+	return w
+}
+
+// check handles one low for sym (barNY = the bar's start); the Today% lookup runs on the pool.
+func (w *soldOffWatch) check(ctx context.Context, sym string, low float64, barNY time.Time, open5m bool) {
+	if !barNY.Before(w.untilNY) || low <= 0 {
+		return
+	}
+	m, ok := w.open[sym]
+	if !ok {
+		return
+	}
+	f := w.e.st.Filters()
+	if m.RangePct < f.SoldOffOpen5mRangePctMin {
+		return
+	}
+	drop := (m.Open0930 - low) / m.Open0930
+	if drop < f.SoldOffFromOpenPctMin {
+		return
+	}
+
+	w.mu.Lock()
+	if w.seen[sym] || w.pending[sym] {
+		w.mu.Unlock()
+		return
+	}
+	w.pending[sym] = true
+	w.wg.Add(1)
+	w.mu.Unlock()
+
+	w.jobs <- soldOffLookup{sym: sym, m: m, low: low, barNY: barNY, drop: drop, open5m: open5m}
+}
+
+// lookups is one pool worker: Today% (cached per symbol), then the alert if it passes the live
+// filter. Only an alert marks the symbol seen, so a failed lookup or a name just under the bar
+// is checked again on its next breaching bar.
+func (w *soldOffWatch) lookups(ctx context.Context) {
+	for j := range w.jobs {
+		w.mu.Lock()
+		pct, ok := w.pct[j.sym]
+		w.mu.Unlock()
+		if !ok {
+			pct, ok = w.todayPct(ctx, j.sym, j.m)
+		}
+
+		alert := ok && pct >= w.e.st.Filters().SoldOffOpen5mTodayPctMin
+		w.mu.Lock()
+		if ok {
+			w.pct[j.sym] = pct
+		}
+		w.seen[j.sym] = alert
+		delete(w.pending, j.sym)
+		w.mu.Unlock()
+
+		if alert {
+			w.alert(j.sym, j.m, j.low, j.barNY, j.drop, pct, j.open5m)
+		}
+		w.wg.Done()
+	}
+}
+
+// todayPct is the open-5m volume vs the previous sessions' average: from the tracked state when
+// the name was selected at 09:35, from REST otherwise.
+func (w *soldOffWatch) todayPct(ctx context.Context, sym string, m open5mMetric) (float64, bool) {
+	e := w.e
+	if t := e.st.GetTicker(sym); t != nil && t.Prev10AvgOpen5mVol > 0 {
+		return t.Open5mTodayPct, true
+	}
+	avg, err := e.avgPrevSessionsOpen5mVol(ctx, sym, w.openNY, e.cfg.History.Open5mLookbackSessions, e.cfg.History.MaxCalendarLookback)
+	if err != nil || avg <= 0 {
+		return 0, false
+	}
+	return (m.Open5mVol / avg) * 100.0, true
+}
+
+func (w *soldOffWatch) alert(sym string, m open5mMetric, low float64, barNY time.Time, drop, pct float64, open5m bool) {
+	e := w.e
+	nowNY := time.Now().In(e.loc)
+	at := barNY.Format("15:04")
+	if open5m {
+		at = "09:30–09:34"
+	}
+	msg := fmt.Sprintf("SOLD OFF %s (%s) · down %.2f%% from the 09:30 open %.2f (low %.2f at %s) · Open5m Today %.0f%%",
+		sym, nato.SpellNATO(sym), drop*100, m.Open0930, low, at, pct)
+	audioID := ""
+	if e.cfg.Alerts.SoldOffTTS {
+		audioID = e.say(nowNY, "SOLD_OFF", sym, "Sold off. "+nato.SpellNATO(sym))
+	}
+	e.emit(nowNY, "SOLD_OFF", sym, msg, audioID, "signal")
+}
+
+// stop ends the watch (early, or after the scan time) and waits for pending alerts. Safe to call twice.
+func (w *soldOffWatch) stop() {
+	w.timer.Stop()
+	w.feed.stop()
+	w.wg.Wait()
+	w.jobsOnce.Do(func() { close(w.jobs) })
+}
+
+// wait blocks until the watch is over (scan time + grace) or ctx ends.
+func (w *soldOffWatch) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-w.feed.done:
+	}
+	w.wg.Wait()
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"time"
+
+	"massive-orb/internal/config"
+	"massive-orb/internal/store"
+)
+
+// Strategy owns the entry and exit rules. The engine does the bookkeeping (VWAP, last price,
+// MFE/MAE, ATR bars), applies the intents and keeps the positions; live runs, historic
+// replays and sweeps all drive the same hooks.
+//
+// One Strategy value serves every ticker (and every sweep worker), so per-ticker state
+// belongs on the TickerState, not on the strategy.
+type Strategy interface {
+	Name() string
+
+	// OnOpenRange runs at 09:35 for each ticker whose 09:30–09:35 range passed the open-5m
+	// filters (ORHigh, ORLow, Open5mVol, Open5mRangePct set).
+	OnOpenRange(t *store.TickerState, p SimParams, s Session)
+
+	// OnSelection runs once the ticker is tracked (Open5mTodayPct set), before any of its prints.
+	OnSelection(t *store.TickerState, p SimParams, s Session)
+
+	// OnTrade runs on every print after the engine's bookkeeping. With allowActions false
+	// (open → 09:35 seed, catch-up replays) it may only update state; the intent is ignored.
+	OnTrade(t *store.TickerState, p SimParams, s Session, trNY time.Time, price, size float64, allowActions bool) Intent
+
+	// OnTimer runs at force_exit_time (or where a replay ends early) for every ticker still holding
+	// a position.
+	OnTimer(t *store.TickerState, p SimParams, s Session, nowNY time.Time) Intent
+}
+
+// Session holds the key times of the session being run.
+type Session struct {
+	OpenNY   time.Time // 09:30
+	SelNY    time.Time // 09:35 selection
+	CutoffNY time.Time // last entry
+	ExitNY   time.Time // force exit
+}
+
+type IntentAction int
+
+const (
+	IntentNone IntentAction = iota
+	IntentEnter
+	IntentExit
+)
+
+// Intent is what a hook asks the engine to do with a ticker.
+type Intent struct {
+	Action IntentAction
+	Side   string  // enter: SideLong / SideShort
+	Stop   float64 // enter: stop price (0 = risk.stop_loss_pct from the entry)
+	Reason string  // exit: ExitProfit, ExitStop, ExitTrail, ...
+}
+
+func enterIntent(side string) Intent  { return Intent{Action: IntentEnter, Side: side} }
+func exitIntent(reason string) Intent { return Intent{Action: IntentExit, Reason: reason} }
+
+// DefaultStrategy is used when config.yaml does not name one.
+const DefaultStrategy = "orb"
+
+var strategies = map[string]Strategy{
+	"orb": orbStrategy{},
+}
+
+// config validates strategy against this registry
+func init() {
+	for name := range strategies {
+		config.RegisterStrategy(name)
+	}
+}
+
+// strategyFor returns the named strategy (config validates the name; unknown falls back to the default).
+func strategyFor(name string) Strategy {
+	if s, ok := strategies[name]; ok {
+		return s
+	}
+	return strategies[DefaultStrategy]
+}
+
+// manageExit is the shared position management: ratchet the stop (break-even, trailing), then
+// take-profit, stop and time stop. dir flips the comparisons for shorts.
+func manageExit(t *store.TickerState, p SimParams, trNY time.Time, price float64) Intent {
+	p.Exits.adjustStop(t)
+
+	dir := sideDir(t.Side)
+	if t.TakeProfitPrice > 0 && dir*(price-t.TakeProfitPrice) >= 0 {
+		return exitIntent(ExitProfit)
+	}
+	if t.StopPrice > 0 && dir*(price-t.StopPrice) <= 0 {
+		reason := t.StopKind
+		if reason == "" {
+			reason = ExitStop
+		}
+		return exitIntent(reason)
+	}
+	if p.Exits.timeStop(t, trNY, price) {
+		return exitIntent(ExitTimeStop)
+	}
+	return Intent{}
+}
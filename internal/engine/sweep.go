@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"massive-orb/internal/store"
+)
+
+// SweepSpec is a parameter grid for RunSweep. Every listed value is tried; fields left empty
+// keep the current runtime filter / risk value. Sessions come from From/To (trading days of the
+// exchange calendar) plus any explicit Dates.
+type SweepSpec struct {
+	From  string   `yaml:"from" json:"from,omitempty"`
+	To    string   `yaml:"to" json:"to,omitempty"`
+	Dates []string `yaml:"dates" json:"dates,omitempty"`
+
+	Filters SweepFilters `yaml:"filters" json:"filters"`
+	Risk    SweepRisk    `yaml:"risk" json:"risk"`
+
+	RankBy    string `yaml:"rank_by" json:"rank_by"`       // net_pnl (default) | profit_factor | win_rate
+	MinTrades int    `yaml:"min_trades" json:"min_trades"` // combos with fewer trades rank last
+	Top       int    `yaml:"top" json:"top"`               // results kept (default 25)
+	MaxCombos int    `yaml:"max_combos" json:"max_combos"` // refuse larger grids (default 20000)
+}
+
+// SweepFilters lists candidate values per RuntimeFilters field (same keys as /api/filters).
+type SweepFilters struct {
+	Open5mRangePctMin []float64 `yaml:"open_5m_range_pct_min" json:"open_5m_range_pct_min,omitempty"`
+	Open5mRangePctMax []float64 `yaml:"open_5m_range_pct_max" json:"open_5m_range_pct_max,omitempty"`
+	Open5mVolMin      []float64 `yaml:"open_5m_vol_min" json:"open_5m_vol_min,omitempty"`
+	Open5mVolMax      []float64 `yaml:"open_5m_vol_max" json:"open_5m_vol_max,omitempty"`
+	Open5mTodayPctMin []float64 `yaml:"open_5m_today_pct_min" json:"open_5m_today_pct_min,omitempty"`
+	Open5mTodayPctMax []float64 `yaml:"open_5m_today_pct_max" json:"open_5m_today_pct_max,omitempty"`
+	EntryMinAfterOpen []int     `yaml:"entry_minutes_after_open_min" json:"entry_minutes_after_open_min,omitempty"`
+	EntryMaxAfterOpen []int     `yaml:"entry_minutes_after_open_max" json:"entry_minutes_after_open_max,omitempty"`
+	EntryPriceMin     []float64 `yaml:"entry_price_min" json:"entry_price_min,omitempty"`
+	EntryPriceMax     []float64 `yaml:"entry_price_max" json:"entry_price_max,omitempty"`
+	Side              []string  `yaml:"side" json:"side,omitempty"`             // long | short | both
+	EntryMode         []string  `yaml:"entry_mode" json:"entry_mode,omitempty"` // vwap_cross | or_breakout
+	BreakoutVolMult   []float64 `yaml:"breakout_vol_mult" json:"breakout_vol_mult,omitempty"`
+	GapPctMin         []float64 `yaml:"gap_pct_min" json:"gap_pct_min,omitempty"`
+	GapPctMax         []float64 `yaml:"gap_pct_max" json:"gap_pct_max,omitempty"`
+	PremarketVolMin   []float64 `yaml:"premarket_vol_min" json:"premarket_vol_min,omitempty"`
+}
+
+type SweepRisk struct {
+	TakeProfitPct []float64 `yaml:"take_profit_pct" json:"take_profit_pct,omitempty"`
+	StopLossPct   []float64 `yaml:"stop_loss_pct" json:"stop_loss_pct,omitempty"`
+}
+
+// SweepResult is one parameter combination evaluated over every session.
+type SweepResult struct {
+	Rank        int                   `json:"rank"`
+	Params      SimParams             `json:"params"`
+	Summary     store.HistoricSummary `json:"summary"`
+	Sessions    int                   `json:"sessions"`
+	GreenDays   int                   `json:"green_days"`
+	RedDays     int                   `json:"red_days"`
+	MaxDrawdown float64               `json:"max_drawdown"`
+}
+
+type SweepReport struct {
+	RankBy    string        `json:"rank_by"`
+	MinTrades int           `json:"min_trades"`
+	Sessions  []string      `json:"sessions"`
+	Skipped   []string      `json:"skipped,omitempty"`
+	Combos    int           `json:"combos"`
+	Base      SimParams     `json:"base"`
+	Results   []SweepResult `json:"results"`
+}
+
+func LoadSweepSpec(path string) (SweepSpec, error) {
+	var spec SweepSpec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return spec, err
+	}
+	return spec, spec.validate()
+}
+
+func (s *SweepSpec) validate() error {
+	s.RankBy = strings.ToLower(strings.TrimSpace(s.RankBy))
+	if s.RankBy == "" {
+		s.RankBy = "net_pnl"
+	}
+	switch s.RankBy {
+	case "net_pnl", "profit_factor", "win_rate":
+	default:
+		return errors.New("rank_by invalid (net_pnl|profit_factor|win_rate)")
+	}
+	if s.Top <= 0 {
+		s.Top = 25
+	}
+	if s.MaxCombos <= 0 {
+		s.MaxCombos = 20000
+	}
+	if s.MinTrades < 0 {
+		return errors.New("min_trades invalid")
+	}
+	for _, side := range s.Filters.Side {
+		switch side {
+		case SideLong, SideShort, SideBoth:
+		default:
+			return errors.New("filters.side invalid (long|short|both)")
+		}
+	}
+	for _, mode := range s.Filters.EntryMode {
+		switch mode {
+		case EntryVWAPCross, EntryORBreakout:
+		default:
+			return errors.New("filters.entry_mode invalid (vwap_cross|or_breakout)")
+		}
+	}
+	if (s.From == "") != (s.To == "") {
+		return errors.New("from and to must be set together")
+	}
+	if s.From == "" && len(s.Dates) == 0 {
+		return errors.New("sweep needs from/to or dates")
+	}
+	return nil
+}
+
+// RunSweep fetches every session's tape once, evaluates each parameter combination in
+// memory and returns the combinations ranked by spec.RankBy.
+func (e *Engine) RunSweep(ctx context.Context, spec SweepSpec) (*SweepReport, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	days, err := e.sweepDays(spec.From, spec.To, spec.Dates)
+	if err != nil {
+		return nil, err
+	}
+
+	base := e.simParams()
+	if err := replayQuotesErr(base); err != nil {
+		return nil, err
+	}
+	combos, err := spec.combos(base)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("sweep: %d combinations over %d calendar days", len(combos), len(days))
+
+	tapes, skipped, err := e.loadSessionTapes(ctx, days, selectionBoundsFor(combos))
+	if err != nil {
+		return nil, err
+	}
+	if len(tapes) == 0 {
+		return nil, fmt.Errorf("no sessions with data in the requested dates")
+	}
+
+	results, err := e.evaluateCombos(ctx, tapes, combos)
+	if err != nil {
+		return nil, err
+	}
+	rankSweepResults(results, spec.RankBy, spec.MinTrades)
+	if len(results) > spec.Top {
+		results = results[:spec.Top]
+	}
+
+	sessions := make([]string, 0, len(tapes))
+	for _, tp := range tapes {
+		sessions = append(sessions, tp.dayNY.Format("2006-01-02"))
+	}
+
+	return &SweepReport{
+		RankBy:    spec.RankBy,
+		MinTrades: spec.MinTrades,
+		Sessions:  sessions,
+		Skipped:   skipped,
+		Combos:    len(combos),
+		Base:      base,
+		Results:   results,
+	}, nil
+}
+
+// sweepDays expands from/to (inclusive) plus explicit dates into sorted unique NY days, capped at today.
+func (e *Engine) sweepDays(fromISO, toISO string, dates []string) ([]time.Time, error) {
+	todayNY := dateOnlyInLoc(time.Now().In(e.loc), e.loc)
+	seen := make(map[string]time.Time, 64)
+
+	add := func(d time.Time) {
+		if d.After(todayNY) {
+			return
+		}
+		seen[d.Format("2006-01-02")] = d
+	}
+
+	if fromISO != "" {
+		from, err1 := time.ParseInLocation("2006-01-02", fromISO, e.loc)
+		to, err2 := time.ParseInLocation("2006-01-02", toISO, e.loc)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("from/to invalid (use YYYY-MM-DD)")
+		}
+		if from.After(to) {
+			return nil, errors.New("from must be on or before to")
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			add(d)
+		}
+	}
+	for _, iso := range dates {
+		d, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(iso), e.loc)
+		if err != nil {
+			return nil, fmt.Errorf("date %q invalid (use YYYY-MM-DD)", iso)
+		}
+		add(d)
+	}
+
+	out := make([]time.Time, 0, len(seen))
+	for _, d := range seen {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	if len(out) == 0 {
+		return nil, errors.New("no dates on or before today")
+	}
+	return out, nil
+}
+
+type sweepAxis struct {
+	vals []float64
+	set  func(p *SimParams, v float64)
+}
+
+// indexes lets a string axis ride the float grid: each value is an index into the original slice.
+func indexes[T any](v []T) []float64 {
+	out := make([]float64, len(v))
+	for i := range v {
+		out[i] = float64(i)
+	}
+	return out
+}
+
+func intsToFloats(v []int) []float64 {
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return out
+}
+
+// combos expands the grid around base. Combinations with min > max or non-positive TP/SL are dropped.
+func (s SweepSpec) combos(base SimParams) ([]SimParams, error) {
+	f := s.Filters
+	axes := []sweepAxis{
+		{f.Open5mRangePctMin, func(p *SimParams, v float64) { p.Filters.Open5mRangePctMin = v }},
+		{f.Open5mRangePctMax, func(p *SimParams, v float64) { p.Filters.Open5mRangePctMax = v }},
+		{f.Open5mVolMin, func(p *SimParams, v float64) { p.Filters.Open5mVolMin = v }},
+		{f.Open5mVolMax, func(p *SimParams, v float64) { p.Filters.Open5mVolMax = v }},
+		{f.Open5mTodayPctMin, func(p *SimParams, v float64) { p.Filters.Open5mTodayPctMin = v }},
+		{f.Open5mTodayPctMax, func(p *SimParams, v float64) { p.Filters.Open5mTodayPctMax = v }},
+		{intsToFloats(f.EntryMinAfterOpen), func(p *SimParams, v float64) { p.Filters.EntryMinAfterOpen = int(v) }},
+		{intsToFloats(f.EntryMaxAfterOpen), func(p *SimParams, v float64) { p.Filters.EntryMaxAfterOpen = int(v) }},
+		{f.EntryPriceMin, func(p *SimParams, v float64) { p.Filters.EntryPriceMin = v }},
+		{f.EntryPriceMax, func(p *SimParams, v float64) { p.Filters.EntryPriceMax = v }},
+		{indexes(f.Side), func(p *SimParams, v float64) { p.Filters.Side = f.Side[int(v)] }},
+		{indexes(f.EntryMode), func(p *SimParams, v float64) { p.Filters.EntryMode = f.EntryMode[int(v)] }},
+		{f.BreakoutVolMult, func(p *SimParams, v float64) { p.Filters.BreakoutVolMult = v }},
+		{f.GapPctMin, func(p *SimParams, v float64) { p.Filters.GapPctMin = v }},
+		{f.GapPctMax, func(p *SimParams, v float64) { p.Filters.GapPctMax = v }},
+		{f.PremarketVolMin, func(p *SimParams, v float64) { p.Filters.PremarketVolMin = v }},
+		{s.Risk.TakeProfitPct, func(p *SimParams, v float64) { p.TakeProfitPct = v }},
+		{s.Risk.StopLossPct, func(p *SimParams, v float64) { p.StopLossPct = v }},
+	}
+
+	total := 1
+	for _, a := range axes {
+		if len(a.vals) > 0 {
+			total *= len(a.vals)
+			if total > s.MaxCombos {
+				return nil, fmt.Errorf("grid has more than max_combos=%d combinations", s.MaxCombos)
+			}
+		}
+	}
+
+	out := make([]SimParams, 0, total)
+	var walk func(i int, p SimParams)
+	walk = func(i int, p SimParams) {
+		if i == len(axes) {
+			if p.valid() {
+				out = append(out, p)
+			}
+			return
+		}
+		if len(axes[i].vals) == 0 {
+			walk(i+1, p)
+			return
+		}
+		for _, v := range axes[i].vals {
+			next := p
+			axes[i].set(&next, v)
+			walk(i+1, next)
+		}
+	}
+	walk(0, base)
+
+	if len(out) == 0 {
+		return nil, errors.New("grid has no valid combinations (check min <= max)")
+	}
+	return out, nil
+}
+
+func (p SimParams) valid() bool {
+	f := p.Filters
+	return f.Open5mRangePctMin <= f.Open5mRangePctMax &&
+		f.Open5mVolMin <= f.Open5mVolMax &&
+		f.Open5mTodayPctMin <= f.Open5mTodayPctMax &&
+		f.EntryMinAfterOpen <= f.EntryMaxAfterOpen &&
+		f.EntryPriceMin <= f.EntryPriceMax &&
+		(f.GapPctMin == 0 || f.GapPctMax == 0 || f.GapPctMin <= f.GapPctMax) &&
+		p.TakeProfitPct > 0 && p.StopLossPct > 0
+}
+
+// evaluateCombos simulates every combination over every tape (CPU-bound, one worker per core).
+func (e *Engine) evaluateCombos(ctx context.Context, tapes []*sessionTape, combos []SimParams) ([]SweepResult, error) {
+	results := make([]SweepResult, len(combos))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = e.evaluateParams(tapes, combos[idx])
+			}
+		}()
+	}
+
+	started := time.Now()
+	for i := range combos {
+		select {
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			return nil, ctx.Err()
+		case jobs <- i:
+		}
+		if (i+1)%1000 == 0 {
+			log.Printf("sweep: queued %d/%d combinations (%s)", i+1, len(combos), time.Since(started).Round(time.Second))
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// evaluateParams runs one parameter set over tapes and aggregates it like a range backtest.
+func (e *Engine) evaluateParams(tapes []*sessionTape, p SimParams) SweepResult {
+	days, trades := e.simulateTapes(tapes, p)
+	return sweepResultFrom(p, days, trades)
+}
+
+// simulateTapes returns one summary per tape plus every trade taken under p.
+func (e *Engine) simulateTapes(tapes []*sessionTape, p SimParams) ([]store.HistoricSummary, []store.HistoricTrade) {
+	days := make([]store.HistoricSummary, 0, len(tapes))
+	trades := make([]store.HistoricTrade, 0, 64)
+	for _, tp := range tapes {
+		dayTrades := tp.simulate(p, e.loc)
+		sum := summarizeTrades(dayTrades)
+		sum.DateNY = tp.dayNY.Format("2006-01-02")
+		sum.Sizing = p.Sizing.String()
+		sum.CostModel = p.Costs.String()
+		days = append(days, sum)
+		trades = append(trades, dayTrades...)
+	}
+	return days, trades
+}
+
+func sweepResultFrom(p SimParams, days []store.HistoricSummary, trades []store.HistoricTrade) SweepResult {
+	fromISO, toISO := "", ""
+	if len(days) > 0 {
+		fromISO = days[0].DateNY
+		toISO = days[len(days)-1].DateNY
+	}
+	rng := buildHistoricRangeReport(fromISO, toISO, days, trades, nil, false)
+
+	return SweepResult{
+		Params:      p,
+		Summary:     rng.Summary,
+		Sessions:    rng.Sessions,
+		GreenDays:   rng.GreenDays,
+		RedDays:     rng.RedDays,
+		MaxDrawdown: rng.MaxDrawdown,
+	}
+}
+
+// rankSweepResults sorts best-first by rankBy, breaking ties with the other metrics
+// (net P&L, profit factor, win rate). Results under minTrades always sort last.
+func rankSweepResults(rs []SweepResult, rankBy string, minTrades int) {
+	metric := func(r SweepResult, name string) float64 {
+		switch name {
+		case "profit_factor":
+			return r.Summary.ProfitFactor
+		case "win_rate":
+			return r.Summary.WinRate
+		default:
+			return r.Summary.NetPnL
+		}
+	}
+	order := []string{rankBy}
+	for _, m := range []string{"net_pnl", "profit_factor", "win_rate"} {
+		if m != rankBy {
+			order = append(order, m)
+		}
+	}
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		ei := rs[i].Summary.TradesTaken >= minTrades
+		ej := rs[j].Summary.TradesTaken >= minTrades
+		if ei != ej {
+			return ei
+		}
+		for _, m := range order {
+			a, b := metric(rs[i], m), metric(rs[j], m)
+			if a != b {
+				return a > b
+			}
+		}
+		return false
+	})
+	for i := range rs {
+		rs[i].Rank = i + 1
+	}
+}
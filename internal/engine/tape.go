@@ -0,0 +1,302 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/store"
+)
+
+// sessionTape is everything one session's replay needs, fetched once so many parameter
+// sets can be re-evaluated in memory (sweeps, walk-forward).
+type sessionTape struct {
+	dayNY    time.Time
+	openNY   time.Time
+	selNY    time.Time
+	cutoffNY time.Time
+	exitNY   time.Time
+
+	syms     []string // candidate superset, sorted
+	open5m   map[string]open5mMetric
+	avgPrev  map[string]float64
+	todayPct map[string]float64
+	pre      map[string]premarketMetric // previous close + 04:00 → open (only when some params use it)
+	trades   map[string][]tapePrint     // open → force exit
+}
+
+type tapePrint struct {
+	at    time.Time // NY
+	price float64
+	size  float64
+}
+
+// selectionBounds is the loosest 09:35 selection window across a set of params;
+// anything outside it can never be selected, so its tape is not fetched.
+type selectionBounds struct {
+	rangeMin, rangeMax float64
+	volMin, volMax     float64
+	premarket          bool // some params set a gap / premarket volume bound
+}
+
+func selectionBoundsFor(params []SimParams) selectionBounds {
+	var b selectionBounds
+	for i, p := range params {
+		f := p.Filters
+		if i == 0 {
+			b = selectionBounds{f.Open5mRangePctMin, f.Open5mRangePctMax, f.Open5mVolMin, f.Open5mVolMax, premarketFiltersOn(f)}
+			continue
+		}
+		b.premarket = b.premarket || premarketFiltersOn(f)
+		if f.Open5mRangePctMin < b.rangeMin {
+			b.rangeMin = f.Open5mRangePctMin
+		}
+		if f.Open5mRangePctMax > b.rangeMax {
+			b.rangeMax = f.Open5mRangePctMax
+		}
+		if f.Open5mVolMin < b.volMin {
+			b.volMin = f.Open5mVolMin
+		}
+		if f.Open5mVolMax > b.volMax {
+			b.volMax = f.Open5mVolMax
+		}
+	}
+	return b
+}
+
+// loadSessionTapes fetches tapes for days (in order). Weekends and exchange holidays are left out;
+// days without session data and today before its force exit are returned as skipped.
+func (e *Engine) loadSessionTapes(ctx context.Context, days []time.Time, b selectionBounds) (tapes []*sessionTape, skipped []string, err error) {
+	asOfNY := time.Now().In(e.loc)
+	wl := e.st.Watchlist()
+
+	for _, d := range days {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		if !calendar.IsTradingDay(d) {
+			continue
+		}
+
+		sess := e.SessionFor(d)
+		tp := &sessionTape{
+			dayNY:    d,
+			openNY:   sess.OpenNY,
+			selNY:    sess.SelNY,
+			cutoffNY: sess.CutoffNY,
+			exitNY:   sess.ExitNY,
+		}
+
+		if sameDayInLoc(d, asOfNY, e.loc) && asOfNY.Before(tp.exitNY) {
+			skipped = append(skipped, d.Format("2006-01-02"))
+			continue
+		}
+
+		ok, err := e.hasOpen5mData(ctx, tp.openNY, tp.selNY)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", d.Format("2006-01-02"), err)
+		}
+		if !ok {
+			skipped = append(skipped, d.Format("2006-01-02"))
+			continue
+		}
+
+		tp.open5m = e.fetchOpen5mMetrics(ctx, wl, tp.openNY, tp.selNY)
+		for sym, m := range tp.open5m {
+			if m.RangePct >= b.rangeMin && m.RangePct <= b.rangeMax &&
+				m.Open5mVol >= b.volMin && m.Open5mVol <= b.volMax {
+				tp.syms = append(tp.syms, sym)
+			}
+		}
+		sort.Strings(tp.syms)
+
+		if err := e.fillTape(ctx, tp, b.premarket); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", d.Format("2006-01-02"), err)
+		}
+
+		log.Printf("tape %s: %d candidates", d.Format("2006-01-02"), len(tp.syms))
+		tapes = append(tapes, tp)
+	}
+	return tapes, skipped, nil
+}
+
+// fillTape fetches prior-session open-5m averages and open → exit trades for every candidate, and
+// the premarket metrics when premarket is set (no params filter on them otherwise).
+func (e *Engine) fillTape(ctx context.Context, tp *sessionTape, premarket bool) error {
+	var prev map[string]premarketMetric
+	if premarket {
+		prev = e.prevCloses(ctx, tp.syms, tp.openNY)
+	}
+
+	type result struct {
+		sym    string
+		avg    float64
+		pre    premarketMetric
+		prints []tapePrint
+		err    error
+	}
+
+	jobs := make(chan string)
+	results := make(chan result)
+
+	workerN := e.cfg.History.MaxWorkers
+	if workerN < 1 {
+		workerN = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workerN; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sym := range jobs {
+				r := result{sym: sym}
+				avg, err := e.avgPrevSessionsOpen5mVol(ctx, sym, tp.openNY, e.cfg.History.Open5mLookbackSessions, e.cfg.History.MaxCalendarLookback)
+				if err == nil {
+					r.avg = avg
+				}
+				if m, ok := prev[sym]; ok {
+					if m, err := e.premarketBars(ctx, sym, tp.openNY, tp.openNY, m); err == nil {
+						r.pre = m
+					}
+				}
+				// same two windows a replay uses (seed + replay), so the cache is shared
+				for _, w := range [][2]time.Time{{tp.openNY, tp.selNY}, {tp.selNY, tp.exitNY}} {
+					it := e.md.Trades(ctx, sym, w[0], w[1])
+					for it.Next() {
+						tr := it.Item()
+						if tr.Timestamp == 0 || !e.condFilter.eligible(nil, tr) {
+							continue
+						}
+						r.prints = append(r.prints, tapePrint{
+							at:    time.UnixMilli(tr.Timestamp).In(e.loc),
+							price: tr.Price,
+							size:  tr.Size,
+						})
+					}
+					if err := it.Err(); err != nil {
+						r.err = err
+						break
+					}
+				}
+				results <- r
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, sym := range tp.syms {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- sym:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	tp.avgPrev = make(map[string]float64, len(tp.syms))
+	tp.todayPct = make(map[string]float64, len(tp.syms))
+	tp.pre = make(map[string]premarketMetric, len(tp.syms))
+	tp.trades = make(map[string][]tapePrint, len(tp.syms))
+
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s trades: %w", r.sym, r.err)
+			}
+			continue
+		}
+		tp.trades[r.sym] = r.prints
+		tp.pre[r.sym] = r.pre
+		if r.avg > 0 {
+			tp.avgPrev[r.sym] = r.avg
+			tp.todayPct[r.sym] = (tp.open5m[r.sym].Open5mVol / r.avg) * 100.0
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// simulate replays the tape under p exactly like a historic run (09:35 selection, then the
+// strategy's entries/exits and its force-exit timer) and returns the trades taken. Nothing is written to the store.
+// Hold/MFE/MAE columns stop at the exit, so only realized fields are meaningful here.
+func (tp *sessionTape) simulate(p SimParams, loc *time.Location) []store.HistoricTrade {
+	f := p.Filters
+	strat := p.strategy()
+	sess := Session{OpenNY: tp.openNY, SelNY: tp.selNY, CutoffNY: tp.cutoffNY, ExitNY: tp.exitNY}
+	out := make([]store.HistoricTrade, 0, 8)
+	lastNY := tp.exitNY.Add(5 * time.Minute)
+
+	for _, sym := range tp.syms {
+		m := tp.open5m[sym]
+		if m.RangePct < f.Open5mRangePctMin || m.RangePct > f.Open5mRangePctMax ||
+			m.Open5mVol < f.Open5mVolMin || m.Open5mVol > f.Open5mVolMax {
+			continue
+		}
+
+		t := store.TickerState{
+			Symbol:             sym,
+			Open0930:           m.Open0930,
+			ORHigh:             m.ORHigh,
+			ORLow:              m.ORLow,
+			Open5mVol:          m.Open5mVol,
+			Open5mRangePct:     m.RangePct,
+			Prev10AvgOpen5mVol: tp.avgPrev[sym],
+			Open5mTodayPct:     tp.todayPct[sym],
+		}
+		applyPremarket(&t, tp.pre[sym])
+		if !premarketOK(&t, f) {
+			continue
+		}
+		strat.OnOpenRange(&t, p, sess)
+		strat.OnSelection(&t, p, sess)
+
+		for _, pr := range tp.trades[sym] {
+			if pr.at.Before(tp.openNY) || pr.at.After(lastNY) {
+				continue
+			}
+			in := stepTrade(&t, strat, p, sess, pr.at, pr.price, pr.size, true)
+			switch in.Action {
+			case IntentEnter:
+				_ = enterPosition(&t, p, tp.openNY, pr.at, pr.price, in.Side, in.Stop) // false: below one share, no trade
+			case IntentExit:
+				exitPosition(&t, tp.openNY, pr.at, in.Reason, pr.price)
+			}
+			// nothing left to decide for this symbol
+			if t.Exited || (!t.HasPosition && !pr.at.Before(tp.cutoffNY)) {
+				break
+			}
+		}
+
+		if t.HasPosition && !t.Exited {
+			if in := strat.OnTimer(&t, p, sess, tp.exitNY); in.Action == IntentExit {
+				px := t.LastPrice
+				if px <= 0 {
+					px = t.EntryPrice
+				}
+				exitPosition(&t, tp.openNY, tp.exitNY, in.Reason, px)
+			}
+		}
+
+		if tr, ok := historicTradeFromState(t, tp.exitNY, loc, p.Costs); ok {
+			out = append(out, tr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"massive-orb/internal/store"
+)
+
+// WalkForwardSpec optimizes a sweep grid on a rolling in-sample window and applies the
+// winner to the following out-of-sample window. Window sizes are in trading sessions.
+type WalkForwardSpec struct {
+	SweepSpec `yaml:",inline"`
+
+	InSampleSessions    int `yaml:"in_sample_sessions" json:"in_sample_sessions"`
+	OutOfSampleSessions int `yaml:"out_of_sample_sessions" json:"out_of_sample_sessions"`
+	StepSessions        int `yaml:"step_sessions" json:"step_sessions"` // default = out_of_sample_sessions
+}
+
+// WalkForwardWindow is one optimize → apply step.
+type WalkForwardWindow struct {
+	Index           int         `json:"index"`
+	InSampleFrom    string      `json:"in_sample_from"`
+	InSampleTo      string      `json:"in_sample_to"`
+	OutOfSampleFrom string      `json:"out_of_sample_from"`
+	OutOfSampleTo   string      `json:"out_of_sample_to"`
+	Params          SimParams   `json:"params"`
+	InSample        SweepResult `json:"in_sample"`
+	OutOfSample     SweepResult `json:"out_of_sample"`
+}
+
+type WalkForwardReport struct {
+	RankBy              string `json:"rank_by"`
+	MinTrades           int    `json:"min_trades"`
+	Combos              int    `json:"combos"`
+	InSampleSessions    int    `json:"in_sample_sessions"`
+	OutOfSampleSessions int    `json:"out_of_sample_sessions"`
+	StepSessions        int    `json:"step_sessions"`
+
+	Sessions []string `json:"sessions"`
+	Skipped  []string `json:"skipped,omitempty"`
+
+	Windows []WalkForwardWindow `json:"windows"`
+
+	// InSample aggregates each window's best in-sample result (windows may overlap).
+	// OutOfSample stitches the out-of-sample sessions back to back, so it is what the
+	// rule would actually have produced if re-optimized on this schedule.
+	InSample    *store.HistoricRangeReport `json:"in_sample"`
+	OutOfSample *store.HistoricRangeReport `json:"out_of_sample"`
+
+	// Efficiency is out-of-sample avg day P&L / in-sample avg day P&L (1.0 = no decay).
+	Efficiency float64 `json:"efficiency"`
+}
+
+func LoadWalkForwardSpec(path string) (WalkForwardSpec, error) {
+	var spec WalkForwardSpec
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return spec, err
+	}
+	return spec, spec.validate()
+}
+
+func (s *WalkForwardSpec) validate() error {
+	if err := s.SweepSpec.validate(); err != nil {
+		return err
+	}
+	if s.InSampleSessions < 1 {
+		return errors.New("in_sample_sessions invalid")
+	}
+	if s.OutOfSampleSessions < 1 {
+		return errors.New("out_of_sample_sessions invalid")
+	}
+	if s.StepSessions <= 0 {
+		s.StepSessions = s.OutOfSampleSessions
+	}
+	// overlapping out-of-sample windows would stitch the same sessions in twice
+	if s.StepSessions < s.OutOfSampleSessions {
+		return errors.New("step_sessions invalid (>= out_of_sample_sessions)")
+	}
+	return nil
+}
+
+// RunWalkForward loads every session once, then for each window picks the best combination
+// on the in-sample sessions and replays it unchanged on the next out-of-sample sessions.
+func (e *Engine) RunWalkForward(ctx context.Context, spec WalkForwardSpec) (*WalkForwardReport, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	days, err := e.sweepDays(spec.From, spec.To, spec.Dates)
+	if err != nil {
+		return nil, err
+	}
+	if err := replayQuotesErr(e.simParams()); err != nil {
+		return nil, err
+	}
+	combos, err := spec.combos(e.simParams())
+	if err != nil {
+		return nil, err
+	}
+
+	tapes, skipped, err := e.loadSessionTapes(ctx, days, selectionBoundsFor(combos))
+	if err != nil {
+		return nil, err
+	}
+	is, oos, step := spec.InSampleSessions, spec.OutOfSampleSessions, spec.StepSessions
+	if len(tapes) < is+1 {
+		return nil, fmt.Errorf("need at least %d sessions for in_sample_sessions=%d, have %d", is+1, is, len(tapes))
+	}
+
+	rep := &WalkForwardReport{
+		RankBy:              spec.RankBy,
+		MinTrades:           spec.MinTrades,
+		Combos:              len(combos),
+		InSampleSessions:    is,
+		OutOfSampleSessions: oos,
+		StepSessions:        step,
+		Skipped:             skipped,
+	}
+	for _, tp := range tapes {
+		rep.Sessions = append(rep.Sessions, tp.dayNY.Format("2006-01-02"))
+	}
+
+	var (
+		isDays    []store.HistoricSummary
+		isTrades  []store.HistoricTrade
+		oosDays   []store.HistoricSummary
+		oosTrades []store.HistoricTrade
+	)
+
+	for start := 0; start+is < len(tapes); start += step {
+		inTapes := tapes[start : start+is]
+		end := start + is + oos
+		if end > len(tapes) {
+			end = len(tapes)
+		}
+		outTapes := tapes[start+is : end]
+
+		results, err := e.evaluateCombos(ctx, inTapes, combos)
+		if err != nil {
+			return nil, err
+		}
+		rankSweepResults(results, spec.RankBy, spec.MinTrades)
+		best := results[0]
+
+		inD, inT := e.simulateTapes(inTapes, best.Params)
+		outD, outT := e.simulateTapes(outTapes, best.Params)
+		isDays = append(isDays, inD...)
+		isTrades = append(isTrades, inT...)
+		oosDays = append(oosDays, outD...)
+		oosTrades = append(oosTrades, outT...)
+
+		w := WalkForwardWindow{
+			Index:           len(rep.Windows) + 1,
+			InSampleFrom:    inTapes[0].dayNY.Format("2006-01-02"),
+			InSampleTo:      inTapes[len(inTapes)-1].dayNY.Format("2006-01-02"),
+			OutOfSampleFrom: outTapes[0].dayNY.Format("2006-01-02"),
+			OutOfSampleTo:   outTapes[len(outTapes)-1].dayNY.Format("2006-01-02"),
+			Params:          best.Params,
+			InSample:        best,
+			OutOfSample:     sweepResultFrom(best.Params, outD, outT),
+		}
+		rep.Windows = append(rep.Windows, w)
+
+		log.Printf("walk-forward %d: IS %s→%s net %.2f | OOS %s→%s net %.2f",
+			w.Index, w.InSampleFrom, w.InSampleTo, w.InSample.Summary.NetPnL,
+			w.OutOfSampleFrom, w.OutOfSampleTo, w.OutOfSample.Summary.NetPnL)
+
+		if end == len(tapes) {
+			break
+		}
+	}
+
+	rep.InSample = buildHistoricRangeReport(rep.Windows[0].InSampleFrom, rep.Windows[len(rep.Windows)-1].InSampleTo, isDays, isTrades, nil, false)
+	rep.OutOfSample = buildHistoricRangeReport(rep.Windows[0].OutOfSampleFrom, rep.Windows[len(rep.Windows)-1].OutOfSampleTo, oosDays, oosTrades, nil, false)
+	if rep.InSample.AvgDayPnL != 0 {
+		rep.Efficiency = rep.OutOfSample.AvgDayPnL / rep.InSample.AvgDayPnL
+	}
+	return rep, nil
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"massive-orb/internal/store"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+
+	TableAll = "all"
+)
+
+// Table is one spreadsheet-shaped export: a name (used as the file suffix) and a slice of flat structs.
+type Table struct {
+	Name string
+	Rows any
+}
+
+// Tables lists what can be exported from a session report or a range report.
+// Either argument may be nil. With a range, rep is only its last session, so the per-session
+// tables are left out (range_trades has every session's trades, dated). Names are stable; they
+// are what ?table= matches.
+func Tables(rep *store.HistoricReport, rng *store.HistoricRangeReport) []Table {
+	var out []Table
+	if rep != nil && rng == nil {
+		out = append(out,
+			Table{Name: "summary", Rows: []store.HistoricSummary{rep.Summary}},
+			Table{Name: "trades", Rows: rep.Trades},
+			Table{Name: "no_entries", Rows: rep.NoEntries},
+			Table{Name: "sold_off", Rows: rep.SoldOff},
+		)
+		if rep.Rebound != nil {
+			out = append(out,
+				Table{Name: "rebound_summary", Rows: []store.HistoricSummary{rep.Rebound.Summary}},
+				Table{Name: "rebound_trades", Rows: rep.Rebound.Trades},
+			)
+		}
+	}
+	if rng != nil {
+		out = append(out,
+			Table{Name: "range_summary", Rows: []store.HistoricSummary{rng.Summary}},
+			Table{Name: "range_days", Rows: rng.Days},
+			Table{Name: "range_equity", Rows: rng.Equity},
+			Table{Name: "range_trades", Rows: rng.Trades},
+		)
+		if rng.Rebound != nil {
+			out = append(out,
+				Table{Name: "range_rebound_summary", Rows: []store.HistoricSummary{rng.Rebound.Summary}},
+				Table{Name: "range_rebound_trades", Rows: rng.Rebound.Trades},
+			)
+		}
+	}
+	return out
+}
+
+func Find(tables []Table, name string) (Table, bool) {
+	for _, t := range tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// Prefix is the file name stem for a report, e.g. "orb_2025-03-03" or "orb_2025-03-03_2025-03-28".
+func Prefix(rep *store.HistoricReport, rng *store.HistoricRangeReport) string {
+	switch {
+	case rng != nil && rng.FromNY != "":
+		return "orb_" + rng.FromNY + "_" + rng.ToNY
+	case rep != nil && rep.Summary.DateNY != "":
+		return "orb_" + rep.Summary.DateNY
+	default:
+		return "orb"
+	}
+}
+
+// WriteCSV writes rows (a slice of structs) with a header taken from the json tags,
+// so the columns match the API field names. Only flat fields are written.
+func WriteCSV(w io.Writer, rows any) error {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("export: rows must be a slice, got %T", rows)
+	}
+	typ := v.Type().Elem()
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("export: rows must be a slice of structs, got %T", rows)
+	}
+
+	var (
+		header []string
+		fields []int
+	)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() || !isScalar(f.Type.Kind()) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	rec := make([]string, len(fields))
+	for r := 0; r < v.Len(); r++ {
+		row := v.Index(r)
+		for j, fi := range fields {
+			rec[j] = formatValue(row.Field(fi))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteZip writes every table as <prefix>_<name>.csv into one zip archive.
+func WriteZip(w io.Writer, prefix string, tables []Table) error {
+	zw := zip.NewWriter(w)
+	for _, t := range tables {
+		f, err := zw.Create(prefix + "_" + t.Name + ".csv")
+		if err != nil {
+			return err
+		}
+		if err := WriteCSV(f, t.Rows); err != nil {
+			return fmt.Errorf("%s: %w", t.Name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// WriteJSON writes v as indented JSON (same field names as the API).
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Document is the JSON shape for a full export.
+type Document struct {
+	Report *store.HistoricReport      `json:"report,omitempty"`
+	Range  *store.HistoricRangeReport `json:"range,omitempty"`
+}
+
+// NewDocument is the JSON export of a session or a range (the range's last session is left out, see Tables).
+func NewDocument(rep *store.HistoricReport, rng *store.HistoricRangeReport) Document {
+	if rng != nil {
+		return Document{Range: rng}
+	}
+	return Document{Report: rep}
+}
+
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		// full precision, no exponent: spreadsheets parse it as a plain number
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return ""
+}
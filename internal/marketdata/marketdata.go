@@ -0,0 +1,152 @@
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MarketData is the provider-neutral surface the engine and server depend on.
+// Massive is one adapter (see internal/massive); recorded data, other vendors
+// and fakes only need to implement this interface.
+type MarketData interface {
+	// MinuteBars returns 1-minute bars in [start,end), oldest first.
+	MinuteBars(ctx context.Context, ticker string, start, end time.Time) ([]Bar, error)
+
+	// Trades iterates trades in [start,end), oldest first.
+	Trades(ctx context.Context, ticker string, start, end time.Time) TradeIter
+
+	// StreamMinuteAggs subscribes to live 1-minute aggregates for tickers.
+	StreamMinuteAggs(ctx context.Context, tickers []string) (AggStream, error)
+
+	// StreamTrades subscribes to live trades for tickers.
+	StreamTrades(ctx context.Context, tickers []string) (TradeStream, error)
+
+	// StreamQuotes subscribes to live NBBO quotes for tickers.
+	StreamQuotes(ctx context.Context, tickers []string) (QuoteStream, error)
+}
+
+// Reference is the optional ticker-universe surface (see watchlist.BuildUniverse). It is kept out
+// of MarketData; the engine also uses GroupedDaily for previous closes when md has it.
+type Reference interface {
+	// Tickers lists the active tickers of one reference type (e.g. "CS", common stock).
+	Tickers(ctx context.Context, typ string) ([]TickerRef, error)
+
+	// GroupedDaily returns every ticker's daily bar for one session.
+	GroupedDaily(ctx context.Context, day time.Time) ([]DailyBar, error)
+}
+
+// TickerRef is one entry of the provider's ticker reference.
+type TickerRef struct {
+	Symbol          string
+	Name            string
+	Type            string
+	PrimaryExchange string // MIC, e.g. XNAS
+}
+
+// DailyBar is one ticker's daily aggregate from a grouped daily request.
+type DailyBar struct {
+	Symbol string
+	Bar
+}
+
+// Bar is a single OHLCV aggregate.
+type Bar struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+	VWAP   float64
+}
+
+// Agg is a streamed minute aggregate for one symbol.
+type Agg struct {
+	Symbol         string
+	StartTimestamp int64 // Unix ms
+	Open           float64
+	High           float64
+	Low            float64
+	Close          float64
+	Volume         float64
+}
+
+// Trade is a single print. Timestamp is the best available tape time in Unix ms.
+type Trade struct {
+	Symbol     string
+	Timestamp  int64
+	Price      float64
+	Size       float64
+	Exchange   int
+	Conditions []int32
+	Sequence   int64 // provider sequence number: unique and increasing per ticker (0 = unknown)
+}
+
+// Quote is a national best bid/offer update. Sizes are in shares.
+type Quote struct {
+	Symbol    string
+	Timestamp int64 // Unix ms
+	BidPrice  float64
+	BidSize   float64
+	AskPrice  float64
+	AskSize   float64
+}
+
+type TradeIter interface {
+	Next() bool
+	Item() Trade
+	Err() error
+}
+
+// Non-fatal notices a stream may send on Err when the provider handles a dropped connection
+// itself: ErrReconnecting while it is retrying, ErrReconnected once the stream is back.
+// Either way the prints (or bars) in between are missing; any other error means the stream is dead.
+var (
+	ErrReconnecting = errors.New("stream disconnected, reconnecting")
+	ErrReconnected  = errors.New("stream reconnected")
+)
+
+// AggStream delivers live minute aggregates until Close is called.
+// Aggs is closed when the stream shuts down; Err reports stream errors (see ErrReconnected).
+type AggStream interface {
+	Aggs() <-chan Agg
+	Err() <-chan error
+	Close()
+}
+
+// TradeStream delivers live trades until Close is called.
+type TradeStream interface {
+	Trades() <-chan Trade
+	Err() <-chan error
+	Close()
+}
+
+// QuoteStream delivers live quotes until Close is called.
+type QuoteStream interface {
+	Quotes() <-chan Quote
+	Err() <-chan error
+	Close()
+}
+
+// SliceTradeIter adapts an in-memory slice to TradeIter.
+type SliceTradeIter struct {
+	trades []Trade
+	i      int
+	err    error
+}
+
+func NewSliceTradeIter(trades []Trade, err error) *SliceTradeIter {
+	return &SliceTradeIter{trades: trades, i: -1, err: err}
+}
+
+func (s *SliceTradeIter) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.i++
+	return s.i < len(s.trades)
+}
+
+func (s *SliceTradeIter) Item() Trade { return s.trades[s.i] }
+func (s *SliceTradeIter) Err() error  { return s.err }
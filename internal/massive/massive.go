@@ -15,10 +15,19 @@ func NewREST(apiKey string) *mrest.Client {
 	return mrest.New(apiKey)
 }
 
-func NewWS(apiKey, feed string) (*massivews.Client, error) {
+// wsMaxRetries bounds the client's own reconnect attempts; past that the stream errors out and
+// the engine takes over (backoff, resubscribe, REST backfill).
+const wsMaxRetries uint64 = 3
+
+// NewWS builds a stocks client. onReconnect (optional) sees every automatic reconnect attempt:
+// non-nil error while it keeps failing, nil once the connection is back.
+func NewWS(apiKey, feed string, onReconnect func(error)) (*massivews.Client, error) {
+	retries := wsMaxRetries
 	cfg := massivews.Config{
-		APIKey: apiKey,
-		Market: massivews.Stocks,
+		APIKey:            apiKey,
+		Market:            massivews.Stocks,
+		MaxRetries:        &retries,
+		ReconnectCallback: onReconnect,
 	}
 
 	switch strings.ToLower(feed) {
@@ -47,3 +56,4 @@ func ToNanos(t time.Time) models.Nanos {
 
 type EquityAgg = wsmodels.EquityAgg
 type EquityTrade = wsmodels.EquityTrade
+type EquityQuote = wsmodels.EquityQuote
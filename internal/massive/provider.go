@@ -0,0 +1,388 @@
+package massive
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	mrest "github.com/massive-com/client-go/v2/rest"
+	"github.com/massive-com/client-go/v2/rest/iter"
+	"github.com/massive-com/client-go/v2/rest/models"
+	massivews "github.com/massive-com/client-go/v2/websocket"
+
+	"massive-orb/internal/marketdata"
+)
+
+// Provider is the Massive adapter for marketdata.MarketData.
+type Provider struct {
+	apiKey      string
+	feed        string
+	wsBatchSize int
+
+	rest *mrest.Client
+}
+
+var (
+	_ marketdata.MarketData = (*Provider)(nil)
+	_ marketdata.Reference  = (*Provider)(nil)
+)
+
+func NewProvider(apiKey, feed string, wsBatchSize int) *Provider {
+	if wsBatchSize <= 0 {
+		wsBatchSize = 200
+	}
+	return &Provider{
+		apiKey:      apiKey,
+		feed:        feed,
+		wsBatchSize: wsBatchSize,
+		rest:        NewREST(apiKey),
+	}
+}
+
+func (p *Provider) MinuteBars(ctx context.Context, ticker string, start, end time.Time) ([]marketdata.Bar, error) {
+	params := models.ListAggsParams{
+		Ticker:     ticker,
+		Multiplier: 1,
+		Timespan:   models.Minute,
+		From:       ToMillis(start),
+		To:         ToMillis(end),
+	}
+	it := p.rest.ListAggs(ctx, &params)
+
+	out := make([]marketdata.Bar, 0, 64)
+	for it.Next() {
+		a := it.Item()
+		out = append(out, marketdata.Bar{
+			Start:  time.Time(a.Timestamp),
+			Open:   a.Open,
+			High:   a.High,
+			Low:    a.Low,
+			Close:  a.Close,
+			Volume: a.Volume,
+			VWAP:   a.VWAP,
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Provider) Trades(ctx context.Context, ticker string, start, end time.Time) marketdata.TradeIter {
+	params := models.ListTradesParams{Ticker: ticker}.
+		WithTimestamp(models.GTE, ToNanos(start)).
+		WithTimestamp(models.LT, ToNanos(end)).
+		WithLimit(50000)
+
+	return &tradeIter{sym: ticker, it: p.rest.ListTrades(ctx, params)}
+}
+
+type tradeIter struct {
+	sym string
+	it  *iter.Iter[models.Trade]
+}
+
+func (t *tradeIter) Next() bool { return t.it.Next() }
+func (t *tradeIter) Err() error { return t.it.Err() }
+
+func (t *tradeIter) Item() marketdata.Trade {
+	tr := t.it.Item()
+	return marketdata.Trade{
+		Symbol:     t.sym,
+		Timestamp:  tradeTimeMillis(tr),
+		Price:      tr.Price,
+		Size:       tr.Size,
+		Exchange:   tr.Exchange,
+		Conditions: tr.Conditions,
+		Sequence:   tr.SequenceNumber,
+	}
+}
+
+// tradeTimeMillis picks the best available trade timestamp from the REST model and returns Unix ms.
+func tradeTimeMillis(tr models.Trade) int64 {
+	// Prefer SIP timestamp (best “tape time”), then participant, then TRF.
+	if !time.Time(tr.SipTimestamp).IsZero() {
+		return time.Time(tr.SipTimestamp).UnixMilli()
+	}
+	if !time.Time(tr.ParticipantTimestamp).IsZero() {
+		return time.Time(tr.ParticipantTimestamp).UnixMilli()
+	}
+	if !time.Time(tr.TrfTimestamp).IsZero() {
+		return time.Time(tr.TrfTimestamp).UnixMilli()
+	}
+	return 0
+}
+
+// ---- Reference ----
+
+func (p *Provider) Tickers(ctx context.Context, typ string) ([]marketdata.TickerRef, error) {
+	params := models.ListTickersParams{}.
+		WithMarket(models.AssetStocks).
+		WithType(typ).
+		WithActive(true).
+		WithLimit(1000)
+	it := p.rest.ListTickers(ctx, params)
+
+	out := make([]marketdata.TickerRef, 0, 4096)
+	for it.Next() {
+		t := it.Item()
+		out = append(out, marketdata.TickerRef{
+			Symbol:          t.Ticker,
+			Name:            t.Name,
+			Type:            t.Type,
+			PrimaryExchange: t.PrimaryExchange,
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Provider) GroupedDaily(ctx context.Context, day time.Time) ([]marketdata.DailyBar, error) {
+	y, m, d := day.Date()
+	params := models.GetGroupedDailyAggsParams{
+		Locale:     models.US,
+		MarketType: models.Stocks,
+		Date:       models.Date(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)),
+	}.WithAdjusted(true)
+	res, err := p.rest.GetGroupedDailyAggs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]marketdata.DailyBar, 0, len(res.Results))
+	for _, a := range res.Results {
+		out = append(out, marketdata.DailyBar{
+			Symbol: a.Ticker,
+			Bar: marketdata.Bar{
+				Start:  time.Time(a.Timestamp),
+				Open:   a.Open,
+				High:   a.High,
+				Low:    a.Low,
+				Close:  a.Close,
+				Volume: a.Volume,
+				VWAP:   a.VWAP,
+			},
+		})
+	}
+	return out, nil
+}
+
+// ---- Streaming ----
+
+// subscribe opens a WS client, subscribes tickers in batches (important for 8k) and connects.
+func (p *Provider) subscribe(topic massivews.Topic, tickers []string, notes *streamNotes) (*massivews.Client, error) {
+	ws, err := NewWS(p.apiKey, p.feed, notes.onReconnect)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(tickers); i += p.wsBatchSize {
+		j := i + p.wsBatchSize
+		if j > len(tickers) {
+			j = len(tickers)
+		}
+		if err := ws.Subscribe(topic, tickers[i:j]...); err != nil {
+			ws.Close()
+			return nil, fmt.Errorf("subscribe: %w", err)
+		}
+	}
+	if err := ws.Connect(); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("ws connect: %w", err)
+	}
+	notes.forward(ws)
+	return ws, nil
+}
+
+func (p *Provider) StreamMinuteAggs(ctx context.Context, tickers []string) (marketdata.AggStream, error) {
+	notes := newStreamNotes()
+	ws, err := p.subscribe(massivews.StocksMinAggs, tickers, notes)
+	if err != nil {
+		return nil, err
+	}
+	s := &aggStream{streamNotes: notes, ws: ws, out: make(chan marketdata.Agg, 4096)}
+	go func() {
+		defer close(s.out)
+		for msg := range ws.Output() {
+			agg, ok := msg.(EquityAgg)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			case s.out <- marketdata.Agg{
+				Symbol:         agg.Symbol,
+				StartTimestamp: agg.StartTimestamp,
+				Open:           agg.Open,
+				High:           agg.High,
+				Low:            agg.Low,
+				Close:          agg.Close,
+				Volume:         agg.Volume,
+			}:
+			}
+		}
+	}()
+	return s, nil
+}
+
+func (p *Provider) StreamTrades(ctx context.Context, tickers []string) (marketdata.TradeStream, error) {
+	notes := newStreamNotes()
+	ws, err := p.subscribe(massivews.StocksTrades, tickers, notes)
+	if err != nil {
+		return nil, err
+	}
+	s := &tradeStream{streamNotes: notes, ws: ws, out: make(chan marketdata.Trade, 4096)}
+	go func() {
+		defer close(s.out)
+		for msg := range ws.Output() {
+			tr, ok := msg.(EquityTrade)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			case s.out <- marketdata.Trade{
+				Symbol:     tr.Symbol,
+				Timestamp:  tr.Timestamp,
+				Price:      tr.Price,
+				Size:       float64(tr.Size),
+				Exchange:   int(tr.Exchange),
+				Conditions: tr.Conditions,
+				Sequence:   tr.SequenceNumber,
+			}:
+			}
+		}
+	}()
+	return s, nil
+}
+
+// StreamQuotes streams NBBO updates. Massive quotes sizes in round lots; Quote carries shares.
+func (p *Provider) StreamQuotes(ctx context.Context, tickers []string) (marketdata.QuoteStream, error) {
+	notes := newStreamNotes()
+	ws, err := p.subscribe(massivews.StocksQuotes, tickers, notes)
+	if err != nil {
+		return nil, err
+	}
+	s := &quoteStream{streamNotes: notes, ws: ws, out: make(chan marketdata.Quote, 4096)}
+	go func() {
+		defer close(s.out)
+		for msg := range ws.Output() {
+			q, ok := msg.(EquityQuote)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			case s.out <- marketdata.Quote{
+				Symbol:    q.Symbol,
+				Timestamp: q.Timestamp,
+				BidPrice:  q.BidPrice,
+				BidSize:   float64(q.BidSize) * 100,
+				AskPrice:  q.AskPrice,
+				AskSize:   float64(q.AskSize) * 100,
+			}:
+			}
+		}
+	}()
+	return s, nil
+}
+
+// streamNotes is a stream's Err channel: the client's fatal errors plus its automatic
+// reconnects as marketdata.ErrReconnecting / ErrReconnected notices.
+//
+// Close may be called more than once; the done channel releases a pump
+// goroutine that is blocked on a consumer which stopped reading.
+type streamNotes struct {
+	errs chan error
+	done chan struct{}
+	once sync.Once
+}
+
+func newStreamNotes() *streamNotes {
+	return &streamNotes{errs: make(chan error, 8), done: make(chan struct{})}
+}
+
+// onReconnect runs on the client's reconnect goroutine (under its lock): never block there.
+// A full buffer only drops notices the consumer has not caught up with yet.
+func (n *streamNotes) onReconnect(err error) {
+	note := marketdata.ErrReconnected
+	if err != nil {
+		note = fmt.Errorf("%w: %v", marketdata.ErrReconnecting, err)
+	}
+	select {
+	case n.errs <- note:
+	default:
+	}
+}
+
+// forward relays the client's error channel (unbuffered) until the stream is closed.
+func (n *streamNotes) forward(ws *massivews.Client) {
+	go func() {
+		for {
+			select {
+			case <-n.done:
+				return
+			case err := <-ws.Error():
+				select {
+				case <-n.done:
+					return
+				case n.errs <- err:
+				}
+			}
+		}
+	}()
+}
+
+func (n *streamNotes) Err() <-chan error { return n.errs }
+
+func (n *streamNotes) stop() { n.once.Do(func() { close(n.done) }) }
+
+type aggStream struct {
+	*streamNotes
+	ws  *massivews.Client
+	out chan marketdata.Agg
+}
+
+func (s *aggStream) Aggs() <-chan marketdata.Agg { return s.out }
+
+func (s *aggStream) Close() {
+	s.stop()
+	s.ws.Close()
+}
+
+type tradeStream struct {
+	*streamNotes
+	ws  *massivews.Client
+	out chan marketdata.Trade
+}
+
+func (s *tradeStream) Trades() <-chan marketdata.Trade { return s.out }
+
+func (s *tradeStream) Close() {
+	s.stop()
+	s.ws.Close()
+}
+
+type quoteStream struct {
+	*streamNotes
+	ws  *massivews.Client
+	out chan marketdata.Quote
+}
+
+func (s *quoteStream) Quotes() <-chan marketdata.Quote { return s.out }
+
+func (s *quoteStream) Close() {
+	s.stop()
+	s.ws.Close()
+}
@@ -0,0 +1,373 @@
+package mdcache
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"massive-orb/internal/marketdata"
+)
+
+// Cache is a persistent on-disk cache in front of a MarketData provider.
+//
+// Minute bars and trade tapes are stored per symbol/day/window under dir.
+// Only windows that ended before today (in loc) are cached, so replays of past
+// sessions are served from disk while today's (still changing) data always
+// goes to the provider. Streams pass straight through.
+type Cache struct {
+	md       marketdata.MarketData
+	dir      string
+	maxBytes int64
+	loc      *time.Location
+
+	mu      sync.Mutex
+	entries map[string]entry // rel path -> entry
+	bytes   int64
+	stats   Stats
+}
+
+type entry struct {
+	size   int64
+	usedAt time.Time
+}
+
+// Stats is a point-in-time view of cache usage.
+type Stats struct {
+	Dir       string `json:"dir"`
+	Files     int    `json:"files"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	Writes    int64  `json:"writes"`
+	Evictions int64  `json:"evictions"`
+	Errors    int64  `json:"errors"`
+}
+
+var (
+	_ marketdata.MarketData = (*Cache)(nil)
+	_ marketdata.Reference  = (*Cache)(nil)
+)
+
+var errNoReference = errors.New("market data provider has no ticker reference")
+
+// New wraps md with a cache rooted at dir. maxBytes <= 0 disables the size cap.
+func New(md marketdata.MarketData, dir string, maxBytes int64, loc *time.Location) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	c := &Cache{
+		md:       md,
+		dir:      dir,
+		maxBytes: maxBytes,
+		loc:      loc,
+		entries:  make(map[string]entry, 1024),
+	}
+	if err := c.loadIndex(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.evictLocked()
+	c.mu.Unlock()
+	return c, nil
+}
+
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Dir = c.dir
+	st.Files = len(c.entries)
+	st.Bytes = c.bytes
+	st.MaxBytes = c.maxBytes
+	return st
+}
+
+// ---- marketdata.MarketData ----
+
+func (c *Cache) MinuteBars(ctx context.Context, ticker string, start, end time.Time) ([]marketdata.Bar, error) {
+	if !c.cacheable(end) {
+		return c.md.MinuteBars(ctx, ticker, start, end)
+	}
+	key := c.key("bars", ticker, start, end)
+
+	var bars []marketdata.Bar
+	if c.read(key, &bars) {
+		return bars, nil
+	}
+
+	bars, err := c.md.MinuteBars(ctx, ticker, start, end)
+	if err != nil {
+		return nil, err
+	}
+	c.write(key, bars)
+	return bars, nil
+}
+
+func (c *Cache) Trades(ctx context.Context, ticker string, start, end time.Time) marketdata.TradeIter {
+	if !c.cacheable(end) {
+		return c.md.Trades(ctx, ticker, start, end)
+	}
+	key := c.key("trades", ticker, start, end)
+
+	var trades []marketdata.Trade
+	if c.read(key, &trades) {
+		return marketdata.NewSliceTradeIter(trades, nil)
+	}
+
+	it := c.md.Trades(ctx, ticker, start, end)
+	trades = make([]marketdata.Trade, 0, 1024)
+	for it.Next() {
+		trades = append(trades, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		// never cache a partial tape
+		return marketdata.NewSliceTradeIter(trades, err)
+	}
+	c.write(key, trades)
+	return marketdata.NewSliceTradeIter(trades, nil)
+}
+
+func (c *Cache) StreamMinuteAggs(ctx context.Context, tickers []string) (marketdata.AggStream, error) {
+	return c.md.StreamMinuteAggs(ctx, tickers)
+}
+
+func (c *Cache) StreamTrades(ctx context.Context, tickers []string) (marketdata.TradeStream, error) {
+	return c.md.StreamTrades(ctx, tickers)
+}
+
+func (c *Cache) StreamQuotes(ctx context.Context, tickers []string) (marketdata.QuoteStream, error) {
+	return c.md.StreamQuotes(ctx, tickers)
+}
+
+// ---- marketdata.Reference (when the wrapped provider has one) ----
+
+func (c *Cache) Tickers(ctx context.Context, typ string) ([]marketdata.TickerRef, error) {
+	ref, ok := c.md.(marketdata.Reference)
+	if !ok {
+		return nil, errNoReference
+	}
+	return ref.Tickers(ctx, typ)
+}
+
+// GroupedDaily caches finished sessions under "grouped/ALL/<day>" like any other window.
+func (c *Cache) GroupedDaily(ctx context.Context, day time.Time) ([]marketdata.DailyBar, error) {
+	ref, ok := c.md.(marketdata.Reference)
+	if !ok {
+		return nil, errNoReference
+	}
+	d := day.In(c.loc)
+	start := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, c.loc)
+	end := start.AddDate(0, 0, 1)
+	if !c.cacheable(end) {
+		return ref.GroupedDaily(ctx, day)
+	}
+	key := c.key("grouped", "ALL", start, end)
+
+	var bars []marketdata.DailyBar
+	if c.read(key, &bars) {
+		return bars, nil
+	}
+
+	bars, err := ref.GroupedDaily(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	c.write(key, bars)
+	return bars, nil
+}
+
+// ---- keys / files ----
+
+// cacheable reports whether a window ending at end is final (ended before today in loc).
+func (c *Cache) cacheable(end time.Time) bool {
+	now := time.Now().In(c.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, c.loc)
+	return !end.After(today)
+}
+
+// key builds "<kind>/<SYM>/<YYYY-MM-DD>/<HHMMSS>-<HHMMSS>.gob.gz" (end carries its date if it is on another day).
+func (c *Cache) key(kind, ticker string, start, end time.Time) string {
+	s := start.In(c.loc)
+	e := end.In(c.loc)
+	day := s.Format("2006-01-02")
+	endPart := e.Format("150405")
+	if e.Format("2006-01-02") != day {
+		endPart = e.Format("20060102T150405")
+	}
+	sym := strings.ToUpper(strings.TrimSpace(ticker))
+	// reversible escape: BRK.B and BRK_B must not share a file (path separators are escaped, "." is kept)
+	sym = url.PathEscape(sym)
+	if sym == "." || sym == ".." {
+		sym = strings.ReplaceAll(sym, ".", "%2E")
+	}
+	return filepath.Join(kind, sym, day, fmt.Sprintf("%s-%s.gob.gz", s.Format("150405"), endPart))
+}
+
+func (c *Cache) read(key string, v any) bool {
+	path := filepath.Join(c.dir, key)
+	f, err := os.Open(path)
+	if err != nil {
+		c.mu.Lock()
+		c.stats.Misses++
+		c.mu.Unlock()
+		return false
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err == nil {
+		err = gob.NewDecoder(zr).Decode(v)
+	}
+	if err != nil {
+		// corrupt entry: drop it and refetch
+		_ = os.Remove(path)
+		c.mu.Lock()
+		c.stats.Misses++
+		c.stats.Errors++
+		c.forgetLocked(key)
+		c.mu.Unlock()
+		return false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	c.mu.Lock()
+	c.stats.Hits++
+	if en, ok := c.entries[key]; ok {
+		en.usedAt = now
+		c.entries[key] = en
+	}
+	c.mu.Unlock()
+	return true
+}
+
+func (c *Cache) write(key string, v any) {
+	path := filepath.Join(c.dir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		c.countError()
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		c.countError()
+		return
+	}
+	zw := gzip.NewWriter(tmp)
+	err = gob.NewEncoder(zw).Encode(v)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		c.countError()
+		return
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		c.countError()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forgetLocked(key)
+	c.entries[key] = entry{size: fi.Size(), usedAt: time.Now()}
+	c.bytes += fi.Size()
+	c.stats.Writes++
+	c.evictLocked()
+}
+
+func (c *Cache) countError() {
+	c.mu.Lock()
+	c.stats.Errors++
+	c.mu.Unlock()
+}
+
+func (c *Cache) forgetLocked(key string) {
+	if en, ok := c.entries[key]; ok {
+		c.bytes -= en.size
+		delete(c.entries, key)
+	}
+}
+
+// evictLocked removes least-recently-used entries until the cache is under 90% of its cap.
+func (c *Cache) evictLocked() {
+	if c.maxBytes <= 0 || c.bytes <= c.maxBytes {
+		return
+	}
+	target := c.maxBytes * 9 / 10
+
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].usedAt.Before(c.entries[keys[j]].usedAt)
+	})
+
+	for _, k := range keys {
+		if c.bytes <= target {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, k)); err != nil && !os.IsNotExist(err) {
+			c.stats.Errors++
+			continue
+		}
+		c.forgetLocked(k)
+		c.stats.Evictions++
+	}
+}
+
+// loadIndex walks dir to rebuild sizes and last-use times (file mtime is bumped on every hit).
+func (c *Cache) loadIndex() error {
+	return filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".tmp-") {
+			_ = os.Remove(path)
+			return nil
+		}
+		if !strings.HasSuffix(name, ".gob.gz") {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return nil
+		}
+		c.entries[rel] = entry{size: fi.Size(), usedAt: fi.ModTime()}
+		c.bytes += fi.Size()
+		return nil
+	})
+}
@@ -0,0 +1,167 @@
+package reportdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"massive-orb/internal/store"
+)
+
+const (
+	KindSession = "session"
+	KindRange   = "range"
+)
+
+var (
+	bucketMeta    = []byte("meta")    // id -> Meta (cheap listing)
+	bucketRecords = []byte("records") // id -> Record
+
+	ErrNotFound = errors.New("report not found")
+)
+
+// Meta is the list view of a saved report.
+type Meta struct {
+	ID      string                `json:"id"`
+	SavedAt time.Time             `json:"saved_at"`
+	Kind    string                `json:"kind"`    // session | range
+	DateNY  string                `json:"date_ny"` // session date, or "from → to" for ranges
+	Note    string                `json:"note,omitempty"`
+	Summary store.HistoricSummary `json:"summary"`
+}
+
+// Record is everything needed to reopen a run: the report plus the filters and config it ran under.
+type Record struct {
+	Meta
+
+	Filters       store.RuntimeFilters `json:"filters"`
+	TakeProfitPct float64              `json:"take_profit_pct"`
+	StopLossPct   float64              `json:"stop_loss_pct"`
+	ConfigYAML    string               `json:"config_yaml"`
+
+	Report *store.HistoricReport      `json:"report,omitempty"`
+	Range  *store.HistoricRangeReport `json:"range,omitempty"`
+}
+
+// DB is an embedded (bbolt) store of historic reports, keyed by time-ordered IDs.
+type DB struct {
+	db *bolt.DB
+
+	mu   sync.Mutex
+	last int64 // last ID (unix ns), keeps IDs unique within a process
+}
+
+func Open(path string) (*DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketMeta); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketRecords)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+func (d *DB) Close() error { return d.db.Close() }
+
+// Save assigns rec an ID and SavedAt and writes it. IDs sort chronologically.
+func (d *DB) Save(rec *Record) (string, error) {
+	d.mu.Lock()
+	now := time.Now()
+	n := now.UnixNano()
+	if n <= d.last {
+		n = d.last + 1
+	}
+	d.last = n
+	d.mu.Unlock()
+
+	rec.ID = fmt.Sprintf("%019d", n)
+	rec.SavedAt = now
+
+	metaJSON, err := json.Marshal(rec.Meta)
+	if err != nil {
+		return "", err
+	}
+	recJSON, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketMeta).Put([]byte(rec.ID), metaJSON); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketRecords).Put([]byte(rec.ID), recJSON)
+	})
+	if err != nil {
+		return "", err
+	}
+	return rec.ID, nil
+}
+
+// List returns saved reports newest first (limit <= 0 means all).
+func (d *DB) List(limit int) ([]Meta, error) {
+	out := make([]Meta, 0, 64)
+	err := d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketMeta).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var m Meta
+			if err := json.Unmarshal(v, &m); err != nil {
+				continue
+			}
+			out = append(out, m)
+			if limit > 0 && len(out) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (d *DB) Get(id string) (*Record, error) {
+	var rec *Record
+	err := d.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketRecords).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		rec = &Record{}
+		return json.Unmarshal(v, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (d *DB) Delete(id string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketMeta).Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		if err := tx.Bucket(bucketMeta).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketRecords).Delete([]byte(id))
+	})
+}
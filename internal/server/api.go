@@ -25,11 +25,8 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 	// If times haven't been initialized yet (e.g. engine not started), set them once.
 	openNY, selNY, cutoffNY, exitNY := s.st.Times()
 	if openNY.IsZero() || selNY.IsZero() || cutoffNY.IsZero() || exitNY.IsZero() {
-		dayNY := time.Date(nowNY.Year(), nowNY.Month(), nowNY.Day(), 0, 0, 0, 0, loc)
-		openNY = atTimeInLoc(dayNY, s.cfg.Market.OpenTime, loc)
-		selNY = atTimeInLoc(dayNY, s.cfg.Market.SelectionTime, loc)
-		cutoffNY = atTimeInLoc(dayNY, s.cfg.Market.VWAPCrossCutoff, loc)
-		exitNY = atTimeInLoc(dayNY, s.cfg.Market.ForceExitTime, loc)
+		sess := s.eng.SessionFor(nowNY)
+		openNY, selNY, cutoffNY, exitNY = sess.OpenNY, sess.SelNY, sess.CutoffNY, sess.ExitNY
 		s.st.SetTimes(openNY, selNY, cutoffNY, exitNY)
 	}
 
@@ -86,6 +83,18 @@ type filtersPatch struct {
 	EntryPriceMin     *float64 `json:"entry_price_min"`
 	EntryPriceMax     *float64 `json:"entry_price_max"`
 
+	Side *string `json:"side"`
+
+	EntryMode       *string  `json:"entry_mode"`
+	BreakoutVolMult *float64 `json:"breakout_vol_mult"`
+
+	MaxSpreadPct *float64 `json:"max_spread_pct"`
+
+	GapPctMin       *float64 `json:"gap_pct_min"`
+	GapPctMax       *float64 `json:"gap_pct_max"`
+	PremarketVolMin *float64 `json:"premarket_vol_min"`
+
+	SoldOffScanTime          *string  `json:"sold_off_scan_time"`
 	SoldOffFromOpenPctMin    *float64 `json:"sold_off_from_open_pct_min"`
 	SoldOffOpen5mRangePctMin *float64 `json:"sold_off_open5m_range_pct_min"`
 	SoldOffOpen5mTodayPctMin *float64 `json:"sold_off_open5m_today_pct_min"`
@@ -139,7 +148,31 @@ func (s *Server) handleFilters(w http.ResponseWriter, r *http.Request) {
 			if p.EntryPriceMax != nil {
 				f.EntryPriceMax = *p.EntryPriceMax
 			}
+			if p.Side != nil {
+				f.Side = *p.Side
+			}
+			if p.EntryMode != nil {
+				f.EntryMode = *p.EntryMode
+			}
+			if p.BreakoutVolMult != nil {
+				f.BreakoutVolMult = *p.BreakoutVolMult
+			}
+			if p.MaxSpreadPct != nil {
+				f.MaxSpreadPct = *p.MaxSpreadPct
+			}
+			if p.GapPctMin != nil {
+				f.GapPctMin = *p.GapPctMin
+			}
+			if p.GapPctMax != nil {
+				f.GapPctMax = *p.GapPctMax
+			}
+			if p.PremarketVolMin != nil {
+				f.PremarketVolMin = *p.PremarketVolMin
+			}
 
+			if p.SoldOffScanTime != nil {
+				f.SoldOffScanTime = strings.TrimSpace(*p.SoldOffScanTime)
+			}
 			if p.SoldOffFromOpenPctMin != nil {
 				f.SoldOffFromOpenPctMin = *p.SoldOffFromOpenPctMin
 			}
@@ -174,6 +207,89 @@ func (s *Server) handleFilters(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ---------- /api/shadow (live vs shadow profiles) ----------
+
+func (s *Server) handleShadow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	profiles := s.eng.ProfileReports()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":         true,
+		"configured": len(s.cfg.ShadowProfiles),
+		"profiles":   profiles,
+	})
+}
+
+// ---------- /api/premarket?date=YYYY-MM-DD (pre-open gap scan) ----------
+
+func (s *Server) handlePremarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	loc := mustLoc(s.cfg.Market.Timezone)
+	dayNY := time.Now().In(loc)
+	if dateStr := strings.TrimSpace(r.URL.Query().Get("date")); dateStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+		if err != nil {
+			http.Error(w, "invalid date (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		dayNY = t
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	scan, err := s.eng.ScanPremarket(ctx, dayNY)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "scan": scan})
+}
+
+// ---------- /api/scan/soldoff?date=YYYY-MM-DD&until=10:00,10:30,11:00 ----------
+
+// handleScanSoldOff runs the sold-off scan on its own (no ORB replay), in either mode.
+// until is a comma-separated list of checkpoints; empty = the sold_off_scan_time filter.
+func (s *Server) handleScanSoldOff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	loc := mustLoc(s.cfg.Market.Timezone)
+	dayNY := time.Now().In(loc)
+	if dateStr := strings.TrimSpace(r.URL.Query().Get("date")); dateStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+		if err != nil {
+			http.Error(w, "invalid date (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		dayNY = t
+	}
+	var untils []string
+	for _, u := range strings.Split(r.URL.Query().Get("until"), ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			untils = append(untils, u)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 120*time.Second)
+	defer cancel()
+
+	scan, err := s.eng.ScanSoldOff(ctx, dayNY, untils)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "scan": scan})
+}
+
 // ---------- /api/historic/run?date=YYYY-MM-DD ----------
 
 func (s *Server) handleHistoricRun(w http.ResponseWriter, r *http.Request) {
@@ -191,13 +307,40 @@ func (s *Server) handleHistoricRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	loc := mustLoc(s.cfg.Market.Timezone)
+
+	// NEW: range backtest (?from=YYYY-MM-DD&to=YYYY-MM-DD)
+	fromISO := strings.TrimSpace(r.URL.Query().Get("from"))
+	toISO := strings.TrimSpace(r.URL.Query().Get("to"))
+	if fromISO != "" || toISO != "" {
+		if fromISO == "" || toISO == "" {
+			http.Error(w, "range needs both from and to", http.StatusBadRequest)
+			return
+		}
+		fromNY, err1 := time.ParseInLocation("2006-01-02", fromISO, loc)
+		toNY, err2 := time.ParseInLocation("2006-01-02", toISO, loc)
+		if err1 != nil || err2 != nil {
+			http.Error(w, "invalid from/to (use YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		if fromNY.After(toNY) {
+			http.Error(w, "from must be on or before to", http.StatusBadRequest)
+			return
+		}
+
+		s.QueueHistoricRange(fromNY, toNY)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		return
+	}
+
 	dateISO := strings.TrimSpace(r.URL.Query().Get("date"))
 	if dateISO == "" {
 		http.Error(w, "missing date", http.StatusBadRequest)
 		return
 	}
 
-	loc := mustLoc(s.cfg.Market.Timezone)
 	dayNY, err := time.ParseInLocation("2006-01-02", dateISO, loc)
 	if err != nil {
 		http.Error(w, "invalid date (use YYYY-MM-DD)", http.StatusBadRequest)
@@ -249,10 +392,26 @@ func (s *Server) eventPump(ctx context.Context) {
 
 // ---------- Historic run queue + loop ----------
 
+// historicRequest is a queued replay: a single day, or a from/to range when from is set.
+type historicRequest struct {
+	day  time.Time
+	from time.Time
+	to   time.Time
+}
+
 func (s *Server) QueueHistoricRun(dayNY time.Time) {
+	s.queueHistoric(historicRequest{day: dayNY})
+}
+
+// QueueHistoricRange queues a multi-session backtest over [fromNY, toNY].
+func (s *Server) QueueHistoricRange(fromNY, toNY time.Time) {
+	s.queueHistoric(historicRequest{from: fromNY, to: toNY})
+}
+
+func (s *Server) queueHistoric(req historicRequest) {
 	// non-blocking "latest wins"
 	select {
-	case s.histReqC <- dayNY:
+	case s.histReqC <- req:
 		return
 	default:
 		// channel full: drop the old request and replace it
@@ -261,7 +420,7 @@ func (s *Server) QueueHistoricRun(dayNY time.Time) {
 		default:
 		}
 		select {
-		case s.histReqC <- dayNY:
+		case s.histReqC <- req:
 		default:
 		}
 	}
@@ -278,19 +437,28 @@ func (s *Server) historicRunLoop(ctx context.Context) {
 		doneCh  chan struct{}
 	)
 
-	startRun := func(day time.Time) {
+	startRun := func(req historicRequest) {
 		runCtx, c := context.WithCancel(ctx)
 		cancel = c
 		doneCh = make(chan struct{})
 		running = true
 
-		go func(day time.Time) {
+		go func(req historicRequest) {
 			defer close(doneCh)
 
 			// normalize to NY date boundary
-			day = time.Date(day.In(loc).Year(), day.In(loc).Month(), day.In(loc).Day(), 0, 0, 0, 0, loc)
+			dayOnly := func(t time.Time) time.Time {
+				t = t.In(loc)
+				return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			}
 
-			if err := s.eng.RunHistoricForDate(runCtx, day); err != nil && runCtx.Err() == nil {
+			var err error
+			if !req.from.IsZero() {
+				err = s.eng.RunHistoricRange(runCtx, dayOnly(req.from), dayOnly(req.to))
+			} else {
+				err = s.eng.RunHistoricForDate(runCtx, dayOnly(req.day))
+			}
+			if err != nil && runCtx.Err() == nil {
 				now := time.Now().In(loc)
 				s.st.AddEvent(store.Event{
 					ID:      fmt.Sprintf("%d-SYSTEM-historic", now.UnixNano()),
@@ -300,7 +468,7 @@ func (s *Server) historicRunLoop(ctx context.Context) {
 					Level:   "warn",
 				})
 			}
-		}(day)
+		}(req)
 	}
 
 	for {
@@ -312,13 +480,13 @@ func (s *Server) historicRunLoop(ctx context.Context) {
 			}
 			return
 
-		case day := <-s.histReqC:
+		case req := <-s.histReqC:
 			if s.st.Mode() != store.ModeHistoric {
 				continue
 			}
 
 			if !running {
-				startRun(day)
+				startRun(req)
 				continue
 			}
 
@@ -332,11 +500,11 @@ func (s *Server) historicRunLoop(ctx context.Context) {
 			doneCh = nil
 
 			// Drain any extra queued requests and keep only the most recent
-			latest := day
+			latest := req
 			for {
 				select {
-				case d := <-s.histReqC:
-					latest = d
+				case r := <-s.histReqC:
+					latest = r
 				default:
 					goto runLatest
 				}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"massive-orb/internal/export"
+	"massive-orb/internal/store"
+)
+
+// GET /api/historic/export?format=csv|json&table=all|summary|trades|no_entries|sold_off|range_days|range_trades|...&id=
+//
+// Exports the report currently shown (or a saved one with ?id=) as a download.
+// table=all (the default) is a zip of CSVs, or one JSON document.
+func (s *Server) handleHistoricExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+
+	format := strings.ToLower(strings.TrimSpace(q.Get("format")))
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if format != export.FormatCSV && format != export.FormatJSON {
+		http.Error(w, "invalid format (csv|json)", http.StatusBadRequest)
+		return
+	}
+	table := strings.TrimSpace(q.Get("table"))
+	if table == "" {
+		table = export.TableAll
+	}
+
+	var (
+		rep *store.HistoricReport
+		rng *store.HistoricRangeReport
+	)
+	if id := strings.TrimSpace(q.Get("id")); id != "" {
+		if s.reports == nil {
+			writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "reports are disabled"})
+			return
+		}
+		rec, err := s.reports.Get(id)
+		if err != nil {
+			writeReportErr(w, err)
+			return
+		}
+		rep, rng = rec.Report, rec.Range
+	} else {
+		rep, rng = s.st.HistoricReports()
+	}
+	if rep == nil && rng == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "no historic report to export"})
+		return
+	}
+
+	tables := export.Tables(rep, rng)
+	prefix := export.Prefix(rep, rng)
+
+	// Render into a buffer first so a failure can still return a proper error status.
+	var (
+		buf         bytes.Buffer
+		err         error
+		filename    string
+		contentType string
+	)
+	switch {
+	case table == export.TableAll && format == export.FormatCSV:
+		filename, contentType = prefix+".zip", "application/zip"
+		err = export.WriteZip(&buf, prefix, tables)
+
+	case table == export.TableAll:
+		filename, contentType = prefix+".json", "application/json"
+		err = export.WriteJSON(&buf, export.NewDocument(rep, rng))
+
+	default:
+		t, ok := export.Find(tables, table)
+		if !ok {
+			http.Error(w, "unknown table: "+table+" (range reports only have the range_* tables)", http.StatusBadRequest)
+			return
+		}
+		filename = prefix + "_" + t.Name + "." + format
+		if format == export.FormatCSV {
+			contentType = "text/csv; charset=utf-8"
+			err = export.WriteCSV(&buf, t.Rows)
+		} else {
+			contentType = "application/json"
+			err = export.WriteJSON(&buf, t.Rows)
+		}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -7,15 +7,15 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/massive-com/client-go/v2/rest/models"
-
+	"massive-orb/internal/calendar"
 	"massive-orb/internal/config"
 	"massive-orb/internal/engine"
-	"massive-orb/internal/massive"
+	"massive-orb/internal/marketdata"
+	"massive-orb/internal/mdcache"
+	"massive-orb/internal/reportdb"
 	"massive-orb/internal/store"
 )
 
@@ -26,18 +26,23 @@ type Server struct {
 	cfg config.Config
 	st  *store.Store
 	hub *SSEHub
+	md  marketdata.MarketData
 
 	eng      *engine.Engine
-	histReqC chan time.Time
+	histReqC chan historicRequest
+
+	reports *reportdb.DB // nil when reports.enabled is false
 }
 
-func New(cfg config.Config, st *store.Store, eng *engine.Engine) *Server {
+func New(cfg config.Config, st *store.Store, eng *engine.Engine, md marketdata.MarketData, reports *reportdb.DB) *Server {
 	return &Server{
 		cfg:      cfg,
 		st:       st,
 		hub:      NewSSEHub(),
+		md:       md,
 		eng:      eng,
-		histReqC: make(chan time.Time, 1),
+		histReqC: make(chan historicRequest, 1),
+		reports:  reports,
 	}
 }
 
@@ -53,7 +58,17 @@ func (s *Server) Run(ctx context.Context) error {
 	mux.Handle("/api/events", s.hub)
 	mux.HandleFunc("/api/audio/", s.handleAudio)
 	mux.HandleFunc("/api/historic/run", s.handleHistoricRun)
+	mux.HandleFunc("/api/historic/export", s.handleHistoricExport)
 	mux.HandleFunc("/api/filters", s.handleFilters)
+	mux.HandleFunc("/api/cache", s.handleCache)
+	mux.HandleFunc("/api/reports", s.handleReports)
+	mux.HandleFunc("/api/reports/", s.handleReport)
+	mux.HandleFunc("/api/orders", s.handleOrders)
+	mux.HandleFunc("/api/orders/", s.handleOrder)
+	mux.HandleFunc("/api/positions", s.handlePositions)
+	mux.HandleFunc("/api/shadow", s.handleShadow)
+	mux.HandleFunc("/api/premarket", s.handlePremarket)
+	mux.HandleFunc("/api/scan/soldoff", s.handleScanSoldOff)
 
 	// NEW: chart bars for the “Of interest” slideshow
 	mux.HandleFunc("/api/chart/bars", s.handleChartBars)
@@ -79,6 +94,26 @@ func (s *Server) Run(ctx context.Context) error {
 	return srv.ListenAndServe()
 }
 
+// ---- market data cache stats ----
+
+func (s *Server) handleCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	c, ok := s.md.(*mdcache.Cache)
+	if !ok {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "enabled": false})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":      true,
+		"enabled": true,
+		"stats":   c.Stats(),
+	})
+}
+
 // ---- NEW: chart bars endpoint ----
 
 type chartBar struct {
@@ -130,76 +165,52 @@ func (s *Server) handleChartBars(w http.ResponseWriter, r *http.Request) {
 	}
 	dayNY = time.Date(dayNY.Year(), dayNY.Month(), dayNY.Day(), 0, 0, 0, 0, loc)
 
-	openNY := atTimeInLoc(dayNY, s.cfg.Market.OpenTime, loc)      // usually 09:30:00
-	exitNY := atTimeInLoc(dayNY, s.cfg.Market.ForceExitTime, loc) // usually 11:00:00
+	sess := s.eng.SessionFor(dayNY)
+	openNY := sess.OpenNY // usually 09:30:00
+	exitNY := sess.ExitNY // usually 11:00:00 (clamped to 13:00 on early-close days)
 
 	// Request 09:30 → 11:00 (range is [from,to))
 	endNY := exitNY
 
-	key := os.Getenv("MASSIVE_API_KEY")
-	if key == "" {
-		http.Error(w, "MASSIVE_API_KEY is missing on server", http.StatusInternalServerError)
-		return
-	}
-	rest := massive.NewREST(key)
-
 	ctx, cancel := context.WithTimeout(r.Context(), 25*time.Second)
 	defer cancel()
 
-	// Local helper: fetch 1m aggs and assign timestamps by index (start + i*1m).
+	// Local helper: fetch 1m bars from the market data provider.
 	listBars := func(startNY, endNY time.Time) ([]chartBar, error) {
-		params := models.ListAggsParams{
-			Ticker:     sym,
-			Multiplier: 1,
-			Timespan:   models.Minute,
-			From:       massive.ToMillis(startNY),
-			To:         massive.ToMillis(endNY),
+		bars, err := s.md.MinuteBars(ctx, sym, startNY, endNY)
+		if err != nil {
+			return nil, err
 		}
-		it := rest.ListAggs(ctx, &params)
 
-		out := make([]chartBar, 0, 64)
-		idx := 0
-		for it.Next() {
-			a := it.Item()
-			t := startNY.Add(time.Duration(idx) * time.Minute)
-			idx++
-
-			// Skip clearly-bad bars but keep the idx progression stable.
+		out := make([]chartBar, 0, len(bars))
+		for _, a := range bars {
+			// Skip clearly-bad bars.
 			if a.Open <= 0 || a.High <= 0 || a.Low <= 0 || a.Close <= 0 {
 				continue
 			}
 			out = append(out, chartBar{
-				Time:   t.Unix(),
+				Time:   a.Start.Unix(),
 				Open:   a.Open,
 				High:   a.High,
 				Low:    a.Low,
 				Close:  a.Close,
-				Volume: float64(a.Volume),
+				Volume: a.Volume,
 			})
 		}
-		if err := it.Err(); err != nil {
-			return nil, err
-		}
 		return out, nil
 	}
 
-	// Find previous session “last minute” bar (15:59→16:00) by searching backwards.
+	// Find previous session “last minute” bar (15:59→16:00, 12:59→13:00 on early closes) by searching backwards.
 	prevCloseDate := ""
 	var prevBar chartBar
 	gotPrev := false
 
-	d := dayNY.AddDate(0, 0, -1)
-	for tries := 0; tries < 15; tries++ {
-		// skip weekends quickly
-		for d.Weekday() == time.Saturday {
-			d = d.AddDate(0, 0, -1)
-		}
-		for d.Weekday() == time.Sunday {
-			d = d.AddDate(0, 0, -1)
-		}
+	d := dayNY
+	for tries := 0; tries < 5; tries++ {
+		d = calendar.PrevTradingDay(d)
 
-		closeStart := time.Date(d.Year(), d.Month(), d.Day(), 15, 59, 0, 0, loc)
-		closeEnd := closeStart.Add(1 * time.Minute)
+		closeEnd := atTimeInLoc(d, calendar.CloseHMS(d), loc)
+		closeStart := closeEnd.Add(-1 * time.Minute)
 
 		bs, err := listBars(closeStart, closeEnd)
 		if err == nil && len(bs) > 0 {
@@ -208,8 +219,6 @@ func (s *Server) handleChartBars(w http.ResponseWriter, r *http.Request) {
 			gotPrev = true
 			break
 		}
-
-		d = d.AddDate(0, 0, -1)
 	}
 
 	// Today session bars
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"massive-orb/internal/broker"
+)
+
+// ---- broker: orders + positions ----
+
+// GET  /api/orders → account + orders (newest first)
+// POST /api/orders → submit {symbol, side, type, qty, limit_price, stop_price}
+func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
+	b := s.eng.Broker()
+	if b == nil {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "broker is disabled"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "enabled": false, "orders": []broker.Order{}})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ok":      true,
+			"enabled": true,
+			"broker":  b.Name(),
+			"account": b.Account(),
+			"orders":  b.Orders(),
+		})
+
+	case http.MethodPost:
+		var req broker.OrderRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid JSON body"})
+			return
+		}
+		if req.Type == "" {
+			req.Type = broker.Market
+		}
+		if req.ClientID == "" {
+			req.ClientID = "MANUAL"
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		o, err := b.Submit(ctx, req)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"ok": false, "error": err.Error(), "order": o})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "order": o})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// DELETE /api/orders/{id} → cancel
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b := s.eng.Broker()
+	if b == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "broker is disabled"})
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/orders/"), "/")
+	if id == "" {
+		http.Error(w, "missing order id", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	if err := b.Cancel(ctx, id); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, broker.ErrOrderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, broker.ErrNotOpen):
+			status = http.StatusConflict
+		}
+		writeJSON(w, status, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// GET /api/positions → account + positions
+func (s *Server) handlePositions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b := s.eng.Broker()
+	if b == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "enabled": false, "positions": []broker.Position{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":        true,
+		"enabled":   true,
+		"broker":    b.Name(),
+		"account":   b.Account(),
+		"positions": b.Positions(),
+	})
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"massive-orb/internal/reportdb"
+	"massive-orb/internal/store"
+)
+
+// ---- saved historic reports ----
+
+// GET /api/reports?limit=N → newest first
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reports == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "enabled": false, "reports": []reportdb.Meta{}})
+		return
+	}
+
+	limit := 200
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	list, err := s.reports.List(limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "enabled": true, "reports": list})
+}
+
+// GET    /api/reports/{id}       → full record
+// DELETE /api/reports/{id}
+// POST   /api/reports/{id}/open  → show it in the historic UI
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		writeJSON(w, http.StatusNotFound, map[string]any{"ok": false, "error": "reports are disabled"})
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reports/"), "/")
+	id, action, _ := strings.Cut(rest, "/")
+	if id == "" {
+		http.Error(w, "missing report id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		rec, err := s.reports.Get(id)
+		if err != nil {
+			writeReportErr(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "report": rec})
+
+	case action == "" && r.Method == http.MethodDelete:
+		if err := s.reports.Delete(id); err != nil {
+			writeReportErr(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+	case action == "open" && r.Method == http.MethodPost:
+		if s.st.Mode() != store.ModeHistoric {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "not in historic mode"})
+			return
+		}
+		rec, err := s.reports.Get(id)
+		if err != nil {
+			writeReportErr(w, err)
+			return
+		}
+		s.openSavedReport(rec)
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeReportErr(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, reportdb.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]any{"ok": false, "error": err.Error()})
+}
+
+// openSavedReport swaps the saved report into the store as if it had just been replayed.
+func (s *Server) openSavedReport(rec *reportdb.Record) {
+	loc := mustLoc(s.cfg.Market.Timezone)
+
+	dateISO := rec.DateNY
+	if rec.Range != nil {
+		dateISO = rec.Range.ToNY
+	}
+	day, _ := time.ParseInLocation("2006-01-02", dateISO, loc)
+
+	note := fmt.Sprintf("Saved report from %s (%s).", rec.SavedAt.In(loc).Format("2006-01-02 15:04"), rec.Kind)
+	s.st.ResetForHistoricRun(day, day, note)
+	s.st.SetPhase(store.PhaseClosed)
+	s.st.SetHistoricReport(rec.Report)
+	s.st.SetHistoricRangeReport(rec.Range)
+}
@@ -58,8 +58,16 @@ const f_entry_min = $("f_entry_min");
 const f_entry_max = $("f_entry_max");
 const f_px_min = $("f_px_min");
 const f_px_max = $("f_px_max");
+const f_side = $("f_side");
+const f_entry_mode = $("f_entry_mode");
+const f_bo_vol = $("f_bo_vol");
+const f_max_spread = $("f_max_spread");
+const f_gap_min = $("f_gap_min");
+const f_gap_max = $("f_gap_max");
+const f_pm_vol_min = $("f_pm_vol_min");
 
 // Sold-off scan filters
+const f_sold_time = $("f_sold_time");
 const f_sold_pct_min = $("f_sold_pct_min");
 const f_sold_rng_min = $("f_sold_rng_min");
 const f_sold_today_min = $("f_sold_today_min");
@@ -72,10 +80,22 @@ const histTodayBtn = $("histTodayBtn");
 const histLoadBtn = $("histLoadBtn");
 const historicSubtitle = $("historicSubtitle");
 const historicNote = $("historicNote");
+const rangeFromInput = $("rangeFrom");
+const rangeToInput = $("rangeTo");
+const rangeRunBtn = $("rangeRunBtn");
+const savedReportsRow = $("savedReportsRow");
+const savedReportsSelect = $("savedReportsSelect");
+const savedOpenBtn = $("savedOpenBtn");
+const savedDeleteBtn = $("savedDeleteBtn");
+const savedExportBtn = $("savedExportBtn");
+const exportTableSelect = $("exportTable");
+const exportCsvBtn = $("exportCsvBtn");
+const exportJsonBtn = $("exportJsonBtn");
 
 const seenEventIDs = new Set();
 let currentSessionID = null;
 let pendingHistoricRequest = false;
+let lastSavedReportsKey = "";
 let histMinISO = "";
 let histMaxISO = "";
 let lastState = null;
@@ -220,12 +240,33 @@ function fmtMoney(x) {
   const v = Math.abs(x);
   return `${sign}$${v.toFixed(2)}`;
 }
+// sideStats is "trades · win% · net" for one side of a summary.
+function sideStats(s, side) {
+  const n = s[`${side}_trades`] || 0;
+  if (!n) return "—";
+  const wr = s[`${side}_win_rate`];
+  return `${n} · ${isFinite(wr) ? (wr * 100).toFixed(1) + "%" : "—"} · ${fmtMoney(s[`${side}_net_pnl`])}`;
+}
+
+// exitBreakdown lists the non-zero exit counts of a summary ("PROFIT 3 · STOP 2 · TRAIL 1").
+function exitBreakdown(s) {
+  const parts = [
+    ["PROFIT", s.profit_exits],
+    ["STOP", s.stop_exits],
+    ["TRAIL", s.trail_exits],
+    ["BREAKEVEN", s.break_even_exits],
+    ["TIME_STOP", s.time_stop_exits],
+    ["TIME_EXIT", s.time_exits],
+  ].filter(([, n]) => n > 0).map(([k, n]) => `${k} ${n}`);
+  return parts.length ? parts.join(" · ") : "—";
+}
+
 function badge(status) {
   const s = (status || "").toUpperCase();
   let cls = "neutral";
-  if (s === "LONG" || s === "PROFIT") cls = "good";
-  else if (s === "STOP" || s === "STOP LOSS HIT") cls = "bad";
-  else if (s === "TIME_EXIT") cls = "neutral";
+  if (s === "LONG" || s === "SHORT" || s === "PROFIT" || s === "TRAIL") cls = "good";
+  else if (s === "STOP" || s === "STOP LOSS HIT" || s === "SOLD_OFF") cls = "bad";
+  else if (s === "TIME_EXIT" || s === "TIME_STOP" || s === "BREAKEVEN") cls = "neutral";
   else if (s === "SELECTED" || s === "TRACKING") cls = "warn";
   return `<span class="badge ${cls}">${status || "—"}</span>`;
 }
@@ -242,7 +283,7 @@ function setHistoricNote(text) {
 }
 
 function setHistoricControlsDisabled(disabled) {
-  for (const el of [historicDateInput, histPrevBtn, histNextBtn, histTodayBtn, histLoadBtn]) {
+  for (const el of [historicDateInput, histPrevBtn, histNextBtn, histTodayBtn, histLoadBtn, rangeFromInput, rangeToInput, rangeRunBtn, savedOpenBtn, savedDeleteBtn]) {
     if (el) el.disabled = !!disabled;
   }
 }
@@ -272,6 +313,13 @@ function intVal(el) {
   const v = parseInt(s, 10);
   return Number.isFinite(v) ? v : null;
 }
+// "HH:MM" or "HH:MM:SS" → "HH:MM:SS" (anything else is sent as-is for the server to reject)
+function hmsVal(el) {
+  if (!el) return null;
+  const s = String(el.value ?? "").trim();
+  if (s === "") return null;
+  return /^\d{1,2}:\d{2}$/.test(s) ? `${s.padStart(5, "0")}:00` : s;
+}
 
 async function applyFilters() {
   const payload = {
@@ -285,7 +333,15 @@ async function applyFilters() {
     entry_minutes_after_open_max: intVal(f_entry_max),
     entry_price_min: numVal(f_px_min),
     entry_price_max: numVal(f_px_max),
-
+    side: f_side.value,
+    entry_mode: f_entry_mode.value,
+    breakout_vol_mult: numVal(f_bo_vol),
+    max_spread_pct: numVal(f_max_spread),
+    gap_pct_min: numVal(f_gap_min),
+    gap_pct_max: numVal(f_gap_max),
+    premarket_vol_min: numVal(f_pm_vol_min),
+
+    sold_off_scan_time: hmsVal(f_sold_time),
     sold_off_from_open_pct_min: numVal(f_sold_pct_min),
     sold_off_open5m_range_pct_min: numVal(f_sold_rng_min),
     sold_off_open5m_today_pct_min: numVal(f_sold_today_min),
@@ -377,6 +433,113 @@ async function requestHistoricRun(dateISO) {
   }
 }
 
+// NEW: range backtest (server replays each session from → to)
+async function requestHistoricRange(fromISO, toISO) {
+  if (!fromISO || !toISO) return;
+  if (pendingHistoricRequest) return;
+  if (fromISO > toISO) {
+    setHistoricNote("Range start must be on or before the end.");
+    return;
+  }
+
+  pendingHistoricRequest = true;
+  setHistoricControlsDisabled(true);
+  if (rangeRunBtn) rangeRunBtn.textContent = "Running…";
+  setHistoricNote(`Starting range backtest ${fromISO} → ${toISO}…`);
+
+  try {
+    const res = await fetch(`/api/historic/run?from=${encodeURIComponent(fromISO)}&to=${encodeURIComponent(toISO)}`, {
+      method: "POST",
+      cache: "no-store",
+    });
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok) {
+      setHistoricNote(body?.error || `Failed to start range backtest (${res.status})`);
+    }
+  } catch (_) {
+    setHistoricNote("Failed to start range backtest (network error).");
+  } finally {
+    pendingHistoricRequest = false;
+    if (rangeRunBtn) rangeRunBtn.textContent = "Run range";
+  }
+}
+
+// NEW: saved reports (GET /api/reports, POST /api/reports/{id}/open, DELETE /api/reports/{id})
+async function loadSavedReports() {
+  if (!savedReportsSelect) return;
+  try {
+    const res = await fetch("/api/reports?limit=200", { cache: "no-store" });
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok || !body.enabled) {
+      if (savedReportsRow) savedReportsRow.style.display = "none";
+      return;
+    }
+    if (savedReportsRow) savedReportsRow.style.display = "";
+
+    const prev = savedReportsSelect.value;
+    const list = Array.isArray(body.reports) ? body.reports : [];
+    savedReportsSelect.innerHTML = list.length
+      ? list.map(m => {
+          const s = m.summary || {};
+          const saved = (m.saved_at || "").replace("T", " ").slice(0, 16);
+          const label = `${m.kind === "range" ? "Range " : ""}${m.date_ny} · ${s.trades_taken ?? 0} trades · ${fmtMoney(s.net_pnl)} · saved ${saved}`;
+          return `<option value="${m.id}">${label}</option>`;
+        }).join("")
+      : `<option value="">(none yet)</option>`;
+    if (prev && list.some(m => m.id === prev)) savedReportsSelect.value = prev;
+  } catch (_) {}
+}
+
+async function openSavedReport(id) {
+  if (!id) return;
+  try {
+    const res = await fetch(`/api/reports/${encodeURIComponent(id)}/open`, { method: "POST", cache: "no-store" });
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok) setHistoricNote(body?.error || `Failed to open saved report (${res.status})`);
+  } catch (_) {
+    setHistoricNote("Failed to open saved report (network error).");
+  }
+}
+
+async function deleteSavedReport(id) {
+  if (!id) return;
+  if (!confirm("Delete this saved report?")) return;
+  try {
+    const res = await fetch(`/api/reports/${encodeURIComponent(id)}`, { method: "DELETE", cache: "no-store" });
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok) setHistoricNote(body?.error || `Failed to delete saved report (${res.status})`);
+  } catch (_) {
+    setHistoricNote("Failed to delete saved report (network error).");
+  }
+  loadSavedReports();
+}
+
+// Fetch the export, then hand the blob to the browser under the server's filename.
+// (Errors come back as JSON and are shown in the historic note instead.)
+async function downloadExport(params) {
+  const url = `/api/historic/export?${new URLSearchParams(params).toString()}`;
+  try {
+    const res = await fetch(url, { cache: "no-store" });
+    if (!res.ok) {
+      const body = await res.json().catch(() => ({}));
+      setHistoricNote(body?.error || `Export failed (${res.status})`);
+      return;
+    }
+    const cd = res.headers.get("Content-Disposition") || "";
+    const m = cd.match(/filename="?([^";]+)"?/);
+    const blob = await res.blob();
+    const a = document.createElement("a");
+    a.href = URL.createObjectURL(blob);
+    a.download = m ? m[1] : "orb_export";
+    document.body.appendChild(a);
+    a.click();
+    a.remove();
+    setTimeout(() => URL.revokeObjectURL(a.href), 1000);
+  } catch (_) {
+    setHistoricNote("Export failed (network error).");
+  }
+}
+
 async function fetchState() {
   const res = await fetch("/api/state", { cache: "no-store" });
   return await res.json();
@@ -1251,20 +1414,22 @@ async function showSoldChartAt(idx) {
 function renderSoldOff(report, st) {
   if (!soldOffTitle || !soldOffHint || !soldOffBody) return;
 
-  soldOffTitle.textContent = "Sold off by 10:30";
-
   const f = st?.filters || {};
+  const scanAt = report?.sold_off_scan_ny || f.sold_off_scan_time || "10:30:00";
+  const scanHM = scanAt.slice(0, 5);
+  soldOffTitle.textContent = `Sold off by ${scanHM}`;
+
   const downMin = (typeof f.sold_off_from_open_pct_min === "number") ? f.sold_off_from_open_pct_min : null;
   const rngMin  = (typeof f.sold_off_open5m_range_pct_min === "number") ? f.sold_off_open5m_range_pct_min : null;
   const todayMin = (typeof f.sold_off_open5m_today_pct_min === "number") ? f.sold_off_open5m_today_pct_min : null;
 
-  let hint = "Historic scan for tickers that sold off hard from the 09:30 open by 10:30.";
+  let hint = `Historic scan for tickers that sold off hard from the 09:30 open by ${scanHM}.`;
   if (downMin !== null && rngMin !== null && todayMin !== null) {
-    hint = `Down ≥ ${fmtPct(downMin)} from the 09:30 open by 10:30, with Open5m Range% ≥ ${fmtPct(rngMin)} and Open5m Today% ≥ ${todayMin}%.`;
+    hint = `Down ≥ ${fmtPct(downMin)} from the 09:30 open by ${scanHM}, with Open5m Range% ≥ ${fmtPct(rngMin)} and Open5m Today% ≥ ${todayMin}%.`;
   }
 
   const end = report?.summary?.window_end_ny || "";
-  if (end && end < "10:30:00") {
+  if (end && end < scanAt) {
     hint += ` (Data ends at ${end}, so this scan may be incomplete.)`;
   }
   soldOffHint.textContent = hint;
@@ -1331,6 +1496,70 @@ function renderSoldOff(report, st) {
     `;
     soldOffBody.appendChild(tr);
   }
+
+  renderRebound(report);
+}
+
+// NEW: rebound backtest on the sold-off names. Performance view only: it gives away the afternoon.
+function renderRebound(report) {
+  const wrap = $("reboundWrap");
+  if (!wrap) return;
+  const rb = report?.rebound;
+  if (!rb || !showHistoricPerformance) {
+    wrap.style.display = "none";
+    return;
+  }
+  wrap.style.display = "";
+
+  $("reboundHint").textContent = rb.note ? `${rb.rule} — ${rb.note}` : (rb.rule || "");
+
+  const s = rb.summary || {};
+  const metrics = [
+    ["Sold-off names", s.candidates],
+    ["Trades", s.trades_taken],
+    ["No-entry", s.no_entry],
+    ["Win rate", isFinite(s.win_rate) ? (s.win_rate * 100).toFixed(1) + "%" : "—"],
+    ["Exits", exitBreakdown(s)],
+    ["Gross P/L", fmtMoney(s.gross_pnl)],
+    ["Costs", fmtMoney(s.total_costs)],
+    ["Net P/L", fmtMoney(s.net_pnl)],
+    ["Profit factor", isFinite(s.profit_factor) ? s.profit_factor.toFixed(2) : "—"],
+    ["Avg trade", fmtPct(s.avg_return_pct)],
+    ["Best trade", fmtPct(s.best_trade_pct)],
+    ["Worst trade", fmtPct(s.worst_trade_pct)],
+  ];
+  $("reboundSummary").innerHTML = metrics.map(([k,v]) => `
+    <div class="metric">
+      <span>${k}</span>
+      <strong>${v ?? "—"}</strong>
+    </div>
+  `).join("");
+
+  const tb = $("reboundBody");
+  tb.innerHTML = "";
+  for (const t of (Array.isArray(rb.trades) ? rb.trades : [])) {
+    const tr = document.createElement("tr");
+    tr.className = t.realized_pnl_pct > 0 ? "pos" : t.realized_pnl_pct < 0 ? "neg" : "flat";
+    tr.innerHTML = `
+      <td><strong>${t.symbol}</strong></td>
+      <td>${t.entry_time_ny}</td>
+      <td>${fmt(t.entry_price, 4)}</td>
+      <td>${fmtInt(t.shares)}</td>
+      <td>${fmt(t.take_profit_price, 4)}</td>
+      <td>${fmt(t.stop_price, 4)}</td>
+      <td>${t.exit_time_ny}</td>
+      <td>${fmt(t.exit_price, 4)}</td>
+      <td>${badge(t.exit_reason)}</td>
+      <td>${fmtMoney(t.gross_pnl)}</td>
+      <td>${fmtMoney((t.slippage || 0) + (t.commission || 0) + (t.fees || 0))}</td>
+      <td>${fmtPct(t.realized_pnl_pct)}</td>
+      <td>${fmtMoney(t.realized_pnl)}</td>
+      <td>${fmtPct(t.mfe_pnl_pct)}</td>
+      <td>${fmtPct(t.mae_pnl_pct)}</td>
+      <td>${fmtPct(t.hold_pnl_pct)}</td>
+    `;
+    tb.appendChild(tr);
+  }
 }
 
 function renderHistoric(report, mode, st) {
@@ -1536,10 +1765,15 @@ function renderHistoric(report, mode, st) {
     ["Candidates", s.candidates],
     ["Trades", s.trades_taken],
     ["No-entry", s.no_entry],
+    ["Excluded prints", s.excluded_trades ?? 0],
     ["Win rate", isFinite(s.win_rate) ? (s.win_rate * 100).toFixed(1) + "%" : "—"],
     ["Wins", s.wins],
     ["Losses", s.losses],
-    ["TIME_EXIT", s.time_exits],
+    ["Exits", exitBreakdown(s)],
+    ["Long", sideStats(s, "long")],
+    ["Short", sideStats(s, "short")],
+    ["Gross P/L", fmtMoney(s.gross_pnl)],
+    ["Costs", fmtMoney(s.total_costs)],
     ["Net P/L", fmtMoney(s.net_pnl)],
     ["Net return", fmtPct(s.net_return_pct)],
     ["Profit factor", isFinite(s.profit_factor) ? s.profit_factor.toFixed(2) : "—"],
@@ -1548,6 +1782,9 @@ function renderHistoric(report, mode, st) {
     ["Worst trade", fmtPct(s.worst_trade_pct)],
   ];
 
+  if ($("histSizing") && s.sizing) $("histSizing").textContent = s.sizing;
+  if ($("histCosts") && s.cost_model) $("histCosts").textContent = s.cost_model;
+
   const sumWrap = $("histSummary");
   sumWrap.innerHTML = metrics.map(([k,v]) => `
     <div class="metric">
@@ -1568,13 +1805,17 @@ function renderHistoric(report, mode, st) {
     tr.className = cls;
     tr.innerHTML = `
       <td><strong>${t.symbol}</strong></td>
+      <td>${t.side || "long"}</td>
       <td>${t.entry_time_ny}</td>
       <td>${fmt(t.entry_price, 4)}</td>
+      <td>${fmtInt(t.shares)}</td>
       <td>${fmt(t.take_profit_price, 4)}</td>
-      <td>${fmt(t.stop_price, 4)}</td>
+      <td>${fmt(t.stop_price, 4)}${t.final_stop_price && t.final_stop_price !== t.stop_price ? ` → ${fmt(t.final_stop_price, 4)}` : ""}</td>
       <td>${t.exit_time_ny}</td>
       <td>${fmt(t.exit_price, 4)}</td>
       <td>${badge(t.exit_reason)}</td>
+      <td>${fmtMoney(t.gross_pnl)}</td>
+      <td>${fmtMoney((t.slippage || 0) + (t.commission || 0) + (t.fees || 0))}</td>
       <td>${fmtPct(t.realized_pnl_pct)}</td>
       <td>${fmtMoney(t.realized_pnl)}</td>
       <td>${fmtPct(t.mfe_pnl_pct)}</td>
@@ -1610,6 +1851,88 @@ function renderHistoric(report, mode, st) {
   renderSoldOff(report, st);
 }
 
+// NEW: range backtest summary + per-day breakdown
+function renderHistoricRange(rng) {
+  const wrap = $("rangeWrap");
+  if (!wrap) return;
+  if (!rng || !showHistoricPerformance) {
+    wrap.style.display = "none";
+    return;
+  }
+  wrap.style.display = "";
+
+  if (rangeFromInput && rangeToInput && document.activeElement !== rangeFromInput && document.activeElement !== rangeToInput) {
+    if (!rangeFromInput.value) rangeFromInput.value = rng.from_ny || "";
+    if (!rangeToInput.value) rangeToInput.value = rng.to_ny || "";
+  }
+
+  $("rangeTitle").textContent = `Range backtest ${rng.from_ny} → ${rng.to_ny}${rng.running ? " (running…)" : ""}`;
+  const skipped = Array.isArray(rng.skipped) ? rng.skipped : [];
+  $("rangeHint").textContent = skipped.length
+    ? `Skipped (no session data / incomplete): ${skipped.join(", ")}`
+    : "Every weekday in the range had session data.";
+
+  const s = rng.summary || {};
+  const metrics = [
+    ["Sessions", rng.sessions],
+    ["Green / red / flat", `${rng.green_days} / ${rng.red_days} / ${rng.flat_days}`],
+    ["Trades", s.trades_taken],
+    ["Win rate", isFinite(s.win_rate) ? (s.win_rate * 100).toFixed(1) + "%" : "—"],
+    ["Exits", exitBreakdown(s)],
+    ["Long", sideStats(s, "long")],
+    ["Short", sideStats(s, "short")],
+    ["Gross P/L", fmtMoney(s.gross_pnl)],
+    ["Costs", fmtMoney(s.total_costs)],
+    ["Net P/L", fmtMoney(s.net_pnl)],
+    ["Net return", fmtPct(s.net_return_pct)],
+    ["Profit factor", isFinite(s.profit_factor) ? s.profit_factor.toFixed(2) : "—"],
+    ["Avg day", fmtMoney(rng.avg_day_pnl)],
+    ["Best day", fmtMoney(rng.best_day_pnl)],
+    ["Worst day", fmtMoney(rng.worst_day_pnl)],
+    ["Max drawdown", fmtMoney(rng.max_drawdown)],
+    ["Max DD date", rng.max_drawdown_date_ny || "—"],
+  ];
+  // NEW: rebound backtest across the sessions' sold-off names
+  const rb = rng.rebound?.summary;
+  if (rb) {
+    metrics.push(
+      ["Rebound trades", `${rb.trades_taken} of ${rb.candidates} sold off`],
+      ["Rebound win rate", isFinite(rb.win_rate) ? (rb.win_rate * 100).toFixed(1) + "%" : "—"],
+      ["Rebound net P/L", fmtMoney(rb.net_pnl)],
+    );
+  }
+  $("rangeSummary").innerHTML = metrics.map(([k,v]) => `
+    <div class="metric">
+      <span>${k}</span>
+      <strong>${v ?? "—"}</strong>
+    </div>
+  `).join("");
+
+  const eqByDate = new Map();
+  for (const p of (Array.isArray(rng.equity) ? rng.equity : [])) eqByDate.set(p.date_ny, p);
+
+  const tb = $("rangeDaysBody");
+  tb.innerHTML = "";
+  for (const d of (Array.isArray(rng.days) ? rng.days : [])) {
+    const p = eqByDate.get(d.date_ny) || {};
+    const tr = document.createElement("tr");
+    tr.className = d.net_pnl > 0 ? "pos" : d.net_pnl < 0 ? "neg" : "flat";
+    tr.innerHTML = `
+      <td><strong>${d.date_ny}</strong></td>
+      <td>${d.candidates}</td>
+      <td>${d.trades_taken}</td>
+      <td>${d.wins}</td>
+      <td>${d.losses}</td>
+      <td>${isFinite(d.win_rate) ? (d.win_rate * 100).toFixed(1) + "%" : "—"}</td>
+      <td>${fmtMoney(d.gross_pnl)}</td>
+      <td>${fmtMoney(d.net_pnl)}</td>
+      <td>${fmtMoney(p.equity)}</td>
+      <td>${fmtMoney(p.drawdown)}</td>
+    `;
+    tb.appendChild(tr);
+  }
+}
+
 function renderState(st) {
   lastState = st;
   $("now").textContent = st.now_ny;
@@ -1644,7 +1967,15 @@ function renderState(st) {
   syncInput(f_entry_max, f.entry_minutes_after_open_max);
   syncInput(f_px_min, f.entry_price_min);
   syncInput(f_px_max, f.entry_price_max);
-
+  syncInput(f_side, f.side);
+  syncInput(f_entry_mode, f.entry_mode);
+  syncInput(f_bo_vol, f.breakout_vol_mult);
+  syncInput(f_max_spread, f.max_spread_pct);
+  syncInput(f_gap_min, f.gap_pct_min);
+  syncInput(f_gap_max, f.gap_pct_max);
+  syncInput(f_pm_vol_min, f.premarket_vol_min);
+
+  syncInput(f_sold_time, f.sold_off_scan_time);
   syncInput(f_sold_pct_min, f.sold_off_from_open_pct_min);
   syncInput(f_sold_rng_min, f.sold_off_open5m_range_pct_min);
   syncInput(f_sold_today_min, f.sold_off_open5m_today_pct_min);
@@ -1681,6 +2012,14 @@ function renderState(st) {
 
   // Historic report
   renderHistoric(st.historic_report, mode, st);
+  renderHistoricRange(mode === "historic" ? st.historic_range : null);
+
+  // Saved reports list: refresh when a run finishes (a new report was just saved)
+  const savedKey = `${st.session_id}:${st.historic_report ? 1 : 0}:${st.historic_range?.running ? 1 : 0}`;
+  if (mode === "historic" && savedKey !== lastSavedReportsKey) {
+    lastSavedReportsKey = savedKey;
+    loadSavedReports();
+  }
 
   // Existing tickers table (still useful)
   const body = $("tickersBody");
@@ -1689,7 +2028,7 @@ function renderState(st) {
   const tickers = (st.tickers || []).slice().sort((a,b) => {
     const sa = (a.status||"").toUpperCase();
     const sb = (b.status||"").toUpperCase();
-    const score = (s) => s==="LONG"?0 : s==="PROFIT"?1 : s==="STOP"?2 : 9;
+    const score = (s) => (s==="LONG"||s==="SHORT")?0 : (s==="PROFIT"||s==="TRAIL")?1 : (s==="STOP"||s==="BREAKEVEN"||s==="TIME_STOP")?2 : 9;
     return score(sa) - score(sb);
   });
 
@@ -1700,19 +2039,147 @@ function renderState(st) {
       <td>${badge(t.status)}</td>
       <td>${fmt(t.last_price, 4)}</td>
       <td>${fmt(t.vwap, 4)}</td>
+      <td>${t.ask ? `${fmt(t.bid, 4)} / ${fmt(t.ask, 4)}` : "—"}</td>
+      <td>${t.ask ? fmtPct(t.spread_pct) : "—"}</td>
       <td>${fmt(t.minutes_after_open, 2)}</td>
+      <td>${t.prev_close ? fmtPct(t.gap_pct) : "—"}</td>
+      <td>${t.prev_close ? fmtInt(t.premarket_vol) : "—"}</td>
       <td>${fmt(t.open_0930, 4)}</td>
       <td>${fmtInt(t.open_5m_vol)}</td>
       <td>${fmtPct(t.open_5m_range_pct)}</td>
       <td>${isFinite(t.open_5m_today_pct) ? t.open_5m_today_pct.toFixed(1) + "%" : "—"}</td>
-      <td>${fmt(t.entry_price, 4)}</td>
+      <td>${fmt(t.entry_price, 4)}${t.fill_entry_price ? `<div class="hint" style="margin:0">fill ${fmt(t.fill_entry_price, 4)}</div>` : t.order_status ? `<div class="hint" style="margin:0">${t.order_status}</div>` : ""}</td>
       <td>${fmt(t.take_profit_price, 4)}</td>
-      <td>${fmt(t.stop_price, 4)}</td>
+      <td>${fmt(t.stop_price, 4)}${t.fill_exit_price ? `<div class="hint" style="margin:0">exit fill ${fmt(t.fill_exit_price, 4)}</div>` : ""}</td>
     `;
     body.appendChild(tr);
   }
 }
 
+// ---- Broker (orders + positions) ----
+function fmtClock(iso) {
+  if (!iso || iso.startsWith("0001-")) return "—";
+  const d = new Date(iso);
+  return isNaN(d) ? "—" : d.toLocaleTimeString("en-US", { timeZone: "America/New_York", hour12: false });
+}
+
+function renderBroker(pos, ord) {
+  const card = $("brokerCard");
+  if (!card) return;
+  if (!pos?.enabled) {
+    card.style.display = "none";
+    return;
+  }
+  card.style.display = "";
+  $("brokerTitle").textContent = `Broker (${pos.broker})`;
+
+  const a = pos.account || {};
+  $("brokerAccount").innerHTML = [
+    ["Equity", fmtMoney(a.equity)],
+    ["Cash", fmtMoney(a.cash)],
+    ["Buying power", fmtMoney(a.buying_power)],
+    ["Realized P/L", fmtMoney(a.realized_pnl)],
+  ].map(([k,v]) => `
+    <div class="metric">
+      <span>${k}</span>
+      <strong>${v ?? "—"}</strong>
+    </div>
+  `).join("");
+
+  const pb = $("positionsBody");
+  pb.innerHTML = "";
+  for (const p of (Array.isArray(pos.positions) ? pos.positions : [])) {
+    const tr = document.createElement("tr");
+    tr.innerHTML = `
+      <td><strong>${p.symbol}</strong></td>
+      <td>${fmtInt(p.qty)}</td>
+      <td>${p.qty ? fmt(p.avg_price, 4) : "—"}</td>
+      <td>${fmt(p.last_price, 4)}</td>
+      <td>${fmtMoney(p.market_value)}</td>
+      <td>${fmtMoney(p.unrealized_pnl)}</td>
+      <td>${fmtMoney(p.realized_pnl)}</td>
+    `;
+    pb.appendChild(tr);
+  }
+
+  const ob = $("ordersBody");
+  ob.innerHTML = "";
+  for (const o of (Array.isArray(ord?.orders) ? ord.orders : [])) {
+    const px = o.type === "limit" ? ` @ ${fmt(o.limit_price, 2)}` : o.type === "stop" ? ` @ ${fmt(o.stop_price, 2)}` : "";
+    const tr = document.createElement("tr");
+    tr.innerHTML = `
+      <td>${fmtClock(o.submitted_at)}</td>
+      <td><strong>${o.symbol}</strong></td>
+      <td>${o.side}</td>
+      <td>${o.type}${px}</td>
+      <td>${fmtInt(o.qty)}</td>
+      <td>${o.status}</td>
+      <td>${o.filled_qty ? fmt(o.filled_avg_price, 4) : "—"}</td>
+      <td>${fmtClock(o.filled_at)}</td>
+      <td>${o.reason || o.client_id || ""}</td>
+    `;
+    ob.appendChild(tr);
+  }
+}
+
+function renderShadow(res) {
+  const card = $("shadowCard");
+  if (!card) return;
+  const profiles = Array.isArray(res?.profiles) ? res.profiles : [];
+  if (!profiles.length) {
+    card.style.display = "none";
+    return;
+  }
+  card.style.display = "";
+
+  const tb = $("shadowBody");
+  tb.innerHTML = "";
+  for (const pr of profiles) {
+    const s = pr.report?.summary || {};
+    const trades = Array.isArray(pr.report?.trades) ? pr.report.trades : [];
+    const open = trades.filter(t => !t.exit_reason).length;
+    const tr = document.createElement("tr");
+    tr.className = s.net_pnl > 0 ? "pos" : s.net_pnl < 0 ? "neg" : "flat";
+    tr.innerHTML = `
+      <td><strong>${pr.name}</strong>${pr.live ? " (live)" : ""}</td>
+      <td>${s.candidates ?? 0}</td>
+      <td>${s.trades_taken ?? 0}</td>
+      <td>${open}</td>
+      <td>${isFinite(s.win_rate) && s.trades_taken ? (s.win_rate * 100).toFixed(1) + "%" : "—"}</td>
+      <td>${exitBreakdown(s)}</td>
+      <td>${fmtMoney(s.gross_pnl)}</td>
+      <td>${fmtMoney(s.total_costs)}</td>
+      <td>${fmtMoney(s.net_pnl)}</td>
+      <td>${isFinite(s.profit_factor) && s.profit_factor ? s.profit_factor.toFixed(2) : "—"}</td>
+      <td>${pr.final ? "end of day" : "running"}</td>
+    `;
+    tb.appendChild(tr);
+  }
+}
+
+async function shadowLoop() {
+  try {
+    const res = await fetch("/api/shadow", { cache: "no-store" }).then(r => r.json());
+    renderShadow(res);
+    // nothing to poll when no shadow profiles are configured
+    if (!res?.configured) return;
+  } catch (_) {}
+  setTimeout(shadowLoop, 3000);
+}
+
+async function brokerLoop() {
+  try {
+    const [pos, ord] = await Promise.all([
+      fetch("/api/positions", { cache: "no-store" }).then(r => r.json()),
+      fetch("/api/orders", { cache: "no-store" }).then(r => r.json()),
+    ]);
+    renderBroker(pos, ord);
+    // nothing to poll when order routing is off
+    if (!pos?.enabled) return;
+  } catch (_) {}
+  setTimeout(brokerLoop, 2000);
+}
+
 async function loop() {
   try {
     const st = await fetchState();
@@ -1735,8 +2202,9 @@ for (const el of [
   f_or_vol_min, f_or_vol_max,
   f_today_min, f_today_max,
   f_entry_min, f_entry_max,
-  f_px_min, f_px_max,
-  f_sold_pct_min, f_sold_rng_min, f_sold_today_min,
+  f_px_min, f_px_max, f_side,
+  f_entry_mode, f_bo_vol, f_max_spread, f_gap_min, f_gap_max, f_pm_vol_min,
+  f_sold_time, f_sold_pct_min, f_sold_rng_min, f_sold_today_min,
 ]) {
   if (!el) continue;
   el.addEventListener("input", () => markFilterDirty(el));
@@ -1785,6 +2253,38 @@ if (histNextBtn) {
   });
 }
 
+if (rangeRunBtn) {
+  rangeRunBtn.addEventListener("click", () => {
+    const fromISO = clampISO(rangeFromInput?.value);
+    const toISO = clampISO(rangeToInput?.value);
+    if (fromISO && toISO) requestHistoricRange(fromISO, toISO);
+  });
+}
+
+if (savedReportsSelect) {
+  // refresh the list whenever it is about to be used (new runs save reports in the background)
+  savedReportsSelect.addEventListener("focus", loadSavedReports);
+  loadSavedReports();
+}
+if (savedOpenBtn) {
+  savedOpenBtn.addEventListener("click", () => openSavedReport(savedReportsSelect?.value));
+}
+if (savedDeleteBtn) {
+  savedDeleteBtn.addEventListener("click", () => deleteSavedReport(savedReportsSelect?.value));
+}
+if (savedExportBtn) {
+  savedExportBtn.addEventListener("click", () => {
+    const id = savedReportsSelect?.value;
+    if (id) downloadExport({ id, format: "csv", table: "all" });
+  });
+}
+if (exportCsvBtn) {
+  exportCsvBtn.addEventListener("click", () => downloadExport({ format: "csv", table: exportTableSelect?.value || "all" }));
+}
+if (exportJsonBtn) {
+  exportJsonBtn.addEventListener("click", () => downloadExport({ format: "json", table: exportTableSelect?.value || "all" }));
+}
+
 // Charts UI wiring
 if (showChartsBtn) {
   showChartsBtn.addEventListener("click", () => {
@@ -1918,3 +2418,5 @@ if (soldChartRevealBtn) {
 
 connectEvents();
 loop();
+brokerLoop();
+shadowLoop();
@@ -42,43 +42,81 @@ type RuntimeFilters struct {
 	EntryPriceMin     float64 `json:"entry_price_min"`
 	EntryPriceMax     float64 `json:"entry_price_max"`
 
-	// Historic “sold off by 10:30” scan
+	Side string `json:"side"` // long | short | both
+
+	EntryMode       string  `json:"entry_mode"`        // vwap_cross | or_breakout
+	BreakoutVolMult float64 `json:"breakout_vol_mult"` // or_breakout: minute volume >= mult × avg open-5m minute (0 = off)
+
+	MaxSpreadPct float64 `json:"max_spread_pct"` // quoted spread / mid at entry must be <= this (0 = off; no quote = pass)
+
+	// NEW: premarket vs previous close (each bound 0 = off; unknown gap/volume fails an active bound)
+	GapPctMin       float64 `json:"gap_pct_min"`       // last premarket price / prev close - 1 >= this
+	GapPctMax       float64 `json:"gap_pct_max"`       // ... <= this (negative = gap-down scan)
+	PremarketVolMin float64 `json:"premarket_vol_min"` // 04:00 → open_time volume
+
+	// Historic “sold off by sold_off_scan_time” scan
+	SoldOffScanTime          string  `json:"sold_off_scan_time"` // "HH:MM:SS" NY
 	SoldOffFromOpenPctMin    float64 `json:"sold_off_from_open_pct_min"`
 	SoldOffOpen5mRangePctMin float64 `json:"sold_off_open5m_range_pct_min"`
 	SoldOffOpen5mTodayPctMin float64 `json:"sold_off_open5m_today_pct_min"`
 }
 
 type HistoricSummary struct {
-	DateNY        string  `json:"date_ny"`
-	WindowStartNY string  `json:"window_start_ny"`
-	WindowEndNY   string  `json:"window_end_ny"`
-	Shares        int     `json:"shares"`
-	Candidates    int     `json:"candidates"`
-	TradesTaken   int     `json:"trades_taken"`
-	NoEntry       int     `json:"no_entry"`
-	Wins          int     `json:"wins"`
-	Losses        int     `json:"losses"`
-	TimeExits     int     `json:"time_exits"`
-	WinRate       float64 `json:"win_rate"`
-	NetPnL        float64 `json:"net_pnl"`
-	TotalNotional float64 `json:"total_notional"`
-	NetReturnPct  float64 `json:"net_return_pct"`
-	AvgReturnPct  float64 `json:"avg_return_pct"`
-	AvgWinPct     float64 `json:"avg_win_pct"`
-	AvgLossPct    float64 `json:"avg_loss_pct"`
-	ProfitFactor  float64 `json:"profit_factor"`
-	BestTradePct  float64 `json:"best_trade_pct"`
-	WorstTradePct float64 `json:"worst_trade_pct"`
+	DateNY         string  `json:"date_ny"`
+	WindowStartNY  string  `json:"window_start_ny"`
+	WindowEndNY    string  `json:"window_end_ny"`
+	Shares         int     `json:"shares"`               // per trade when every trade has the same size, else 0
+	Sizing         string  `json:"sizing,omitempty"`     // sizing rule, e.g. "1000 shares" or "$200 risk per trade"
+	CostModel      string  `json:"cost_model,omitempty"` // e.g. "$0.005/sh · 5 bps slip · SEC/FINRA"
+	Candidates     int     `json:"candidates"`
+	TradesTaken    int     `json:"trades_taken"`
+	NoEntry        int     `json:"no_entry"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	TimeExits      int     `json:"time_exits"`
+	ProfitExits    int     `json:"profit_exits"`
+	StopExits      int     `json:"stop_exits"`
+	TrailExits     int     `json:"trail_exits"`
+	BreakEvenExits int     `json:"break_even_exits"`
+	TimeStopExits  int     `json:"time_stop_exits"`
+	WinRate        float64 `json:"win_rate"`
+	NetPnL         float64 `json:"net_pnl"`   // after costs
+	GrossPnL       float64 `json:"gross_pnl"` // perfect fills, no costs
+	TotalCosts     float64 `json:"total_costs"`
+	TotalNotional  float64 `json:"total_notional"`
+	NetReturnPct   float64 `json:"net_return_pct"`
+	AvgReturnPct   float64 `json:"avg_return_pct"`
+	AvgWinPct      float64 `json:"avg_win_pct"`
+	AvgLossPct     float64 `json:"avg_loss_pct"`
+	ProfitFactor   float64 `json:"profit_factor"`
+	BestTradePct   float64 `json:"best_trade_pct"`
+	WorstTradePct  float64 `json:"worst_trade_pct"`
+
+	// NEW: per side
+	LongTrades   int     `json:"long_trades"`
+	LongWins     int     `json:"long_wins"`
+	LongWinRate  float64 `json:"long_win_rate"`
+	LongNetPnL   float64 `json:"long_net_pnl"`
+	ShortTrades  int     `json:"short_trades"`
+	ShortWins    int     `json:"short_wins"`
+	ShortWinRate float64 `json:"short_win_rate"`
+	ShortNetPnL  float64 `json:"short_net_pnl"`
+
+	// NEW: prints dropped by trades.exclude_conditions across all candidates
+	ExcludedTrades int `json:"excluded_trades"`
 }
 
 type HistoricTrade struct {
+	DateNY                string  `json:"date_ny"` // session (entry day)
 	Symbol                string  `json:"symbol"`
+	Side                  string  `json:"side"` // long | short
 	EntryTimeNY           string  `json:"entry_time_ny"`
 	EntryPrice            float64 `json:"entry_price"`
 	EntryMinutesAfterOpen float64 `json:"entry_minutes_after_open"`
 
 	TakeProfitPrice float64 `json:"take_profit_price"`
-	StopPrice       float64 `json:"stop_price"`
+	StopPrice       float64 `json:"stop_price"`       // initial stop
+	FinalStopPrice  float64 `json:"final_stop_price"` // after trailing / break-even moves
 
 	ExitTimeNY           string  `json:"exit_time_ny"`
 	ExitPrice            float64 `json:"exit_price"`
@@ -86,9 +124,16 @@ type HistoricTrade struct {
 	ExitReason           string  `json:"exit_reason"`
 
 	Shares         int     `json:"shares"`
-	RealizedPnLPct float64 `json:"realized_pnl_pct"`
+	RealizedPnLPct float64 `json:"realized_pnl_pct"` // net of costs
 	RealizedPnL    float64 `json:"realized_pnl"`
 
+	// Perfect fills at the trigger prints, and what the cost model took off
+	GrossPnLPct float64 `json:"gross_pnl_pct"`
+	GrossPnL    float64 `json:"gross_pnl"`
+	Slippage    float64 `json:"slippage"`
+	Commission  float64 `json:"commission"`
+	Fees        float64 `json:"fees"` // SEC + FINRA TAF
+
 	// “What if held to cutoff”
 	HoldPrice  float64 `json:"hold_price"`
 	HoldPnLPct float64 `json:"hold_pnl_pct"`
@@ -123,10 +168,10 @@ type HistoricSoldOff struct {
 	Symbol string `json:"symbol"`
 
 	Open0930        float64 `json:"open_0930"`
-	LowPrice        float64 `json:"low_price"`           // low from 09:30 → 10:30
-	LowTimeNY       string  `json:"low_time_ny"`         // time of that low (NY)
-	PriceAtScanTime float64 `json:"price_at_scan_time"`  // approx px @ 10:30 (10:29→10:30 close)
-	DropPct         float64 `json:"drop_pct"`            // (open - low) / open
+	LowPrice        float64 `json:"low_price"`          // low from 09:30 up to the scan time (this checkpoint's)
+	LowTimeNY       string  `json:"low_time_ny"`        // time of that low (NY)
+	PriceAtScanTime float64 `json:"price_at_scan_time"` // approx px at the scan time (close of the minute before it)
+	DropPct         float64 `json:"drop_pct"`           // (open - low) / open
 
 	Open5mVol      float64 `json:"open_5m_vol"`
 	Open5mRangePct float64 `json:"open_5m_range_pct"`
@@ -138,6 +183,56 @@ type HistoricReport struct {
 	Trades    []HistoricTrade   `json:"trades"`
 	NoEntries []HistoricNoEntry `json:"no_entries"`
 	SoldOff   []HistoricSoldOff `json:"sold_off,omitempty"`
+
+	SoldOffScanNY string `json:"sold_off_scan_ny,omitempty"` // NEW: end of the sold-off scan window ("HH:MM:SS")
+
+	Rebound *HistoricRebound `json:"rebound,omitempty"` // NEW: rebound backtest on the SoldOff names
+}
+
+// HistoricRebound is the rebound (mean-reversion) backtest on the sold-off names: one long per
+// name after the scan time, target / stop, flat at the close. Summary.Candidates is the number
+// of sold-off names, NoEntry those that never triggered.
+type HistoricRebound struct {
+	Rule    string          `json:"rule"` // e.g. "VWAP reclaim after 10:30 · TP 5.00% / SL 3.00% · entries until 15:00, flat at 16:00"
+	Note    string          `json:"note,omitempty"`
+	Summary HistoricSummary `json:"summary"`
+	Trades  []HistoricTrade `json:"trades"`
+}
+
+// HistoricEquityPoint is the cumulative result after one session of a range backtest.
+type HistoricEquityPoint struct {
+	DateNY   string  `json:"date_ny"`
+	PnL      float64 `json:"pnl"`      // that session's net P&L
+	Equity   float64 `json:"equity"`   // cumulative net P&L
+	Drawdown float64 `json:"drawdown"` // equity - running peak (<= 0)
+}
+
+// HistoricRangeReport aggregates a multi-session backtest (from → to).
+// Summary carries the trade stats across every session; Days has one summary per session.
+type HistoricRangeReport struct {
+	FromNY  string `json:"from_ny"`
+	ToNY    string `json:"to_ny"`
+	Running bool   `json:"running"`
+
+	Summary HistoricSummary `json:"summary"`
+
+	Sessions  int      `json:"sessions"`
+	GreenDays int      `json:"green_days"`
+	RedDays   int      `json:"red_days"`
+	FlatDays  int      `json:"flat_days"`
+	Skipped   []string `json:"skipped,omitempty"` // trading days with no session data (or today, still running)
+
+	AvgDayPnL         float64 `json:"avg_day_pnl"`
+	BestDayPnL        float64 `json:"best_day_pnl"`
+	WorstDayPnL       float64 `json:"worst_day_pnl"`
+	MaxDrawdown       float64 `json:"max_drawdown"` // most negative equity - peak
+	MaxDrawdownDateNY string  `json:"max_drawdown_date_ny,omitempty"`
+
+	Days   []HistoricSummary     `json:"days"`
+	Equity []HistoricEquityPoint `json:"equity"`
+	Trades []HistoricTrade       `json:"trades"` // every session's trades, oldest session first
+
+	Rebound *HistoricRebound `json:"rebound,omitempty"` // NEW: rebound backtest across the sessions' sold-off names
 }
 
 type Event struct {
@@ -158,6 +253,11 @@ type PublicTicker struct {
 	Prev10AvgOpen5m float64 `json:"prev10_avg_open5m_vol"`
 	Open5mTodayPct  float64 `json:"open_5m_today_pct"`
 
+	// NEW: premarket
+	PrevClose    float64 `json:"prev_close,omitempty"`
+	GapPct       float64 `json:"gap_pct,omitempty"`
+	PremarketVol float64 `json:"premarket_vol,omitempty"`
+
 	SawCrossInWindow bool    `json:"saw_cross_in_window"`
 	FirstCrossTimeNY string  `json:"first_cross_time_ny,omitempty"`
 	FirstCrossPrice  float64 `json:"first_cross_price"`
@@ -165,9 +265,23 @@ type PublicTicker struct {
 	LastPrice        float64 `json:"last_price"`
 	MinutesAfterOpen float64 `json:"minutes_after_open"`
 	Status           string  `json:"status"`
+	Side             string  `json:"side,omitempty"`
 	EntryPrice       float64 `json:"entry_price"`
 	TakeProfitPrice  float64 `json:"take_profit_price"`
 	StopPrice        float64 `json:"stop_price"`
+
+	// NEW: live NBBO (after 09:35)
+	Bid       float64 `json:"bid,omitempty"`
+	Ask       float64 `json:"ask,omitempty"`
+	SpreadPct float64 `json:"spread_pct,omitempty"`
+
+	// NEW: prints ignored for VWAP/signals (trades.exclude_conditions)
+	ExcludedTrades int `json:"excluded_trades,omitempty"`
+
+	// NEW: broker fills (live order routing)
+	OrderStatus    string  `json:"order_status,omitempty"`
+	FillEntryPrice float64 `json:"fill_entry_price,omitempty"`
+	FillExitPrice  float64 `json:"fill_exit_price,omitempty"`
 }
 
 type TickerState struct {
@@ -186,6 +300,15 @@ type TickerState struct {
 	Prev10AvgOpen5mVol float64
 	Open5mTodayPct     float64
 
+	// NEW: premarket metrics (04:00 → open_time) vs the previous session's close
+	PrevClose       float64
+	PrevCloseDateNY string
+	PremarketHigh   float64
+	PremarketLow    float64
+	PremarketVol    float64
+	PremarketLast   float64
+	GapPct          float64 // PremarketLast / PrevClose - 1 (0 when either is unknown)
+
 	// tick tracking
 	CumPV float64
 	CumV  float64
@@ -201,6 +324,8 @@ type TickerState struct {
 
 	// trade lifecycle
 	HasPosition     bool
+	Side            string // long | short (set at entry)
+	Shares          int
 	EntryPrice      float64
 	EntryTime       time.Time
 	TakeProfitPrice float64
@@ -208,6 +333,43 @@ type TickerState struct {
 	Exited          bool
 	ExitReason      string
 
+	// NEW: stop management (trailing / break-even / time stop)
+	InitialStopPrice float64
+	StopKind         string // rule that set StopPrice: STOP | BREAKEVEN | TRAIL
+	TimeStopChecked  bool
+
+	// 1-minute bars built from prints, for the ATR trailing stop
+	BarStart     time.Time
+	BarHigh      float64
+	BarLow       float64
+	BarClose     float64
+	PrevBarClose float64
+	ATR          float64
+	ATRBars      int
+
+	// NEW: opening-range breakout (or_breakout entry mode)
+	VolMinute         time.Time // minute MinuteVol belongs to
+	MinuteVol         float64
+	BreakoutUpTime    time.Time // first print above ORHigh after 09:35
+	BreakoutUpPrice   float64
+	BreakoutDownTime  time.Time // first print below ORLow after 09:35
+	BreakoutDownPrice float64
+
+	// NEW: live NBBO (quotes stream from 09:35; zero in replays, which have no quotes)
+	Bid       float64
+	Ask       float64
+	BidSize   float64
+	AskSize   float64
+	SpreadPct float64 // (ask - bid) / mid
+	QuoteTime time.Time
+
+	// quoted fill prices (ask for buys, bid for sells) at entry / exit; 0 = no quote at the time
+	EntryQuotePrice float64
+	ExitQuotePrice  float64
+
+	// NEW: prints skipped because of an excluded trade condition (odd lot, out of sequence, ...)
+	ExcludedTrades int
+
 	// entry/exit details for reporting
 	EntryMinutesAfterOpen float64
 	ExitPrice             float64
@@ -225,6 +387,16 @@ type TickerState struct {
 	FirstCrossTime   time.Time
 	FirstCrossPrice  float64
 
+	// NEW: cross-down from above VWAP (short side)
+	SawCrossDownInWindow bool
+	FirstCrossDownTime   time.Time
+	FirstCrossDownPrice  float64
+
+	// broker sync: entry order status + actual fill prices (engine prices above are signal prices)
+	OrderStatus    string
+	FillEntryPrice float64
+	FillExitPrice  float64
+
 	Status string // UI-friendly badge
 }
 
@@ -233,6 +405,7 @@ type Store struct {
 
 	mode           Mode
 	historicReport *HistoricReport
+	historicRange  *HistoricRangeReport
 
 	filters RuntimeFilters
 
@@ -261,19 +434,20 @@ type Store struct {
 }
 
 type Snapshot struct {
-	NowNY           string          `json:"now_ny"`
-	Mode            Mode            `json:"mode"`
-	Phase           Phase           `json:"phase"`
-	WatchlistCount  int             `json:"watchlist_count"`
-	OpenTimeNY      string          `json:"open_time_ny"`
-	SelectionTimeNY string          `json:"selection_time_ny"`
-	VwapCutoffNY    string          `json:"vwap_cutoff_ny"`
-	ForceExitNY     string          `json:"force_exit_ny"`
-	TrackedCount    int             `json:"tracked_count"`
-	Tickers         []PublicTicker  `json:"tickers"`
-	Filters         RuntimeFilters  `json:"filters"`
-	Events          []Event         `json:"events"`
-	HistoricReport  *HistoricReport `json:"historic_report,omitempty"`
+	NowNY           string               `json:"now_ny"`
+	Mode            Mode                 `json:"mode"`
+	Phase           Phase                `json:"phase"`
+	WatchlistCount  int                  `json:"watchlist_count"`
+	OpenTimeNY      string               `json:"open_time_ny"`
+	SelectionTimeNY string               `json:"selection_time_ny"`
+	VwapCutoffNY    string               `json:"vwap_cutoff_ny"`
+	ForceExitNY     string               `json:"force_exit_ny"`
+	TrackedCount    int                  `json:"tracked_count"`
+	Tickers         []PublicTicker       `json:"tickers"`
+	Filters         RuntimeFilters       `json:"filters"`
+	Events          []Event              `json:"events"`
+	HistoricReport  *HistoricReport      `json:"historic_report,omitempty"`
+	HistoricRange   *HistoricRangeReport `json:"historic_range,omitempty"`
 
 	SessionID              string `json:"session_id"`
 	HistoricTargetDateNY   string `json:"historic_target_date_ny,omitempty"`
@@ -296,12 +470,26 @@ func runtimeFiltersFromConfig(cfg config.Config) RuntimeFilters {
 		EntryPriceMin:     cfg.Filters.EntryPriceMin,
 		EntryPriceMax:     cfg.Filters.EntryPriceMax,
 
+		Side: cfg.Filters.Side,
+
+		EntryMode:       cfg.Filters.EntryMode,
+		BreakoutVolMult: cfg.Filters.BreakoutVolMult,
+		MaxSpreadPct:    cfg.Filters.MaxSpreadPct,
+
+		GapPctMin:       cfg.Filters.GapPctMin,
+		GapPctMax:       cfg.Filters.GapPctMax,
+		PremarketVolMin: cfg.Filters.PremarketVolMin,
+
+		SoldOffScanTime:          cfg.Filters.SoldOffScanTime,
 		SoldOffFromOpenPctMin:    cfg.Filters.SoldOffFromOpenPctMin,
 		SoldOffOpen5mRangePctMin: cfg.Filters.SoldOffOpen5mRangePctMin,
 		SoldOffOpen5mTodayPctMin: cfg.Filters.SoldOffOpen5mTodayPctMin,
 	}
 }
 
+// Validate applies the same checks as POST /api/filters.
+func (f RuntimeFilters) Validate() error { return validateRuntimeFilters(f) }
+
 func validateRuntimeFilters(f RuntimeFilters) error {
 	if f.Open5mRangePctMin <= 0 || f.Open5mRangePctMax <= 0 || f.Open5mRangePctMax < f.Open5mRangePctMin {
 		return fmt.Errorf("open_5m_range_pct_min/max invalid")
@@ -318,7 +506,32 @@ func validateRuntimeFilters(f RuntimeFilters) error {
 	if f.EntryPriceMax < f.EntryPriceMin {
 		return fmt.Errorf("entry_price_min/max invalid")
 	}
+	switch f.Side {
+	case "long", "short", "both":
+	default:
+		return fmt.Errorf("side invalid (long|short|both)")
+	}
+	switch f.EntryMode {
+	case "vwap_cross", "or_breakout":
+	default:
+		return fmt.Errorf("entry_mode invalid (vwap_cross|or_breakout)")
+	}
+	if f.BreakoutVolMult < 0 {
+		return fmt.Errorf("breakout_vol_mult invalid (>=0)")
+	}
+	if f.MaxSpreadPct < 0 || f.MaxSpreadPct >= 1 {
+		return fmt.Errorf("max_spread_pct invalid (expected 0..1, 0 = off)")
+	}
+	if f.GapPctMin <= -1 || f.GapPctMax <= -1 || (f.GapPctMin != 0 && f.GapPctMax != 0 && f.GapPctMax < f.GapPctMin) {
+		return fmt.Errorf("gap_pct_min/max invalid (> -1, max >= min when both set, 0 = off)")
+	}
+	if f.PremarketVolMin < 0 {
+		return fmt.Errorf("premarket_vol_min invalid (>=0)")
+	}
 
+	if _, err := time.Parse("15:04:05", f.SoldOffScanTime); err != nil {
+		return fmt.Errorf("sold_off_scan_time invalid (HH:MM:SS)")
+	}
 	if f.SoldOffFromOpenPctMin <= 0 || f.SoldOffFromOpenPctMin >= 1 {
 		return fmt.Errorf("sold_off_from_open_pct_min invalid (expected 0..1)")
 	}
@@ -391,6 +604,61 @@ func (s *Store) SetHistoricReport(r *HistoricReport) {
 	s.historicReport = r
 }
 
+func (s *Store) HistoricNote() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.historicNote
+}
+
+// SetHistoricRangeReport publishes (or clears, with nil) the range backtest report.
+// It survives ResetForHistoricRun so the aggregate stays visible while each session replays.
+func (s *Store) SetHistoricRangeReport(r *HistoricRangeReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.historicRange = r
+}
+
+// HistoricReports returns copies of the latest session report and range report (either may be nil).
+func (s *Store) HistoricReports() (*HistoricReport, *HistoricRangeReport) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyHistoricReport(s.historicReport), copyHistoricRange(s.historicRange)
+}
+
+func copyHistoricReport(r *HistoricReport) *HistoricReport {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Trades = append([]HistoricTrade(nil), r.Trades...)
+	cp.NoEntries = append([]HistoricNoEntry(nil), r.NoEntries...)
+	cp.SoldOff = append([]HistoricSoldOff(nil), r.SoldOff...)
+	cp.Rebound = copyHistoricRebound(r.Rebound)
+	return &cp
+}
+
+func copyHistoricRange(r *HistoricRangeReport) *HistoricRangeReport {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Skipped = append([]string(nil), r.Skipped...)
+	cp.Days = append([]HistoricSummary(nil), r.Days...)
+	cp.Equity = append([]HistoricEquityPoint(nil), r.Equity...)
+	cp.Trades = append([]HistoricTrade(nil), r.Trades...)
+	cp.Rebound = copyHistoricRebound(r.Rebound)
+	return &cp
+}
+
+func copyHistoricRebound(r *HistoricRebound) *HistoricRebound {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Trades = append([]HistoricTrade(nil), r.Trades...)
+	return &cp
+}
+
 // ResetForHistoricRun clears volatile session state (tickers, events, report, audio)
 // and updates UI-facing historic metadata. Intended to be called at the start of each historic replay.
 func (s *Store) ResetForHistoricRun(targetDateNY, resolvedDateNY time.Time, note string) (sessionID string) {
@@ -527,6 +795,9 @@ func (s *Store) Snapshot(nowNY time.Time) Snapshot {
 			Open5mRangePct:   t.Open5mRangePct,
 			Prev10AvgOpen5m:  t.Prev10AvgOpen5mVol,
 			Open5mTodayPct:   t.Open5mTodayPct,
+			PrevClose:        t.PrevClose,
+			GapPct:           t.GapPct,
+			PremarketVol:     t.PremarketVol,
 			SawCrossInWindow: t.SawCrossInWindow,
 			FirstCrossTimeNY: func() string {
 				if t.FirstCrossTime.IsZero() {
@@ -539,20 +810,22 @@ func (s *Store) Snapshot(nowNY time.Time) Snapshot {
 			LastPrice:        t.LastPrice,
 			MinutesAfterOpen: t.MinutesAfterOpen,
 			Status:           t.Status,
+			Side:             t.Side,
 			EntryPrice:       t.EntryPrice,
 			TakeProfitPrice:  t.TakeProfitPrice,
 			StopPrice:        t.StopPrice,
+			Bid:              t.Bid,
+			Ask:              t.Ask,
+			SpreadPct:        t.SpreadPct,
+			ExcludedTrades:   t.ExcludedTrades,
+			OrderStatus:      t.OrderStatus,
+			FillEntryPrice:   t.FillEntryPrice,
+			FillExitPrice:    t.FillExitPrice,
 		})
 	}
 
-	var rep *HistoricReport
-	if s.historicReport != nil {
-		cp := *s.historicReport
-		cp.Trades = append([]HistoricTrade(nil), s.historicReport.Trades...)
-		cp.NoEntries = append([]HistoricNoEntry(nil), s.historicReport.NoEntries...)
-		cp.SoldOff = append([]HistoricSoldOff(nil), s.historicReport.SoldOff...)
-		rep = &cp
-	}
+	rep := copyHistoricReport(s.historicReport)
+	rng := copyHistoricRange(s.historicRange)
 
 	// Historic date picker bounds (NY)
 	//
@@ -589,6 +862,7 @@ func (s *Store) Snapshot(nowNY time.Time) Snapshot {
 		Filters:         s.filters,
 		Events:          events,
 		HistoricReport:  rep,
+		HistoricRange:   rng,
 
 		SessionID:              s.sessionID,
 		HistoricTargetDateNY:   tgt,
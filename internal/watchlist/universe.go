@@ -0,0 +1,162 @@
+package watchlist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"massive-orb/internal/calendar"
+	"massive-orb/internal/marketdata"
+)
+
+// UniverseFilter picks the watchlist out of the provider's ticker universe (config universe section).
+type UniverseFilter struct {
+	Types            []string // reference ticker types, e.g. CS
+	Exchanges        []string // primary exchange MICs; empty = any
+	PriceMin         float64  // last close; 0 = off
+	PriceMax         float64  // 0 = off
+	AvgVolumeMin     float64  // average daily volume over LookbackSessions; 0 = off
+	LookbackSessions int
+}
+
+// UniverseInfo records how a generated watchlist was built (the `universe` block of the file).
+type UniverseInfo struct {
+	AsOf      string  `yaml:"as_of"`    // last session of the grouped daily bars (YYYY-MM-DD)
+	BuiltAt   string  `yaml:"built_at"` // RFC 3339
+	Sessions  int     `yaml:"sessions"` // daily bars averaged for the volume
+	Listed    int     `yaml:"listed"`   // active tickers of the wanted types / exchanges
+	Kept      int     `yaml:"kept"`
+	PriceMin  float64 `yaml:"price_min,omitempty"`
+	PriceMax  float64 `yaml:"price_max,omitempty"`
+	VolumeMin float64 `yaml:"avg_volume_min,omitempty"`
+}
+
+// BuildUniverse lists the active tickers of f.Types on f.Exchanges and keeps those whose close on
+// asOf (a trading day, NY date) is inside the price range and whose average volume over the
+// f.LookbackSessions sessions ending at asOf reaches f.AvgVolumeMin. A ticker missing from a
+// session counts as zero volume that day. Symbols come back sorted.
+func BuildUniverse(ctx context.Context, ref marketdata.Reference, f UniverseFilter, asOf time.Time) ([]string, UniverseInfo, error) {
+	info := UniverseInfo{AsOf: asOf.Format("2006-01-02"), PriceMin: f.PriceMin, PriceMax: f.PriceMax, VolumeMin: f.AvgVolumeMin}
+	if !calendar.IsTradingDay(asOf) {
+		return nil, info, fmt.Errorf("%s is not a trading day", info.AsOf)
+	}
+
+	exch := make(map[string]bool, len(f.Exchanges))
+	for _, x := range f.Exchanges {
+		exch[strings.ToUpper(strings.TrimSpace(x))] = true
+	}
+	listed := make(map[string]bool, 8192)
+	for _, typ := range f.Types {
+		refs, err := ref.Tickers(ctx, typ)
+		if err != nil {
+			return nil, info, fmt.Errorf("list %s tickers: %w", typ, err)
+		}
+		for _, r := range refs {
+			sym := strings.ToUpper(strings.TrimSpace(r.Symbol))
+			if sym == "" || (len(exch) > 0 && !exch[strings.ToUpper(strings.TrimSpace(r.PrimaryExchange))]) {
+				continue
+			}
+			listed[sym] = true
+		}
+	}
+	info.Listed = len(listed)
+	if len(listed) == 0 {
+		return nil, info, errors.New("no active tickers for the configured types / exchanges")
+	}
+
+	lookback := max(f.LookbackSessions, 1)
+	closes := make(map[string]float64, len(listed))
+	vols := make(map[string]float64, len(listed))
+	d := asOf
+	for i := 0; i < lookback; i++ {
+		bars, err := ref.GroupedDaily(ctx, d)
+		if err != nil {
+			return nil, info, fmt.Errorf("grouped daily %s: %w", d.Format("2006-01-02"), err)
+		}
+		if i == 0 && len(bars) == 0 {
+			return nil, info, fmt.Errorf("no grouped daily bars for %s yet", info.AsOf)
+		}
+		for _, b := range bars {
+			sym := strings.ToUpper(strings.TrimSpace(b.Symbol))
+			if !listed[sym] {
+				continue
+			}
+			if i == 0 {
+				closes[sym] = b.Close
+			}
+			vols[sym] += b.Volume
+		}
+		info.Sessions++
+		d = calendar.PrevTradingDay(d)
+	}
+
+	out := make([]string, 0, len(closes))
+	for sym, px := range closes {
+		if px <= 0 || (f.PriceMin > 0 && px < f.PriceMin) || (f.PriceMax > 0 && px > f.PriceMax) {
+			continue
+		}
+		if f.AvgVolumeMin > 0 && vols[sym]/float64(info.Sessions) < f.AvgVolumeMin {
+			continue
+		}
+		out = append(out, sym)
+	}
+	sort.Strings(out)
+	info.Kept = len(out)
+	info.BuiltAt = time.Now().UTC().Format(time.RFC3339)
+	return out, info, nil
+}
+
+// UniverseAsOf returns the as_of of a generated watchlist file ("" when the file is missing,
+// hand-written or unreadable).
+func UniverseAsOf(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var wl WatchlistFile
+	if err := yaml.Unmarshal(b, &wl); err != nil || wl.Universe == nil {
+		return ""
+	}
+	return wl.Universe.AsOf
+}
+
+// Save writes syms as a watchlist file (same format Load reads) with the universe block on top.
+// The file is replaced atomically so a crash never leaves half a watchlist behind.
+func Save(path string, syms []string, info UniverseInfo) error {
+	wl := WatchlistFile{Universe: &info}
+	for _, s := range syms {
+		wl.Watchlist = append(wl.Watchlist, struct {
+			Symbol string `yaml:"symbol"`
+		}{Symbol: s})
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Generated from the provider's ticker universe (config universe section).\n")
+	buf.WriteString("# Edits are overwritten on the next refresh; set universe.enabled: false to keep a hand-written list.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&wl); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -8,6 +8,7 @@ import (
 )
 
 type WatchlistFile struct {
+	Universe  *UniverseInfo `yaml:"universe,omitempty"` // NEW: set when generated by BuildUniverse
 	Watchlist []struct {
 		Symbol string `yaml:"symbol"`
 	} `yaml:"watchlist"`